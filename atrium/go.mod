@@ -51,6 +51,7 @@ require (
 	k8s.io/klog/v2 v2.130.1
 	k8s.io/kubectl v0.35.3
 	kernel.org/pub/linux/libs/security/libcap/cap v1.2.70
+	sigs.k8s.io/yaml v1.6.0
 )
 
 require (
@@ -193,7 +194,6 @@ require (
 	sigs.k8s.io/json v0.0.0-20250730193827-2d320260d730 // indirect
 	sigs.k8s.io/kustomize/api v0.20.1 // indirect
 	sigs.k8s.io/kustomize/kyaml v0.20.1 // indirect
-	sigs.k8s.io/yaml v1.6.0 // indirect
 )
 
 require (
@@ -114,6 +114,12 @@ func TriggerAllChangeChannel(tfmContext *TrcFlowMachineContext, table string, ch
 			if tfContext, tfContextOk := tfmContext.FlowMap[flowcore.FlowNameType(table)]; tfContextOk {
 				tfmContext.FlowMapLock.RUnlock()
 				if slices.Contains(tfContext.ChangeIdKeys, changeIdKey) {
+					if tfmContext.IsChannelCdc() {
+						if len(tfContext.ChangeIdKeys) == 1 {
+							tfmContext.requeueChange(tfContext, []any{changeIDValue})
+						}
+						break
+					}
 					changeQuery := fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES (:id, current_timestamp())", tfContext.FlowHeader.SourceAlias, tfContext.ChangeFlowName)
 					bindings := map[string]sqle.Expression{
 						"id": sqlee.NewLiteral(changeIDValue, sqle.MustCreateStringWithDefaults(sqltypes.VarChar, 200)),
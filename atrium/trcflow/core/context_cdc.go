@@ -0,0 +1,170 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	sqle "github.com/dolthub/go-mysql-server/sql"
+	flowcore "github.com/trimble-oss/tierceron-core/v2/flow"
+)
+
+// Change data capture modes.  With CdcModeTrigger (the default) changes are
+// tracked by SQL triggers writing to <table>_Changes, which flows poll.  With
+// CdcModeChannel writes made through CallDBQuery publish the changed row ids
+// (TrcChangeId, or the bound change id columns) to an in-process queue
+// instead, and no triggers or _Changes tables are created.  Writes that bypass CallDBQuery (e.g. remote SQL clients) are only
+// captured in trigger mode.
+const (
+	CdcModeTrigger = "trigger"
+	CdcModeChannel = "channel"
+)
+
+// CdcModeEnv selects the change data capture mode for flow machines.
+const CdcModeEnv = "TRC_FLOW_CDC_MODE"
+
+// changeQueue holds the changed rows of a single table in the order they were
+// first published.  Each entry is a row in the same shape as the matching
+// _Changes table query: the id alone, a composite key, or the three data flow
+// statistic columns.  A row already pending is coalesced the way INSERT IGNORE
+// coalesces it in a _Changes table, so the queue holds at most one entry per
+// changed row and publishers never block.
+type changeQueue struct {
+	lock    sync.Mutex
+	pending [][]any
+	keys    map[string]bool
+}
+
+func changeKey(changedEntry []any) string {
+	return fmt.Sprintf("%#v", changedEntry)
+}
+
+// push adds a changed row unless it is already pending.
+func (queue *changeQueue) push(changedEntry []any) {
+	key := changeKey(changedEntry)
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	if queue.keys == nil {
+		queue.keys = map[string]bool{}
+	}
+	if queue.keys[key] {
+		return
+	}
+	queue.keys[key] = true
+	queue.pending = append(queue.pending, changedEntry)
+}
+
+// drain removes and returns the pending rows.
+func (queue *changeQueue) drain() [][]any {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	pending := queue.pending
+	queue.pending = nil
+	queue.keys = nil
+	if pending == nil {
+		return [][]any{}
+	}
+	return pending
+}
+
+func cdcModeFromEnv() string {
+	if strings.EqualFold(os.Getenv(CdcModeEnv), CdcModeChannel) {
+		return CdcModeChannel
+	}
+	return CdcModeTrigger
+}
+
+// IsChannelCdc returns true if changes are published in process.
+func (tfmContext *TrcFlowMachineContext) IsChannelCdc() bool {
+	return tfmContext.CdcMode == CdcModeChannel
+}
+
+func (tfmContext *TrcFlowMachineContext) getChangeQueue(table flowcore.FlowNameType) *changeQueue {
+	tfmContext.changeQueuesLock.Lock()
+	defer tfmContext.changeQueuesLock.Unlock()
+	if tfmContext.changeQueues == nil {
+		tfmContext.changeQueues = map[flowcore.FlowNameType]*changeQueue{}
+	}
+	queue, ok := tfmContext.changeQueues[table]
+	if !ok {
+		queue = &changeQueue{}
+		tfmContext.changeQueues[table] = queue
+	}
+	return queue
+}
+
+// PublishChange records a changed row for table and wakes the table's flow.
+func (tfmContext *TrcFlowMachineContext) PublishChange(table flowcore.FlowNameType, changedEntry []any) {
+	tfmContext.getChangeQueue(table).push(changedEntry)
+	if notificationFlowChannel, ok := tfmContext.ChannelMap[table]; ok {
+		notificationFlowChannel.Bcast(true)
+	}
+}
+
+// requeueChange puts back a change that couldn't be processed yet.  Unlike
+// PublishChange it doesn't wake the flow, the change is retried next cycle.
+func (tfmContext *TrcFlowMachineContext) requeueChange(tfContext *TrcFlowContext, changedEntry []any) {
+	tfmContext.getChangeQueue(flowcore.FlowNameType(tfContext.FlowHeader.TableName())).push(changedEntry)
+}
+
+// publishQueryChange publishes the row changed by a CallDBQuery write,
+// mirroring the rows the trigger fallback writes to _Changes.  The change ids
+// are taken from TrcChangeId if provided, otherwise from the bindings of the
+// table's change id columns.
+func (tfmContext *TrcFlowMachineContext) publishQueryChange(tfContext *TrcFlowContext, queryMap map[string]any, bindingsI map[string]any) {
+	table := flowcore.FlowNameType(tfContext.FlowHeader.TableName())
+	switch changeID := queryMap["TrcChangeId"].(type) {
+	case string:
+		tfmContext.PublishChange(table, []any{changeID})
+		return
+	case []string:
+		switch len(changeID) {
+		case 1:
+			tfmContext.PublishChange(table, []any{changeID[0]})
+			return
+		case 2:
+			tfmContext.PublishChange(table, []any{changeID[0], changeID[1]})
+			return
+		case 3:
+			tfmContext.PublishChange(flowcore.FlowNameType("DataFlowStatistics"), []any{changeID[0], changeID[1], changeID[2]})
+			return
+		}
+	}
+	if changedEntry, ok := tfmContext.boundChangeEntry(tfContext, bindingsI); ok {
+		tfmContext.PublishChange(table, changedEntry)
+		return
+	}
+	tfmContext.Log("Change to "+string(table)+" not captured, provide TrcChangeId or bind its change id columns.", errors.New("missing change id"))
+}
+
+// boundChangeEntry returns the values bound to the table's change id columns.
+func (tfmContext *TrcFlowMachineContext) boundChangeEntry(tfContext *TrcFlowContext, bindingsI map[string]any) ([]any, bool) {
+	if len(tfContext.ChangeIdKeys) == 0 || len(bindingsI) == 0 {
+		return nil, false
+	}
+	var ctx *sqle.Context
+	if tfmContext.TierceronEngine != nil {
+		ctx = tfmContext.TierceronEngine.Context
+	}
+	changedEntry := make([]any, 0, len(tfContext.ChangeIdKeys))
+	for _, changeIdKey := range tfContext.ChangeIdKeys {
+		binding, ok := bindingsI[changeIdKey].(sqle.Expression)
+		if !ok {
+			return nil, false
+		}
+		value, err := binding.Eval(ctx, nil)
+		if err != nil || value == nil {
+			return nil, false
+		}
+		changedEntry = append(changedEntry, fmt.Sprint(value))
+	}
+	return changedEntry, true
+}
+
+// drainChangedTableEntries removes and returns the queued changes for a flow,
+// without duplicates as INSERT IGNORE leaves none in _Changes tables.
+func (tfmContext *TrcFlowMachineContext) drainChangedTableEntries(tfContext *TrcFlowContext) [][]any {
+	return tfmContext.getChangeQueue(flowcore.FlowNameType(tfContext.FlowHeader.TableName())).drain()
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	sqle "github.com/dolthub/go-mysql-server/sql"
+	sqlee "github.com/dolthub/go-mysql-server/sql/expression"
+	"github.com/dolthub/vitess/go/sqltypes"
+	flowcore "github.com/trimble-oss/tierceron-core/v2/flow"
+)
+
+func TestChangeQueueCoalesces(t *testing.T) {
+	tfmContext := &TrcFlowMachineContext{CdcMode: CdcModeChannel}
+	tfContext := &TrcFlowContext{FlowHeader: &flowcore.FlowHeaderType{Name: "Widget", Instances: "*"}}
+	table := flowcore.FlowNameType(tfContext.FlowHeader.TableName())
+
+	tfmContext.PublishChange(table, []any{"1"})
+	tfmContext.PublishChange(table, []any{"2"})
+	tfmContext.PublishChange(table, []any{"1"})
+	tfmContext.PublishChange(table, []any{"3", "a"})
+	tfmContext.PublishChange("Other", []any{"1"})
+
+	changes := tfmContext.drainChangedTableEntries(tfContext)
+	if expected := [][]any{{"1"}, {"2"}, {"3", "a"}}; !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("changes %v, expected %v", changes, expected)
+	}
+	if changes := tfmContext.drainChangedTableEntries(tfContext); len(changes) != 0 {
+		t.Fatalf("changes %v after drain", changes)
+	}
+
+	// A change that failed is retried next cycle, once, after later changes.
+	tfmContext.PublishChange(table, []any{"4"})
+	tfmContext.requeueChange(tfContext, []any{"2"})
+	tfmContext.requeueChange(tfContext, []any{"4"})
+	changes = tfmContext.drainChangedTableEntries(tfContext)
+	if expected := [][]any{{"4"}, {"2"}}; !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("changes %v, expected %v", changes, expected)
+	}
+}
+
+func TestChangeQueueBurst(t *testing.T) {
+	queue := &changeQueue{}
+	var wg sync.WaitGroup
+	for publisher := 0; publisher < 8; publisher++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				queue.push([]any{fmt.Sprint(i % 100)})
+			}
+		}()
+	}
+	wg.Wait()
+	if changes := queue.drain(); len(changes) != 100 {
+		t.Fatalf("%d changes pending, expected 100", len(changes))
+	}
+}
+
+func TestPublishQueryChange(t *testing.T) {
+	tfmContext := &TrcFlowMachineContext{CdcMode: CdcModeChannel}
+	tfContext := &TrcFlowContext{FlowHeader: &flowcore.FlowHeaderType{Name: "Widget", Instances: "*"}, ChangeIdKeys: []string{"widgetId"}}
+	bindings := map[string]any{
+		"widgetId": sqlee.NewLiteral("3", sqle.MustCreateStringWithDefaults(sqltypes.VarChar, 200)),
+		"name":     sqlee.NewLiteral("gear", sqle.MustCreateStringWithDefaults(sqltypes.VarChar, 200)),
+	}
+
+	tfmContext.publishQueryChange(tfContext, map[string]any{"TrcChangeId": "1"}, nil)
+	tfmContext.publishQueryChange(tfContext, map[string]any{"TrcChangeId": []string{"2"}}, nil)
+	tfmContext.publishQueryChange(tfContext, map[string]any{}, bindings)
+	tfmContext.publishQueryChange(tfContext, map[string]any{"TrcChangeId": []string{"4", "a"}}, bindings)
+
+	changes := tfmContext.drainChangedTableEntries(tfContext)
+	if expected := [][]any{{"1"}, {"2"}, {"3"}, {"4", "a"}}; !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("changes %v, expected %v", changes, expected)
+	}
+}
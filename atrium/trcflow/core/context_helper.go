@@ -306,7 +306,9 @@ func (tfmContext *TrcFlowMachineContext) vaultPersistPushRemoteChanges(
 	var matrixChangedEntries [][]any
 	var removeErr error
 
-	if indexColumnNamesSlice, colOK := indexColumnNames.([]string); colOK {
+	if tfmContext.IsChannelCdc() {
+		matrixChangedEntries = tfmContext.drainChangedTableEntries(tfContext)
+	} else if indexColumnNamesSlice, colOK := indexColumnNames.([]string); colOK {
 		if len(indexColumnNamesSlice) == 3 { // TODO: Coercion???
 			matrixChangedEntries, removeErr = tfmContext.removeStatisticChangedTableEntries(tfContext, identityColumnNames, indexColumnNames)
 			if removeErr != nil {
@@ -484,9 +486,14 @@ func (tfmContext *TrcFlowMachineContext) vaultPersistPushRemoteChanges(
 			return trcdb.Query(engine.(*trcengine.TierceronEngine), query["TrcQuery"].(string), tfContext.QueryLock)
 		})
 		if indexPathErr != nil {
-			eUtils.LogErrorObject(tfmContext.DriverConfig.CoreConfig, err, false)
+			eUtils.LogErrorObject(tfmContext.DriverConfig.CoreConfig, indexPathErr, false)
 			// Re-inject into changes because it might not be here yet...
-			if !strings.Contains(indexPath, "PublicIndex") {
+			if tfmContext.IsChannelCdc() {
+				// Same as the trigger fallback, public index rows are only retried for statistics.
+				if !strings.Contains(indexPath, "PublicIndex") || len(changedEntry) == 3 {
+					tfmContext.requeueChange(tfContext, changedEntry)
+				}
+			} else if !strings.Contains(indexPath, "PublicIndex") {
 				_, _, _, err = trcdb.Query(tfmContext.TierceronEngine, getInsertChangeQuery(tfContext.FlowHeader.SourceAlias, tfContext.ChangeFlowName, changedID), tfContext.QueryLock)
 				if err != nil {
 					eUtils.LogErrorObject(tfmContext.DriverConfig.CoreConfig, err, false)
@@ -519,6 +526,10 @@ func (tfmContext *TrcFlowMachineContext) vaultPersistPushRemoteChanges(
 			if seedError != nil {
 				eUtils.LogErrorObject(tfmContext.DriverConfig.CoreConfig, seedError, false)
 				// Re-inject into changes because it might not be here yet...
+				if tfmContext.IsChannelCdc() {
+					tfmContext.requeueChange(tfContext, changedEntry)
+					continue
+				}
 				_, _, _, err = trcdb.Query(tfmContext.TierceronEngine, getInsertChangeQuery(tfContext.FlowHeader.SourceAlias, tfContext.ChangeFlowName, changedID), tfContext.QueryLock)
 				if err != nil {
 					eUtils.LogErrorObject(tfmContext.DriverConfig.CoreConfig, err, false)
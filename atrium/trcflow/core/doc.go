@@ -12,4 +12,9 @@
 // The package serves as the foundation for building complex data flow pipelines that
 // process and transform data between different systems, with support for both forward
 // and reverse data flows, change tracking, and error handling.
+//
+// Change tracking defaults to SQL triggers writing to <table>_Changes tables.
+// Setting TRC_FLOW_CDC_MODE=channel instead publishes changes made through
+// CallDBQuery to an in-process queue per table, without triggers, change
+// tables or polling.
 package core
@@ -99,6 +99,9 @@ type TrcFlowMachineContext struct {
 	PermissionChan            chan PermissionUpdate // This channel is used to alert for dynamic permissions when tables are loaded
 	apiHTTPClients            map[string]*http.Client
 	apiHTTPClientsMu          sync.RWMutex
+	CdcMode                   string // CdcModeTrigger or CdcModeChannel
+	changeQueues              map[flowcore.FlowNameType]*changeQueue
+	changeQueuesLock          sync.Mutex
 }
 
 var _ flowcore.FlowMachineContext = (*TrcFlowMachineContext)(nil)
@@ -215,8 +218,15 @@ func (tfmContext *TrcFlowMachineContext) Init(
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	if tfmContext.CdcMode == "" {
+		tfmContext.CdcMode = cdcModeFromEnv()
+	}
 	tfmContext.GetTableModifierLock().Lock()
 	for _, tableName := range tableNames {
+		if tfmContext.IsChannelCdc() {
+			// Changes are published in process, no change tables needed.
+			break
+		}
 		changeTableName := tableName + "_Changes"
 		if _, ok, _ := tfmContext.TierceronEngine.Database.GetTableInsensitive(tfmContext.TierceronEngine.Context, changeTableName); !ok {
 			tfmContext.LogInfo("Creating tierceron sql table: " + changeTableName)
@@ -379,6 +389,10 @@ func (tfmContext *TrcFlowMachineContext) CreateTableTriggers(tcflowContext flowc
 
 	// Workaround triggers not firing: 9/30/2022
 	tfContext.ChangeIdKeys = identityColumnNames
+	if tfmContext.IsChannelCdc() {
+		tfmContext.GetTableModifierLock().Unlock()
+		return
+	}
 
 	// Create triggers
 	var updTrigger sqle.TriggerDefinition
@@ -424,6 +438,10 @@ func (tfmContext *TrcFlowMachineContext) CreateTableTriggers(tcflowContext flowc
 // whenever a row in a table changes...
 func (tfmContext *TrcFlowMachineContext) CreateCompositeTableTriggers(tcflowContext flowcore.FlowContext, iden1 string, iden2 string, insertT func(string, string, string, string) string, updateT func(string, string, string, string) string, deleteT func(string, string, string, string) string) {
 	tfContext := tcflowContext.(*TrcFlowContext)
+	if tfmContext.IsChannelCdc() {
+		tfContext.ChangeIdKeys = []string{iden1, iden2}
+		return
+	}
 	// Create triggers
 	var updTrigger sqle.TriggerDefinition
 	var insTrigger sqle.TriggerDefinition
@@ -466,6 +484,10 @@ func (tfmContext *TrcFlowMachineContext) CreateDataFlowTableTriggers(tcflowConte
 	deleteT func(string, string, string, string, string) string,
 ) {
 	tfContext := tcflowContext.(*TrcFlowContext)
+	if tfmContext.IsChannelCdc() {
+		tfContext.ChangeIdKeys = []string{iden1, iden2, iden3}
+		return
+	}
 
 	// Create triggers
 	var updTrigger sqle.TriggerDefinition
@@ -940,7 +962,9 @@ func (tfmContext *TrcFlowMachineContext) CallDBQueryN(trcdbExchange *tccore.Trcd
 		if changed && len(matrix) > 0 {
 
 			// If triggers are ever fixed, this can be removed.
-			if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
+			if tfmContext.IsChannelCdc() {
+				tfmContext.publishQueryChange(tfContext, queryMap, bindingsI)
+			} else if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
 				changeQuery := fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES (:id, current_timestamp())", coreopts.BuildOptions.GetDatabaseName(flowcore.TrcDb), tfContext.ChangeFlowName)
 				bindings := map[string]sqle.Expression{
 					"id": sqlee.NewLiteral(changeIDValue, sqle.MustCreateStringWithDefaults(sqltypes.VarChar, 200)),
@@ -1035,7 +1059,9 @@ func (tfmContext *TrcFlowMachineContext) CallDBQueryN(trcdbExchange *tccore.Trcd
 		}
 		if changed && (len(matrix) > 0 || tableName != "") {
 			// If triggers are ever fixed, this can be removed.
-			if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
+			if tfmContext.IsChannelCdc() {
+				tfmContext.publishQueryChange(tfContext, queryMap, bindingsI)
+			} else if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
 				var changeQuery string
 				if strings.Contains(tfContext.ChangeFlowName, flowcore.TierceronControllerFlow.FlowName()) {
 					changeQuery = fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES (:id, current_timestamp())", "FlumeDatabase", tfContext.ChangeFlowName)
@@ -1188,7 +1214,9 @@ func (tfmContext *TrcFlowMachineContext) CallDBQuery(tcflowContext flowcore.Flow
 		if changed && len(matrix) > 0 {
 
 			// If triggers are ever fixed, this can be removed.
-			if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
+			if tfmContext.IsChannelCdc() {
+				tfmContext.publishQueryChange(tfContext, queryMap, bindingsI)
+			} else if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
 				changeQuery := fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES (:id, current_timestamp())", coreopts.BuildOptions.GetDatabaseName(flowcore.TrcDb), tfContext.ChangeFlowName)
 				bindings := map[string]sqle.Expression{
 					"id": sqlee.NewLiteral(changeIDValue, sqle.MustCreateStringWithDefaults(sqltypes.VarChar, 200)),
@@ -1278,7 +1306,9 @@ func (tfmContext *TrcFlowMachineContext) CallDBQuery(tcflowContext flowcore.Flow
 		}
 		if changed && (len(matrix) > 0 || tableName != "") {
 			// If triggers are ever fixed, this can be removed.
-			if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
+			if tfmContext.IsChannelCdc() {
+				tfmContext.publishQueryChange(tfContext, queryMap, bindingsI)
+			} else if changeIDValue, changeIDValueOk := queryMap["TrcChangeId"].(string); changeIDValueOk {
 				var changeQuery string
 				if strings.Contains(tfContext.ChangeFlowName, flowcore.TierceronControllerFlow.FlowName()) {
 					changeQuery = fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES (:id, current_timestamp())", "FlumeDatabase", tfContext.ChangeFlowName)
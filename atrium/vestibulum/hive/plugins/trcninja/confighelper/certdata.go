@@ -2,7 +2,6 @@ package confighelper
 
 import (
 	"errors"
-	"html/template"
 	"io/ioutil"
 	"strings"
 	"text/template/parse"
@@ -27,19 +26,21 @@ func GetHost(templatePath string) (string, error) {
 		return "", errors.New("template file is empty")
 	}
 
-	// Parse template
-	t := template.New("template")
-	theTemplate, err := t.Parse(newTemplate)
+	// Parse template.  It is never executed, so functions trcconfig provides
+	// to templates (b64enc, include...) are accepted without being defined.
+	tree := parse.New("template")
+	tree.Mode = parse.SkipFuncCheck
+	theTree, err := tree.Parse(newTemplate, "", "", map[string]*parse.Tree{})
 	if err != nil {
 		return "", err
 	}
 
 	// Defensive: Check if Tree or Root is nil
-	if theTemplate.Tree == nil || theTemplate.Tree.Root == nil {
+	if theTree == nil || theTree.Root == nil {
 		return "", errors.New("template tree or root is nil")
 	}
 
-	commandList := theTemplate.Tree.Root
+	commandList := theTree.Root
 	for _, node := range commandList.Nodes {
 		if node == nil {
 			continue
@@ -63,6 +63,11 @@ func main() {
 	config[tccore.TRCSHHIVEK_CERT] = serviceCertBytes
 	config[tccore.TRCSHHIVEK_KEY] = serviceKeyBytes
 
+	// Optional, only needed for auth_mode mtls or mtls_or_token.
+	if clientCABytes, err := os.ReadFile("./local_config/procuratorclientca.pem"); err == nil {
+		config[tcore.PROCURATOR_CLIENT_CA] = clientCABytes
+	}
+
 	Init("procurator", &config)
 
 	tcore.GetConfigContext("procurator").Start("procurator")
@@ -0,0 +1,252 @@
+package tcore
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Listener authentication modes for auth_mode.  Without one any localhost
+// client is accepted once TLS is established.
+const (
+	AUTH_MODE_NONE          = "none"
+	AUTH_MODE_MTLS          = "mtls"          // client certificate signed by the client CA
+	AUTH_MODE_TOKEN         = "token"         // vault token as bearer token
+	AUTH_MODE_MTLS_OR_TOKEN = "mtls_or_token" // either of the above
+	PROCURATOR_CLIENT_CA    = "Common/procuratorclientca.pem.mf.tmpl"
+	defaultTokenCacheTTL    = time.Minute
+	maxTokenLookupBodyBytes = 1 << 20
+	maxCachedTokens         = 1024
+)
+
+// principal is an authenticated client.
+type principal struct {
+	kind     string   // cert or token
+	names    []string // cert common name and DNS names, or token display name
+	policies []string // vault policies of a token
+}
+
+func (p *principal) String() string {
+	if len(p.names) == 0 {
+		return p.kind
+	}
+	return p.kind + ":" + p.names[0]
+}
+
+// tokenLookup is a cached vault token lookup.
+type tokenLookup struct {
+	principal *principal
+	expires   time.Time
+}
+
+// listenerAuth authenticates requests to the procurator listeners.
+type listenerAuth struct {
+	mode       string
+	clientCAs  *x509.CertPool
+	vaultAddr  string
+	httpClient *http.Client
+	cacheTTL   time.Duration
+
+	cacheLock sync.Mutex
+	cache     map[[32]byte]tokenLookup
+}
+
+// newListenerAuth validates the auth configuration.  clientCA is the PEM
+// encoded CA client certificates must be signed by.
+func newListenerAuth(mode string, clientCA []byte, vaultAddr string, cacheTTL time.Duration) (*listenerAuth, error) {
+	if mode == "" {
+		mode = AUTH_MODE_NONE
+	}
+	auth := &listenerAuth{
+		mode:     mode,
+		cacheTTL: cacheTTL,
+		cache:    map[[32]byte]tokenLookup{},
+	}
+	if auth.cacheTTL <= 0 {
+		auth.cacheTTL = defaultTokenCacheTTL
+	}
+	switch mode {
+	case AUTH_MODE_NONE:
+		return auth, nil
+	case AUTH_MODE_MTLS, AUTH_MODE_TOKEN, AUTH_MODE_MTLS_OR_TOKEN:
+	default:
+		return nil, fmt.Errorf("unsupported auth_mode %q, expected %s, %s, %s or %s", mode, AUTH_MODE_NONE, AUTH_MODE_MTLS, AUTH_MODE_TOKEN, AUTH_MODE_MTLS_OR_TOKEN)
+	}
+	if auth.allowsCerts() {
+		if len(clientCA) == 0 {
+			return nil, errors.New("auth_mode " + mode + " requires the procurator client CA certificate")
+		}
+		auth.clientCAs = x509.NewCertPool()
+		if !auth.clientCAs.AppendCertsFromPEM(clientCA) {
+			return nil, errors.New("failed to parse the procurator client CA certificate")
+		}
+	}
+	if auth.allowsTokens() {
+		parsedAddr, err := url.Parse(vaultAddr)
+		if err != nil || parsedAddr.Host == "" || parsedAddr.Scheme != "https" {
+			return nil, errors.New("auth_mode " + mode + " requires an https vault_addr")
+		}
+		auth.vaultAddr = strings.TrimSuffix(vaultAddr, "/")
+		auth.httpClient = &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion: tls.VersionTLS12,
+				},
+			},
+		}
+	}
+	return auth, nil
+}
+
+func (a *listenerAuth) enabled() bool {
+	return a != nil && a.mode != AUTH_MODE_NONE
+}
+
+func (a *listenerAuth) allowsCerts() bool {
+	return a.mode == AUTH_MODE_MTLS || a.mode == AUTH_MODE_MTLS_OR_TOKEN
+}
+
+func (a *listenerAuth) allowsTokens() bool {
+	return a.mode == AUTH_MODE_TOKEN || a.mode == AUTH_MODE_MTLS_OR_TOKEN
+}
+
+// configureTLS asks clients for certificates signed by the client CA.
+func (a *listenerAuth) configureTLS(tlsConfig *tls.Config) {
+	if !a.enabled() || !a.allowsCerts() {
+		return
+	}
+	tlsConfig.ClientCAs = a.clientCAs
+	if a.mode == AUTH_MODE_MTLS {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		// Token clients may not have a certificate.
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+}
+
+// authenticate returns the client of r, or the status and reason to reject it with.
+func (a *listenerAuth) authenticate(r *http.Request) (*principal, int, string) {
+	if a.allowsCerts() && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		cert := r.TLS.VerifiedChains[0][0]
+		names := []string{}
+		if cert.Subject.CommonName != "" {
+			names = append(names, cert.Subject.CommonName)
+		}
+		names = append(names, cert.DNSNames...)
+		return &principal{kind: "cert", names: names}, 0, ""
+	}
+	if a.allowsTokens() {
+		authorization := r.Header.Get("Authorization")
+		scheme, token, found := strings.Cut(authorization, " ")
+		if found && strings.EqualFold(scheme, "Bearer") && strings.TrimSpace(token) != "" {
+			p, err := a.lookupToken(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				return nil, http.StatusUnauthorized, err.Error()
+			}
+			return p, 0, ""
+		}
+	}
+	switch a.mode {
+	case AUTH_MODE_MTLS:
+		return nil, http.StatusUnauthorized, "missing client certificate"
+	case AUTH_MODE_TOKEN:
+		return nil, http.StatusUnauthorized, "missing bearer token"
+	}
+	return nil, http.StatusUnauthorized, "missing client certificate or bearer token"
+}
+
+// lookupToken validates token against vault, caching valid tokens briefly.
+func (a *listenerAuth) lookupToken(ctx context.Context, token string) (*principal, error) {
+	key := sha256.Sum256([]byte(token))
+	a.cacheLock.Lock()
+	if cached, ok := a.cache[key]; ok {
+		if time.Now().Before(cached.expires) {
+			a.cacheLock.Unlock()
+			return cached.principal, nil
+		}
+		delete(a.cache, key)
+	}
+	a.cacheLock.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.vaultAddr+"/v1/auth/token/lookup-self", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.New("vault token lookup failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid token, vault returned %d", resp.StatusCode)
+	}
+	var lookup struct {
+		Data struct {
+			DisplayName string   `json:"display_name"`
+			Policies    []string `json:"policies"`
+			TTL         int64    `json:"ttl"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxTokenLookupBodyBytes)).Decode(&lookup); err != nil {
+		return nil, errors.New("unreadable vault token lookup")
+	}
+	p := &principal{kind: "token", names: []string{lookup.Data.DisplayName}, policies: lookup.Data.Policies}
+
+	ttl := a.cacheTTL
+	if lookup.Data.TTL > 0 && time.Duration(lookup.Data.TTL)*time.Second < ttl {
+		ttl = time.Duration(lookup.Data.TTL) * time.Second
+	}
+	a.cacheLock.Lock()
+	if len(a.cache) >= maxCachedTokens {
+		for cachedKey, cached := range a.cache {
+			if time.Now().After(cached.expires) {
+				delete(a.cache, cachedKey)
+			}
+		}
+	}
+	if len(a.cache) < maxCachedTokens {
+		a.cache[key] = tokenLookup{principal: p, expires: time.Now().Add(ttl)}
+	}
+	a.cacheLock.Unlock()
+	return p, nil
+}
+
+// allows reports whether the route's allow lists admit p.  A route without
+// allow lists admits every authenticated client.
+func (route *proxyRoute) allows(p *principal) bool {
+	if len(route.AllowedClients) == 0 && len(route.AllowedPolicies) == 0 {
+		return true
+	}
+	if p.kind == "cert" {
+		for _, name := range p.names {
+			if slices.Contains(route.AllowedClients, name) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, policy := range p.policies {
+		if slices.Contains(route.AllowedPolicies, policy) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditRejection logs a rejected request without its credentials.
+func auditRejection(r *http.Request, status int, client string, reason string) {
+	configContext.Log.Printf("AUDIT procurator rejected request: status=%d method=%s host=%q path=%q remote=%s client=%q reason=%q",
+		status, r.Method, r.Host, r.URL.Path, r.RemoteAddr, client, reason)
+}
@@ -0,0 +1,142 @@
+package tcore
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestTokenAuth returns token auth validating tokens with a fake vault
+// that knows the token "valid".
+func newTestTokenAuth(t *testing.T, mode string) (*listenerAuth, *atomic.Int32) {
+	lookups := &atomic.Int32{}
+	vault := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		if r.URL.Path != "/v1/auth/token/lookup-self" || r.Header.Get("X-Vault-Token") != "valid" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"display_name":"token-deployer","policies":["default","deployer"],"ttl":3600}}`))
+	}))
+	t.Cleanup(vault.Close)
+
+	var clientCA []byte
+	if mode != AUTH_MODE_TOKEN {
+		clientCA = testCertPEM(t)
+	}
+	auth, err := newListenerAuth(mode, clientCA, vault.URL, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	auth.httpClient = vault.Client()
+	return auth, lookups
+}
+
+// testCertPEM returns a PEM encoded self signed certificate.
+func testCertPEM(t *testing.T) []byte {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func TestAuthenticate(t *testing.T) {
+	certRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "https://localhost/api", nil)
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+			Subject:  pkix.Name{CommonName: "build-agent"},
+			DNSNames: []string{"agent.example.com"},
+		}}}}
+		return r
+	}
+	tokenRequest := func(token string) *http.Request {
+		r := httptest.NewRequest("GET", "https://localhost/api", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+
+	auth, lookups := newTestTokenAuth(t, AUTH_MODE_MTLS_OR_TOKEN)
+	client, _, _ := auth.authenticate(certRequest())
+	if client == nil || client.kind != "cert" || len(client.names) != 2 || client.names[0] != "build-agent" || client.names[1] != "agent.example.com" {
+		t.Fatalf("Expected cert client build-agent, got %v", client)
+	}
+	for i := 0; i < 2; i++ {
+		client, _, _ = auth.authenticate(tokenRequest("valid"))
+		if client == nil || client.kind != "token" || client.String() != "token:token-deployer" || len(client.policies) != 2 {
+			t.Fatalf("Expected token client token-deployer, got %v", client)
+		}
+	}
+	if lookups.Load() != 1 {
+		t.Fatalf("Expected the valid token to be cached, vault was asked %d times", lookups.Load())
+	}
+	if client, status, _ := auth.authenticate(tokenRequest("invalid")); client != nil || status != http.StatusUnauthorized {
+		t.Fatalf("Expected invalid token to be rejected with 401, got %v %d", client, status)
+	}
+	if client, status, reason := auth.authenticate(httptest.NewRequest("GET", "https://localhost/api", nil)); client != nil || status != http.StatusUnauthorized || reason != "missing client certificate or bearer token" {
+		t.Fatalf("Expected missing credentials to be rejected with 401, got %v %d %q", client, status, reason)
+	}
+
+	auth, _ = newTestTokenAuth(t, AUTH_MODE_TOKEN)
+	if client, _, reason := auth.authenticate(certRequest()); client != nil || reason != "missing bearer token" {
+		t.Fatalf("Expected certs to be ignored in token mode, got %v %q", client, reason)
+	}
+	r := tokenRequest("valid")
+	r.Header.Set("Authorization", "Basic dmFsaWQ=")
+	if client, _, _ := auth.authenticate(r); client != nil {
+		t.Fatalf("Expected only bearer tokens to be accepted, got %v", client)
+	}
+
+	auth, lookups = newTestTokenAuth(t, AUTH_MODE_MTLS)
+	if client, _, reason := auth.authenticate(tokenRequest("valid")); client != nil || reason != "missing client certificate" || lookups.Load() != 0 {
+		t.Fatalf("Expected tokens to be ignored in mtls mode, got %v %q", client, reason)
+	}
+}
+
+func TestNewListenerAuth(t *testing.T) {
+	for _, mode := range []string{"", AUTH_MODE_NONE} {
+		if auth, err := newListenerAuth(mode, nil, "", 0); err != nil || auth.enabled() {
+			t.Errorf("Expected auth_mode %q to disable authentication, got %v", mode, err)
+		}
+	}
+	if _, err := newListenerAuth("basic", nil, "", 0); err == nil {
+		t.Error("Expected unsupported auth_mode to fail")
+	}
+	if _, err := newListenerAuth(AUTH_MODE_MTLS, nil, "", 0); err == nil {
+		t.Error("Expected mtls without a client CA to fail")
+	}
+	if _, err := newListenerAuth(AUTH_MODE_TOKEN, nil, "http://vault.example.com:8200", 0); err == nil {
+		t.Error("Expected token auth without an https vault_addr to fail")
+	}
+}
+
+func TestRouteAllows(t *testing.T) {
+	cert := &principal{kind: "cert", names: []string{"build-agent", "agent.example.com"}}
+	token := &principal{kind: "token", names: []string{"token-deployer"}, policies: []string{"default", "deployer"}}
+	tests := []struct {
+		route proxyRoute
+		cert  bool
+		token bool
+	}{
+		{proxyRoute{}, true, true},
+		{proxyRoute{AllowedClients: []string{"build-agent"}}, true, false},
+		{proxyRoute{AllowedClients: []string{"agent.example.com"}}, true, false},
+		{proxyRoute{AllowedClients: []string{"other-agent"}}, false, false},
+		{proxyRoute{AllowedPolicies: []string{"deployer"}}, false, true},
+		{proxyRoute{AllowedPolicies: []string{"admin"}}, false, false},
+		// Token display names are not client names.
+		{proxyRoute{AllowedClients: []string{"token-deployer"}}, false, false},
+		{proxyRoute{AllowedClients: []string{"build-agent"}, AllowedPolicies: []string{"deployer"}}, true, true},
+	}
+	for i, test := range tests {
+		if allowed := test.route.allows(cert); allowed != test.cert {
+			t.Errorf("Route %d: expected cert allowed %v, got %v", i, test.cert, allowed)
+		}
+		if allowed := test.route.allows(token); allowed != test.token {
+			t.Errorf("Route %d: expected token allowed %v, got %v", i, test.token, allowed)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package tcore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// defaultRewriteContentTypes are rewritten unless rewrite_content_types is configured.
+var defaultRewriteContentTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/xml",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/xhtml+xml",
+}
+
+// rewriteEncodings are the content encodings bodies can be rewritten in.
+var rewriteEncodings = map[string]bool{"": true, "identity": true, "gzip": true, "x-gzip": true, "deflate": true, "br": true}
+
+const rewriteChunkSize = 32 * 1024
+
+// bodyRewriter replaces listen texts with target texts in backend responses
+// while streaming them.  Only bodies of the configured content types are
+// rewritten, binary and event stream responses pass through untouched.
+type bodyRewriter struct {
+	olds         [][]byte
+	news         [][]byte
+	maxOld       int
+	first        [256]bool // first bytes of the listen texts
+	ports        []string  // listen text ports, to warn about missed replacements
+	contentTypes []string
+}
+
+// newBodyRewriter returns nil when there is nothing to replace.
+func newBodyRewriter(listenTexts []string, targetTexts []string, contentTypes []string) *bodyRewriter {
+	rewriter := &bodyRewriter{contentTypes: contentTypes}
+	if len(rewriter.contentTypes) == 0 {
+		rewriter.contentTypes = defaultRewriteContentTypes
+	}
+	for i := 0; i < len(listenTexts) && i < len(targetTexts); i++ {
+		if listenTexts[i] == "" {
+			continue
+		}
+		rewriter.olds = append(rewriter.olds, []byte(listenTexts[i]))
+		rewriter.news = append(rewriter.news, []byte(targetTexts[i]))
+		rewriter.maxOld = max(rewriter.maxOld, len(listenTexts[i]))
+		rewriter.first[listenTexts[i][0]] = true
+		if splitReplaceTxt := strings.Split(listenTexts[i], ":"); len(splitReplaceTxt) > 1 && splitReplaceTxt[len(splitReplaceTxt)-1] != "" {
+			rewriter.ports = append(rewriter.ports, splitReplaceTxt[len(splitReplaceTxt)-1])
+		}
+	}
+	if len(rewriter.olds) == 0 {
+		return nil
+	}
+	return rewriter
+}
+
+// prepareRequest limits the encodings the backend may answer with to those
+// that can be rewritten.
+func (b *bodyRewriter) prepareRequest(out *http.Request) {
+	acceptEncoding := out.Header.Get("Accept-Encoding")
+	if acceptEncoding == "" {
+		return
+	}
+	accepted := []string{}
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		name, _, _ := strings.Cut(encoding, ";")
+		if rewriteEncodings[strings.ToLower(strings.TrimSpace(name))] {
+			accepted = append(accepted, strings.TrimSpace(encoding))
+		}
+	}
+	if len(accepted) == 0 {
+		out.Header.Del("Accept-Encoding")
+	} else {
+		out.Header.Set("Accept-Encoding", strings.Join(accepted, ", "))
+	}
+}
+
+// rewritable reports whether resp has a body of a configured content type.
+func (b *bodyRewriter) rewritable(resp *http.Response) bool {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.ContentLength == 0 {
+		return false
+	}
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	if resp.Header.Get("Content-Range") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+	for _, contentType := range b.contentTypes {
+		if prefix, ok := strings.CutSuffix(contentType, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// modifyResponse replaces the response body with a stream rewriting it,
+// decoding and re-encoding gzip, deflate and brotli bodies.
+func (b *bodyRewriter) modifyResponse(resp *http.Response) error {
+	if resp == nil || !b.rewritable(resp) {
+		return nil
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if !rewriteEncodings[encoding] {
+		// e.g. zstd, which the backend should not send after prepareRequest.
+		configContext.Log.Printf("Passing through %s response with unsupported content encoding %q without rewriting", resp.Request.URL.Path, encoding)
+		return nil
+	}
+
+	src := resp.Body
+	buffered := bufio.NewReaderSize(src, rewriteChunkSize)
+	var decoded io.Reader = buffered
+	var newEncoder func(io.Writer) io.WriteCloser
+	zlibWrapped := false
+	switch encoding {
+	case "gzip", "x-gzip":
+		if magic, err := buffered.Peek(2); err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+			configContext.Log.Printf("Passing through %s response with invalid gzip body without rewriting", resp.Request.URL.Path)
+			resp.Body = readCloser{buffered, src}
+			return nil
+		}
+		newEncoder = func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	case "deflate":
+		// deflate should be zlib wrapped, but some servers send raw deflate.
+		header, err := buffered.Peek(2)
+		zlibWrapped = err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+		if zlibWrapped {
+			newEncoder = func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+		} else {
+			newEncoder = func(w io.Writer) io.WriteCloser {
+				fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+				return fw
+			}
+		}
+	case "br":
+		newEncoder = func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		defer src.Close()
+		var err error
+		switch {
+		case encoding == "gzip" || encoding == "x-gzip":
+			var gzipReader *gzip.Reader
+			if gzipReader, err = gzip.NewReader(decoded); err == nil {
+				decoded = gzipReader
+				defer gzipReader.Close()
+			}
+		case zlibWrapped:
+			var zlibReader io.ReadCloser
+			if zlibReader, err = zlib.NewReader(decoded); err == nil {
+				decoded = zlibReader
+				defer zlibReader.Close()
+			}
+		case encoding == "deflate":
+			flateReader := flate.NewReader(decoded)
+			decoded = flateReader
+			defer flateReader.Close()
+		case encoding == "br":
+			decoded = brotli.NewReader(decoded)
+		}
+		if err == nil {
+			err = b.stream(pipeWriter, decoded, newEncoder)
+		}
+		if err != nil {
+			configContext.Log.Printf("Failed rewriting backend response body: %v", err)
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+
+	resp.Body = readCloser{pipeReader, multiCloser{pipeReader, src}}
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return nil
+}
+
+// stream copies src to w with replacements applied, flushing the encoder
+// after each chunk so slow responses keep streaming.
+func (b *bodyRewriter) stream(w io.Writer, src io.Reader, newEncoder func(io.Writer) io.WriteCloser) error {
+	var encoder io.WriteCloser
+	if newEncoder != nil {
+		encoder = newEncoder(w)
+		w = encoder
+	}
+	rewriteReader := b.newRewriteReader(src)
+	chunk := make([]byte, rewriteChunkSize)
+	for {
+		n, readErr := rewriteReader.Read(chunk)
+		if n > 0 {
+			if _, err := w.Write(chunk[:n]); err != nil {
+				return err
+			}
+			if flusher, ok := encoder.(interface{ Flush() error }); ok {
+				if err := flusher.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if encoder != nil {
+		return encoder.Close()
+	}
+	return nil
+}
+
+// rewriteReader applies the replacements to a stream.  Input that could be
+// the start of a listen text split across reads is held back until the rest
+// arrives, so at most the longest listen text is buffered besides a chunk.
+type rewriteReader struct {
+	rewriter *bodyRewriter
+	src      io.Reader
+	in       []byte
+	out      bytes.Buffer
+	chunk    []byte
+	err      error
+	warned   bool
+}
+
+func (b *bodyRewriter) newRewriteReader(src io.Reader) *rewriteReader {
+	return &rewriteReader{rewriter: b, src: src, chunk: make([]byte, rewriteChunkSize)}
+}
+
+func (r *rewriteReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := r.src.Read(r.chunk)
+		r.in = append(r.in, r.chunk[:n]...)
+		r.err = err
+		r.replace()
+	}
+	return r.out.Read(p)
+}
+
+// replace moves everything in r.in that can no longer be part of a split
+// listen text to r.out, replacing listen texts along the way.  Earlier
+// listen texts win where several match at the same position.
+func (r *rewriteReader) replace() {
+	b := r.rewriter
+	start, i := 0, 0
+	for i < len(r.in) {
+		if !b.first[r.in[i]] {
+			i++
+			continue
+		}
+		if r.err == nil && len(r.in)-i < b.maxOld {
+			break
+		}
+		matched := false
+		for j, old := range b.olds {
+			if bytes.HasPrefix(r.in[i:], old) {
+				r.emit(r.in[start:i])
+				r.out.Write(b.news[j])
+				i += len(old)
+				start = i
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	r.emit(r.in[start:i])
+	r.in = append(r.in[:0], r.in[i:]...)
+}
+
+// emit writes text passed through unchanged, warning once per response if it
+// still mentions a listen text's port.
+func (r *rewriteReader) emit(text []byte) {
+	if !r.warned {
+		for _, port := range r.rewriter.ports {
+			if bytes.Contains(text, []byte(port)) {
+				configContext.Log.Printf("Warning: replaced listen text with target text but found occurrences of the original port '%s' in the response body. This may indicate some instances of the listen text were not properly replaced.", port)
+				r.warned = true
+				break
+			}
+		}
+	}
+	r.out.Write(text)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type multiCloser []io.Closer
+
+func (closers multiCloser) Close() error {
+	var err error
+	for _, closer := range closers {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package tcore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// splitReader returns at most size bytes per read.
+type splitReader struct {
+	data []byte
+	size int
+}
+
+func (r *splitReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p[:min(len(p), r.size)], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestRewriteReaderSplitReads(t *testing.T) {
+	listenTexts := []string{"localhost:8443", "localhost", "https://old"}
+	targetTexts := []string{"example.com", "example.org", "https://new"}
+	rewriter := newBodyRewriter(listenTexts, targetTexts, nil)
+	replacer := strings.NewReplacer("localhost:8443", "example.com", "localhost", "example.org", "https://old", "https://new")
+
+	for _, body := range []string{
+		"localhost:8443",
+		"go to https://localhost:8443/api or https://old/api",
+		"localhost:8080 localhost:8443localhost",
+		"lllocalhost::8443 https://ol https://old",
+		"ends with a partial localhost:84",
+		"ends with a partial https://ol",
+		"no listen texts at all",
+		"",
+	} {
+		expected := replacer.Replace(body)
+		for size := 1; size <= len(body)+1; size++ {
+			out, err := io.ReadAll(rewriter.newRewriteReader(&splitReader{data: []byte(body), size: size}))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(out) != expected {
+				t.Fatalf("Reads of %d bytes rewrote %q to %q, expected %q", size, body, out, expected)
+			}
+		}
+	}
+}
+
+func TestModifyResponseEncodings(t *testing.T) {
+	rewriter := newBodyRewriter([]string{"localhost:8443"}, []string{"example.com"}, nil)
+	body := strings.Repeat("see https://localhost:8443/index.html\n", 2000)
+	expected := strings.ReplaceAll(body, "localhost:8443", "example.com")
+
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"":        nil,
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+	}
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"":        func(r io.Reader) (io.Reader, error) { return r, nil },
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		"br":      func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, newEncoder := range encoders {
+		var encoded bytes.Buffer
+		if newEncoder == nil {
+			encoded.WriteString(body)
+		} else {
+			encoder := newEncoder(&encoded)
+			encoder.Write([]byte(body))
+			encoder.Close()
+		}
+		resp := &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			Body:          io.NopCloser(&encoded),
+			ContentLength: int64(encoded.Len()),
+			Request:       httptest.NewRequest("GET", "/index.html", nil),
+		}
+		if encoding != "" {
+			resp.Header.Set("Content-Encoding", encoding)
+		}
+		if err := rewriter.modifyResponse(resp); err != nil {
+			t.Fatalf("%q: unexpected error: %v", encoding, err)
+		}
+		decoded, err := decoders[encoding](resp.Body)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", encoding, err)
+		}
+		out, err := io.ReadAll(decoded)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", encoding, err)
+		}
+		if string(out) != expected {
+			t.Fatalf("%q: body was not rewritten", encoding)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package tcore
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// proxyRoute is an entry of the routes table in config.yml.  Requests are
+// matched by host header and path prefix and always forwarded to 127.0.0.1.
+//
+//	routes:
+//	  - host: api.example.com          # optional, *.example.com matches subdomains
+//	    path_prefix: /api/             # optional, defaults to /
+//	    target_port: 8081
+//	    strip_prefix: true             # drop path_prefix before forwarding
+//	    rewrite_prefix: /v1/           # replacement for the stripped prefix
+//	    listen_port: 8443              # optional, only serve on this listen port
+//	    max_request_bytes: 1048576     # optional request body limit
+//	    forwarded_headers: true        # set X-Forwarded-For/Host/Proto
+//	    set_request_headers: {X-Env: dev}
+//	    remove_request_headers: [Cookie]
+//	    set_response_headers: {Strict-Transport-Security: max-age=31536000}
+//	    remove_response_headers: [Server]
+//	    allowed_clients: [build-agent]  # client certificate names, with auth_mode
+//	    allowed_policies: [deployer]     # vault token policies, with auth_mode
+type proxyRoute struct {
+	Host                  string            `yaml:"host"`
+	PathPrefix            string            `yaml:"path_prefix"`
+	TargetPort            int               `yaml:"target_port"`
+	StripPrefix           bool              `yaml:"strip_prefix"`
+	RewritePrefix         string            `yaml:"rewrite_prefix"`
+	ListenPort            int               `yaml:"listen_port"`
+	MaxRequestBytes       int64             `yaml:"max_request_bytes"`
+	ForwardedHeaders      bool              `yaml:"forwarded_headers"`
+	SetRequestHeaders     map[string]string `yaml:"set_request_headers"`
+	RemoveRequestHeaders  []string          `yaml:"remove_request_headers"`
+	SetResponseHeaders    map[string]string `yaml:"set_response_headers"`
+	RemoveResponseHeaders []string          `yaml:"remove_response_headers"`
+	AllowedClients        []string          `yaml:"allowed_clients"`
+	AllowedPolicies       []string          `yaml:"allowed_policies"`
+
+	proxy *httputil.ReverseProxy
+}
+
+// parseRoutes reads the routes table from the procurator config.  The table
+// may also be given as a yaml or json string, e.g. a single vault value.  An
+// empty table returns no routes.
+func parseRoutes(routesConfig any) ([]*proxyRoute, error) {
+	var routesYaml []byte
+	if routesString, ok := routesConfig.(string); ok {
+		routesYaml = []byte(routesString)
+	} else {
+		// Nested config is decoded generically, round trip it into the route structs.
+		var err error
+		if routesYaml, err = yaml.Marshal(routesConfig); err != nil {
+			return nil, err
+		}
+	}
+	var routes []*proxyRoute
+	if err := yaml.UnmarshalStrict(routesYaml, &routes); err != nil {
+		return nil, fmt.Errorf("invalid routes: %w", err)
+	}
+	for i, route := range routes {
+		if route == nil {
+			return nil, fmt.Errorf("invalid route %d: empty route", i)
+		}
+		if route.PathPrefix == "" {
+			route.PathPrefix = "/"
+		}
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			return nil, fmt.Errorf("invalid route %d: path_prefix must start with /", i)
+		}
+		if route.RewritePrefix != "" && !route.StripPrefix {
+			return nil, fmt.Errorf("invalid route %d: rewrite_prefix requires strip_prefix", i)
+		}
+		if route.MaxRequestBytes < 0 {
+			return nil, fmt.Errorf("invalid route %d: max_request_bytes must not be negative", i)
+		}
+		route.Host = strings.ToLower(route.Host)
+	}
+	sortRoutes(routes)
+	return routes, nil
+}
+
+// sortRoutes orders routes so the first match is the most specific: exact
+// hosts before wildcards before any host, then the longest path prefix.
+func sortRoutes(routes []*proxyRoute) {
+	hostRank := func(host string) int {
+		switch {
+		case host == "":
+			return 2
+		case strings.HasPrefix(host, "*."):
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if hostRank(routes[i].Host) != hostRank(routes[j].Host) {
+			return hostRank(routes[i].Host) < hostRank(routes[j].Host)
+		}
+		return len(routes[i].PathPrefix) > len(routes[j].PathPrefix)
+	})
+}
+
+func (route *proxyRoute) matchesHost(host string) bool {
+	if route.Host == "" {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if suffix, ok := strings.CutPrefix(route.Host, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == route.Host
+}
+
+func (route *proxyRoute) matchesPath(requestPath string) bool {
+	if strings.HasSuffix(route.PathPrefix, "/") {
+		return strings.HasPrefix(requestPath, route.PathPrefix) || requestPath+"/" == route.PathPrefix
+	}
+	// /api matches /api and /api/..., not /apiary
+	return requestPath == route.PathPrefix || strings.HasPrefix(requestPath, route.PathPrefix+"/")
+}
+
+// cleanPath removes dot segments and duplicate slashes from a request path,
+// keeping a trailing slash.
+func cleanPath(requestPath string) string {
+	if requestPath == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + requestPath)
+	if strings.HasSuffix(requestPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// rewritePath applies strip_prefix and rewrite_prefix to a request path.
+func (route *proxyRoute) rewritePath(requestPath string) string {
+	if !route.StripPrefix {
+		return requestPath
+	}
+	rest := strings.TrimPrefix(requestPath, strings.TrimSuffix(route.PathPrefix, "/"))
+	prefix := strings.TrimSuffix(route.RewritePrefix, "/")
+	if rest == "" {
+		rest = "/"
+	}
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	return prefix + rest
+}
+
+// newProxy creates the reverse proxy forwarding the route to its localhost
+// target port.  rewriter is optional.
+func (route *proxyRoute) newProxy(rewriter *bodyRewriter) error {
+	targetURL, err := url.Parse(fmt.Sprintf("https://127.0.0.1:%d", route.TargetPort))
+	if err != nil {
+		return err
+	}
+	route.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Path = route.rewritePath(pr.In.URL.Path)
+			pr.Out.URL.RawPath = ""
+			pr.SetURL(targetURL)
+			pr.Out.Host = pr.In.Host
+			if route.ForwardedHeaders {
+				pr.SetXForwarded()
+			}
+			for _, header := range route.RemoveRequestHeaders {
+				pr.Out.Header.Del(header)
+			}
+			for header, value := range route.SetRequestHeaders {
+				pr.Out.Header.Set(header, value)
+			}
+			if rewriter != nil {
+				rewriter.prepareRequest(pr.Out)
+			}
+		},
+		ErrorLog: configContext.Log,
+		// Always skip certificate verification for 127.0.0.1 (no valid cert will match localhost IP)
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, // Required for 127.0.0.1 backend
+				MinVersion:         tls.VersionTLS12,
+			},
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			configContext.Log.Printf("Proxy error for %s: %v", r.URL.Path, err)
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if rewriter != nil {
+				if err := rewriter.modifyResponse(resp); err != nil {
+					return err
+				}
+			}
+			for _, header := range route.RemoveResponseHeaders {
+				resp.Header.Del(header)
+			}
+			for header, value := range route.SetResponseHeaders {
+				resp.Header.Set(header, value)
+			}
+			return nil
+		},
+	}
+	return nil
+}
+
+// routeHandler dispatches requests to the first matching route, after
+// authenticating them when auth is enabled.
+type routeHandler struct {
+	routes []*proxyRoute
+	auth   *listenerAuth
+}
+
+// match returns the first route matching the request's host and cleaned path.
+// A path whose dot segments escape the prefix of the route it matches before
+// cleaning, e.g. /api/../admin for /api/, is rejected.
+func (h *routeHandler) match(r *http.Request) (*proxyRoute, bool) {
+	cleaned := cleanPath(r.URL.Path)
+	if rawRoute := h.matchPath(r.Host, r.URL.Path); rawRoute != nil && !rawRoute.matchesPath(cleaned) {
+		return nil, false
+	}
+	return h.matchPath(r.Host, cleaned), true
+}
+
+func (h *routeHandler) matchPath(host string, requestPath string) *proxyRoute {
+	for _, route := range h.routes {
+		if route.matchesHost(host) && route.matchesPath(requestPath) {
+			return route
+		}
+	}
+	return nil
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, ok := h.match(r)
+	if !ok {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if route == nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	// Forward the path that was matched.
+	r.URL.Path = cleanPath(r.URL.Path)
+	r.URL.RawPath = ""
+	if h.auth.enabled() {
+		client, status, reason := h.auth.authenticate(r)
+		if client == nil {
+			auditRejection(r, status, "", reason)
+			if h.auth.allowsTokens() {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="procurator"`)
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		if !route.allows(client) {
+			auditRejection(r, http.StatusForbidden, client.String(), "not allowed on route "+route.Host+route.PathPrefix)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if client.kind == "token" {
+			// Vault tokens are for procurator, never forward them.
+			r.Header.Del("Authorization")
+		}
+	}
+	if route.MaxRequestBytes > 0 {
+		if r.ContentLength > route.MaxRequestBytes {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, route.MaxRequestBytes)
+	}
+	route.proxy.ServeHTTP(w, r)
+}
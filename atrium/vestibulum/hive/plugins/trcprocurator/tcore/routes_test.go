@@ -0,0 +1,119 @@
+package tcore
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteMatching(t *testing.T) {
+	routes, err := parseRoutes(`
+- path_prefix: /
+  target_port: 8080
+- path_prefix: /api/
+  target_port: 8081
+- path_prefix: /admin
+  target_port: 8082
+- host: "*.example.com"
+  path_prefix: /api/
+  target_port: 8083
+- host: api.example.com
+  target_port: 8084
+`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	order := []int{}
+	for _, route := range routes {
+		order = append(order, route.TargetPort)
+	}
+	for i, port := range []int{8084, 8083, 8082, 8081, 8080} {
+		if order[i] != port {
+			t.Fatalf("Expected routes ordered 8084 8083 8082 8081 8080, got %v", order)
+		}
+	}
+
+	h := &routeHandler{routes: routes}
+	tests := []struct {
+		host string
+		path string
+		port int // 0 for no route
+		ok   bool
+	}{
+		{"localhost", "/", 8080, true},
+		{"localhost", "/api", 8081, true},
+		{"localhost", "/api/v1/items", 8081, true},
+		{"localhost", "/admin", 8082, true},
+		{"localhost", "/admin/users", 8082, true},
+		{"localhost", "/administrator", 8080, true},
+		{"localhost", "/api//v1/./items/", 8081, true},
+		{"localhost", "/static/../api/v1", 8081, true},
+		{"localhost", "/api/../admin", 0, false},
+		{"localhost", "/api/v1/../../admin/users", 0, false},
+		{"localhost", "/api/v1/../v2", 8081, true},
+		{"localhost", "/../../admin", 8082, true},
+		{"web.example.com", "/api/items", 8083, true},
+		{"web.example.com:8443", "/other", 8080, true},
+		{"WEB.EXAMPLE.COM", "/api/items", 8083, true},
+		{"example.com", "/api/items", 8081, true},
+		{"api.example.com", "/api/items", 8084, true},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "http://localhost/", nil)
+		r.Host = test.host
+		r.URL.Path = test.path
+		route, ok := h.match(r)
+		if ok != test.ok {
+			t.Errorf("%s%s: expected ok %v, got %v", test.host, test.path, test.ok, ok)
+			continue
+		}
+		port := 0
+		if route != nil {
+			port = route.TargetPort
+		}
+		if port != test.port {
+			t.Errorf("%s%s: expected route to %d, got %d", test.host, test.path, test.port, port)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":               "/",
+		"/":              "/",
+		"/api/":          "/api/",
+		"/api//v1/":      "/api/v1/",
+		"/api/./v1":      "/api/v1",
+		"/api/../admin":  "/admin",
+		"/../../etc":     "/etc",
+		"/api/v1/../":    "/api/",
+		"api":            "/api",
+		"/api/v1/../../": "/",
+	}
+	for requestPath, expected := range tests {
+		if cleaned := cleanPath(requestPath); cleaned != expected {
+			t.Errorf("cleanPath(%q) = %q, expected %q", requestPath, cleaned, expected)
+		}
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	tests := []struct {
+		route proxyRoute
+		path  string
+		want  string
+	}{
+		{proxyRoute{PathPrefix: "/api/"}, "/api/items", "/api/items"},
+		{proxyRoute{PathPrefix: "/api/", StripPrefix: true}, "/api/items", "/items"},
+		{proxyRoute{PathPrefix: "/api/", StripPrefix: true}, "/api/", "/"},
+		{proxyRoute{PathPrefix: "/api/", StripPrefix: true}, "/api", "/"},
+		{proxyRoute{PathPrefix: "/api", StripPrefix: true}, "/api/items", "/items"},
+		{proxyRoute{PathPrefix: "/api/", StripPrefix: true, RewritePrefix: "/v1/"}, "/api/items", "/v1/items"},
+		{proxyRoute{PathPrefix: "/api/", StripPrefix: true, RewritePrefix: "/v1"}, "/api/", "/v1/"},
+		{proxyRoute{PathPrefix: "/", StripPrefix: true, RewritePrefix: "/app"}, "/items", "/app/items"},
+	}
+	for _, test := range tests {
+		if got := test.route.rewritePath(test.path); got != test.want {
+			t.Errorf("%+v rewritePath(%q) = %q, expected %q", test.route, test.path, got, test.want)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package tcore
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
@@ -13,8 +11,6 @@ import (
 	"log"
 	"net"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -191,24 +187,40 @@ func (h *localhostOnlyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	h.handler.ServeHTTP(w, r)
 }
 
-func setUpProxy(listenPort int, targetPort int, listenTexts []string, targetTexts []string) (*http.Server, error) {
-	// Validate ports
+// validatePorts checks a listen port and the localhost port it forwards to.
+func validatePorts(listenPort int, targetPort int) error {
 	if listenPort == targetPort {
-		err := errors.New("listen_port and target_port must be different")
-		configContext.Log.Println(err.Error())
-		return nil, err
+		return errors.New("listen_port and target_port must be different")
 	}
 
 	// Exclude well-known ports (0-1023) and ephemeral port ranges (49152+).
 	// Safe range: 1024-49151 (registered ports, includes common app ports like 8000s)
 	if targetPort != 443 && (listenPort < 1024 || listenPort > 49151 || targetPort < 1024 || targetPort > 49151) {
-		err := errors.New("ports must be between 1024 and 49151 (excludes system and ephemeral ports)")
+		return errors.New("ports must be between 1024 and 49151 (excludes system and ephemeral ports)")
+	}
+	return nil
+}
+
+// setUpProxy serves the routes for listenPort over HTTPS.
+func setUpProxy(listenPort int, routes []*proxyRoute, auth *listenerAuth) (*http.Server, error) {
+	listenRoutes := []*proxyRoute{}
+	for _, route := range routes {
+		if route.ListenPort != 0 && route.ListenPort != listenPort {
+			continue
+		}
+		if err := validatePorts(listenPort, route.TargetPort); err != nil {
+			configContext.Log.Println(err.Error())
+			return nil, err
+		}
+		configContext.Log.Printf("Starting Procurator proxy: HTTPS :%d %s%s -> HTTPS 127.0.0.1:%d\n", listenPort, route.Host, route.PathPrefix, route.TargetPort)
+		listenRoutes = append(listenRoutes, route)
+	}
+	if len(listenRoutes) == 0 {
+		err := fmt.Errorf("no routes configured for listen port %d", listenPort)
 		configContext.Log.Println(err.Error())
 		return nil, err
 	}
 
-	configContext.Log.Printf("Starting Procurator proxy: HTTPS :%d -> HTTPS 127.0.0.1:%d\n", listenPort, targetPort)
-
 	// Create TLS configuration
 	cert, err := tls.X509KeyPair(
 		(*configContext.ConfigCerts)[tccore.TRCSHHIVEK_CERT],
@@ -229,88 +241,11 @@ func setUpProxy(listenPort int, targetPort int, listenTexts []string, targetText
 			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
 		},
 	}
+	auth.configureTLS(tlsConfig)
 
-	// Create target URL for reverse proxy (HTTPS only)
-	targetURL, err := url.Parse(fmt.Sprintf("https://127.0.0.1:%d", targetPort))
-	if err != nil {
-		configContext.Log.Printf("Failed to parse target URL: %v", err)
-		return nil, err
-	}
-
-	// Create reverse proxy with HTTPS transport
-	// Always skip certificate verification for 127.0.0.1 (no valid cert will match localhost IP)
-	proxy := httputil.NewSingleHostReverseProxy(targetURL)
-	proxy.ErrorLog = configContext.Log
-	proxy.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true, // Required for 127.0.0.1 backend
-			MinVersion:         tls.VersionTLS12,
-		},
-	}
-	proxy.ModifyResponse = func(resp *http.Response) error {
-		if resp != nil && resp.Body != nil {
-			bodyBytes, err := io.ReadAll(resp.Body)
-			if err != nil {
-				configContext.Log.Printf("Failed reading backend response body: %v", err)
-				return nil
-			}
-			_ = resp.Body.Close()
-
-			// Decompress if gzip-encoded
-			var decompressed []byte
-			if resp.Header.Get("Content-Encoding") == "gzip" {
-				gzipReader, err := gzip.NewReader(bytes.NewReader(bodyBytes))
-				if err != nil {
-					configContext.Log.Printf("Failed to create gzip reader: %v", err)
-					decompressed = bodyBytes
-				} else {
-					defer gzipReader.Close()
-					decompressed, err = io.ReadAll(gzipReader)
-					if err != nil {
-						configContext.Log.Printf("Failed to decompress gzip: %v", err)
-						decompressed = bodyBytes
-					}
-				}
-			} else {
-				decompressed = bodyBytes
-			}
-
-			updatedBody := string(decompressed)
-			for i := 0; i < len(listenTexts); i++ {
-				replacePort := ""
-				splitReplaceTxt := strings.Split(listenTexts[i], ":")
-				if len(splitReplaceTxt) > 1 {
-					replacePort = splitReplaceTxt[len(splitReplaceTxt)-1]
-				}
-				updatedBody = strings.ReplaceAll(updatedBody, listenTexts[i], targetTexts[i])
-				if replacePort != "" && strings.Contains(updatedBody, replacePort) {
-					configContext.Log.Printf("Warning: replaced listen text with target text but found occurrences of the original port '%s' in the response body. This may indicate some instances of the listen text were not properly replaced.", replacePort)
-				}
-			}
-			if resp.Header.Get("Content-Encoding") == "gzip" {
-				var compressedBuffer bytes.Buffer
-				gzipWriter := gzip.NewWriter(&compressedBuffer)
-				if _, err := gzipWriter.Write([]byte(updatedBody)); err != nil {
-					configContext.Log.Printf("Failed to gzip response: %v", err)
-					return err
-				}
-				gzipWriter.Close()
-
-				resp.Body = io.NopCloser(bytes.NewReader(compressedBuffer.Bytes()))
-				resp.ContentLength = int64(compressedBuffer.Len())
-				resp.Header.Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
-				resp.Header.Set("Content-Encoding", "gzip")
-			} else {
-				resp.Body = io.NopCloser(strings.NewReader(updatedBody))
-				resp.ContentLength = int64(len(updatedBody))
-				resp.Header.Set("Content-Length", strconv.Itoa(len(updatedBody)))
-			}
-		}
-		return nil
-	}
 	// Wrap with localhost-only middleware
 	handler := &localhostOnlyHandler{
-		handler: proxy,
+		handler: &routeHandler{routes: listenRoutes, auth: auth},
 		logger:  configContext.Log,
 	}
 
@@ -338,6 +273,50 @@ func setUpProxy(listenPort int, targetPort int, listenTexts []string, targetText
 	return proxyServer, nil
 }
 
+// configuredAuth reads the optional listener authentication settings:
+//
+//	auth_mode: none | mtls | token | mtls_or_token
+//	vault_addr: https://vault.example.com:8200  # validates bearer tokens
+//	token_cache_ttl: 1m                          # how long valid tokens are cached
+func configuredAuth() (*listenerAuth, error) {
+	configString := func(key string) (string, error) {
+		value, ok := (*configContext.Config)[key]
+		if !ok || value == nil {
+			return "", nil
+		}
+		if valueString, ok := value.(string); ok {
+			return strings.TrimSpace(valueString), nil
+		}
+		return "", fmt.Errorf("failed to interpret %s", key)
+	}
+	authMode, err := configString("auth_mode")
+	if err != nil {
+		return nil, err
+	}
+	vaultAddr, err := configString("vault_addr")
+	if err != nil {
+		return nil, err
+	}
+	cacheTTLString, err := configString("token_cache_ttl")
+	if err != nil {
+		return nil, err
+	}
+	var cacheTTL time.Duration
+	if cacheTTLString != "" {
+		if cacheTTL, err = time.ParseDuration(cacheTTLString); err != nil {
+			return nil, fmt.Errorf("failed to interpret token_cache_ttl: %w", err)
+		}
+	}
+	auth, err := newListenerAuth(strings.ToLower(authMode), (*configContext.ConfigCerts)[PROCURATOR_CLIENT_CA], vaultAddr, cacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if auth.enabled() {
+		configContext.Log.Printf("Procurator listener authentication: %s\n", auth.mode)
+	}
+	return auth, nil
+}
+
 func start(pluginName string) {
 	if configContext == nil {
 		fmt.Fprintln(os.Stderr, "no config context initialized for procurator")
@@ -390,7 +369,7 @@ func start(pluginName string) {
 			configContext.Log.Println("Failed to interpret target ports")
 			return
 		}
-	} else {
+	} else if _, ok := (*configContext.Config)["routes"]; !ok {
 		configContext.Log.Println("Missing config: target_port")
 		send_err(errors.New("missing config: target_port"))
 		return
@@ -428,16 +407,78 @@ func start(pluginName string) {
 		return
 	}
 
-	if len(listenPorts) != len(targetPorts) || len(listenTexts) != len(targetTexts) {
-		err := errors.New("configuration error: number of listen ports, target ports, listen texts, and target texts must match")
+	var routes []*proxyRoute
+	if routesInterface, ok := (*configContext.Config)["routes"]; ok {
+		var err error
+		if routes, err = parseRoutes(routesInterface); err != nil {
+			configContext.Log.Printf("Failed to interpret routes: %v", err)
+			send_err(err)
+			return
+		}
+	}
+	if len(routes) > 0 {
+		if len(targetPorts) > 0 {
+			configContext.Log.Println("Ignoring target_port, routes are configured")
+		}
+	} else {
+		if len(listenPorts) != len(targetPorts) {
+			err := errors.New("configuration error: number of listen ports and target ports must match")
+			configContext.Log.Println(err.Error())
+			send_err(err)
+			return
+		}
+		// Without a routes table each listen port forwards everything to its target port.
+		for i := 0; i < len(listenPorts); i++ {
+			routes = append(routes, &proxyRoute{
+				PathPrefix:       "/",
+				TargetPort:       targetPorts[i],
+				ListenPort:       listenPorts[i],
+				ForwardedHeaders: true,
+			})
+		}
+	}
+
+	if len(listenTexts) != len(targetTexts) {
+		err := errors.New("configuration error: number of listen texts and target texts must match")
 		configContext.Log.Println(err.Error())
 		send_err(err)
 		return
 	}
 
+	var rewriteContentTypes []string
+	if contentTypesInterface, ok := (*configContext.Config)["rewrite_content_types"]; ok && contentTypesInterface != nil {
+		if contentTypes, ok := contentTypesInterface.(string); ok {
+			for _, contentType := range strings.Split(contentTypes, ",") {
+				if contentType = strings.ToLower(strings.TrimSpace(contentType)); contentType != "" {
+					rewriteContentTypes = append(rewriteContentTypes, contentType)
+				}
+			}
+		} else {
+			configContext.Log.Println("Failed to interpret rewrite content types")
+			send_err(errors.New("failed to interpret rewrite_content_types"))
+			return
+		}
+	}
+	rewriter := newBodyRewriter(listenTexts, targetTexts, rewriteContentTypes)
+
+	for _, route := range routes {
+		if err := route.newProxy(rewriter); err != nil {
+			configContext.Log.Printf("Failed to set up proxy for target port %d: %v", route.TargetPort, err)
+			send_err(err)
+			return
+		}
+	}
+
+	auth, err := configuredAuth()
+	if err != nil {
+		configContext.Log.Printf("Failed to configure listener authentication: %v", err)
+		send_err(err)
+		return
+	}
+
 	for i := 0; i < len(listenPorts); i++ {
-		if proxyServer, err := setUpProxy(listenPorts[i], targetPorts[i], listenTexts, targetTexts); err != nil {
-			configContext.Log.Printf("Failed to set up proxy for listen port %d and target port %d: %v", listenPorts[i], targetPorts[i], err)
+		if proxyServer, err := setUpProxy(listenPorts[i], routes, auth); err != nil {
+			configContext.Log.Printf("Failed to set up proxy for listen port %d: %v", listenPorts[i], err)
 			send_err(err)
 			return
 		} else {
@@ -519,6 +560,9 @@ func Init(pluginName string, properties *map[string]any) {
 			return
 		}
 	}
+	if clientCA, ok := (*properties)[PROCURATOR_CLIENT_CA].([]byte); ok && len(clientCA) > 0 {
+		(*configContext.ConfigCerts)[PROCURATOR_CLIENT_CA] = clientCA
+	}
 	// Change logging context
 	configContext.Log = log.New(configContext.Log.Writer(), "[procurator]", log.LstdFlags)
 	configContext.Log.Println("Successfully initialized procurator.")
@@ -529,6 +573,8 @@ func GetConfigPaths(pluginName string) []string {
 		COMMON_PATH,
 		tccore.TRCSHHIVEK_CERT,
 		tccore.TRCSHHIVEK_KEY,
+		// Optional, only auth_mode mtls or mtls_or_token needs the client CA.
+		PROCURATOR_CLIENT_CA + "?",
 	}
 }
 
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.35.1
+// 	protoc-gen-go v1.36.11
 // 	protoc        v3.21.12
 // source: trcshtalksdk/trcshtalksdk.proto
 
@@ -11,6 +11,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -25,6 +26,7 @@ type Diagnostics int32
 const (
 	// if a diagnostic deprecates, comment it out
 	// if adding a diagnostic, append to the end incrementing integer
+	// new diagnostics should register with the kernel and run by name instead
 	Diagnostics_ALL          Diagnostics = 0 // Default
 	Diagnostics_HEALTH_CHECK Diagnostics = 1
 	Diagnostics_TRCDB        Diagnostics = 2 // future plugins
@@ -71,6 +73,55 @@ func (Diagnostics) EnumDescriptor() ([]byte, []int) {
 	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{0}
 }
 
+type DiagnosticEventType int32
+
+const (
+	DiagnosticEventType_PROGRESS       DiagnosticEventType = 0
+	DiagnosticEventType_PARTIAL_RESULT DiagnosticEventType = 1
+	DiagnosticEventType_DONE           DiagnosticEventType = 2
+)
+
+// Enum value maps for DiagnosticEventType.
+var (
+	DiagnosticEventType_name = map[int32]string{
+		0: "PROGRESS",
+		1: "PARTIAL_RESULT",
+		2: "DONE",
+	}
+	DiagnosticEventType_value = map[string]int32{
+		"PROGRESS":       0,
+		"PARTIAL_RESULT": 1,
+		"DONE":           2,
+	}
+)
+
+func (x DiagnosticEventType) Enum() *DiagnosticEventType {
+	p := new(DiagnosticEventType)
+	*p = x
+	return p
+}
+
+func (x DiagnosticEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DiagnosticEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_trcshtalksdk_trcshtalksdk_proto_enumTypes[1].Descriptor()
+}
+
+func (DiagnosticEventType) Type() protoreflect.EnumType {
+	return &file_trcshtalksdk_trcshtalksdk_proto_enumTypes[1]
+}
+
+func (x DiagnosticEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DiagnosticEventType.Descriptor instead.
+func (DiagnosticEventType) EnumDescriptor() ([]byte, []int) {
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{1}
+}
+
 type PluginQuery int32
 
 const (
@@ -98,11 +149,11 @@ func (x PluginQuery) String() string {
 }
 
 func (PluginQuery) Descriptor() protoreflect.EnumDescriptor {
-	return file_trcshtalksdk_trcshtalksdk_proto_enumTypes[1].Descriptor()
+	return file_trcshtalksdk_trcshtalksdk_proto_enumTypes[2].Descriptor()
 }
 
 func (PluginQuery) Type() protoreflect.EnumType {
-	return &file_trcshtalksdk_trcshtalksdk_proto_enumTypes[1]
+	return &file_trcshtalksdk_trcshtalksdk_proto_enumTypes[2]
 }
 
 func (x PluginQuery) Number() protoreflect.EnumNumber {
@@ -111,19 +162,27 @@ func (x PluginQuery) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use PluginQuery.Descriptor instead.
 func (PluginQuery) EnumDescriptor() ([]byte, []int) {
-	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{1}
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{2}
 }
 
 type DiagnosticRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	MessageId   string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Diagnostics []Diagnostics          `protobuf:"varint,2,rep,packed,name=diagnostics,proto3,enum=trcshtalksdk.Diagnostics" json:"diagnostics,omitempty"`
+	QueryId     string                 `protobuf:"bytes,3,opt,name=query_id,json=queryId,proto3" json:"query_id,omitempty"`
+	Data        []string               `protobuf:"bytes,4,rep,name=data,proto3" json:"data,omitempty"`
+	Queries     []PluginQuery          `protobuf:"varint,5,rep,packed,name=queries,proto3,enum=trcshtalksdk.PluginQuery" json:"queries,omitempty"`
+	// Registered diagnostics to run by name, see ListDiagnostics.
+	DiagnosticNames []string `protobuf:"bytes,6,rep,name=diagnostic_names,json=diagnosticNames,proto3" json:"diagnostic_names,omitempty"`
+	// Parameters for the named diagnostics.
+	Parameters map[string]string `protobuf:"bytes,7,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Set on streamed requests queued for talkback clients, which post
+	// events as the diagnostics progress.
+	Stream bool `protobuf:"varint,8,opt,name=stream,proto3" json:"stream,omitempty"`
+	// Events a talkback client posts for a streamed request.
+	Events        []*DiagnosticEvent `protobuf:"bytes,9,rep,name=events,proto3" json:"events,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	MessageId   string        `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
-	Diagnostics []Diagnostics `protobuf:"varint,2,rep,packed,name=diagnostics,proto3,enum=trcshtalksdk.Diagnostics" json:"diagnostics,omitempty"`
-	QueryId     string        `protobuf:"bytes,3,opt,name=query_id,json=queryId,proto3" json:"query_id,omitempty"`
-	Data        []string      `protobuf:"bytes,4,rep,name=data,proto3" json:"data,omitempty"`
-	Queries     []PluginQuery `protobuf:"varint,5,rep,packed,name=queries,proto3,enum=trcshtalksdk.PluginQuery" json:"queries,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DiagnosticRequest) Reset() {
@@ -191,13 +250,40 @@ func (x *DiagnosticRequest) GetQueries() []PluginQuery {
 	return nil
 }
 
+func (x *DiagnosticRequest) GetDiagnosticNames() []string {
+	if x != nil {
+		return x.DiagnosticNames
+	}
+	return nil
+}
+
+func (x *DiagnosticRequest) GetParameters() map[string]string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *DiagnosticRequest) GetStream() bool {
+	if x != nil {
+		return x.Stream
+	}
+	return false
+}
+
+func (x *DiagnosticRequest) GetEvents() []*DiagnosticEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
 type DiagnosticResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Results       string                 `protobuf:"bytes,2,opt,name=results,proto3" json:"results,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	MessageId string `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
-	Results   string `protobuf:"bytes,2,opt,name=results,proto3" json:"results,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DiagnosticResponse) Reset() {
@@ -244,81 +330,456 @@ func (x *DiagnosticResponse) GetResults() string {
 	return ""
 }
 
-var File_trcshtalksdk_trcshtalksdk_proto protoreflect.FileDescriptor
+type DiagnosticEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	MessageId string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Type      DiagnosticEventType    `protobuf:"varint,2,opt,name=type,proto3,enum=trcshtalksdk.DiagnosticEventType" json:"type,omitempty"`
+	// The diagnostic a PARTIAL_RESULT is for.
+	Target        string `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Results       string `protobuf:"bytes,4,opt,name=results,proto3" json:"results,omitempty"`
+	Completed     int32  `protobuf:"varint,5,opt,name=completed,proto3" json:"completed,omitempty"`
+	Total         int32  `protobuf:"varint,6,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnosticEvent) Reset() {
+	*x = DiagnosticEvent{}
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnosticEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnosticEvent) ProtoMessage() {}
+
+func (x *DiagnosticEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnosticEvent.ProtoReflect.Descriptor instead.
+func (*DiagnosticEvent) Descriptor() ([]byte, []int) {
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DiagnosticEvent) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *DiagnosticEvent) GetType() DiagnosticEventType {
+	if x != nil {
+		return x.Type
+	}
+	return DiagnosticEventType_PROGRESS
+}
+
+func (x *DiagnosticEvent) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *DiagnosticEvent) GetResults() string {
+	if x != nil {
+		return x.Results
+	}
+	return ""
+}
+
+func (x *DiagnosticEvent) GetCompleted() int32 {
+	if x != nil {
+		return x.Completed
+	}
+	return 0
+}
+
+func (x *DiagnosticEvent) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type ListDiagnosticsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	MessageId string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	// Only list diagnostics of this plugin.
+	Plugin        string `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDiagnosticsRequest) Reset() {
+	*x = ListDiagnosticsRequest{}
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDiagnosticsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDiagnosticsRequest) ProtoMessage() {}
+
+func (x *ListDiagnosticsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDiagnosticsRequest.ProtoReflect.Descriptor instead.
+func (*ListDiagnosticsRequest) Descriptor() ([]byte, []int) {
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListDiagnosticsRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ListDiagnosticsRequest) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+type ListDiagnosticsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Diagnostics   []*DiagnosticInfo      `protobuf:"bytes,2,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDiagnosticsResponse) Reset() {
+	*x = ListDiagnosticsResponse{}
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDiagnosticsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDiagnosticsResponse) ProtoMessage() {}
+
+func (x *ListDiagnosticsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDiagnosticsResponse.ProtoReflect.Descriptor instead.
+func (*ListDiagnosticsResponse) Descriptor() ([]byte, []int) {
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListDiagnosticsResponse) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ListDiagnosticsResponse) GetDiagnostics() []*DiagnosticInfo {
+	if x != nil {
+		return x.Diagnostics
+	}
+	return nil
+}
+
+type DiagnosticInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Plugin        string                 `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Parameters    []*DiagnosticParameter `protobuf:"bytes,4,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	TimeoutMs     int64                  `protobuf:"varint,5,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnosticInfo) Reset() {
+	*x = DiagnosticInfo{}
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnosticInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnosticInfo) ProtoMessage() {}
+
+func (x *DiagnosticInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnosticInfo.ProtoReflect.Descriptor instead.
+func (*DiagnosticInfo) Descriptor() ([]byte, []int) {
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{5}
+}
 
-var file_trcshtalksdk_trcshtalksdk_proto_rawDesc = []byte{
-	0x0a, 0x1f, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b, 0x2f, 0x74,
-	0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x0c, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b, 0x22,
-	0xd3, 0x01, 0x0a, 0x11, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x49, 0x64, 0x12, 0x3b, 0x0a, 0x0b, 0x64, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74,
-	0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x74, 0x72, 0x63, 0x73,
-	0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b, 0x2e, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73,
-	0x74, 0x69, 0x63, 0x73, 0x52, 0x0b, 0x64, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63,
-	0x73, 0x12, 0x19, 0x0a, 0x08, 0x71, 0x75, 0x65, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x71, 0x75, 0x65, 0x72, 0x79, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04,
-	0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61,
-	0x12, 0x33, 0x0a, 0x07, 0x71, 0x75, 0x65, 0x72, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
-	0x0e, 0x32, 0x19, 0x2e, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b,
-	0x2e, 0x50, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x07, 0x71, 0x75,
-	0x65, 0x72, 0x69, 0x65, 0x73, 0x22, 0x4d, 0x0a, 0x12, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73,
-	0x74, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65,
-	0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x73,
-	0x75, 0x6c, 0x74, 0x73, 0x2a, 0x33, 0x0a, 0x0b, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74,
-	0x69, 0x63, 0x73, 0x12, 0x07, 0x0a, 0x03, 0x41, 0x4c, 0x4c, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c,
-	0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x5f, 0x43, 0x48, 0x45, 0x43, 0x4b, 0x10, 0x01, 0x12, 0x09,
-	0x0a, 0x05, 0x54, 0x52, 0x43, 0x44, 0x42, 0x10, 0x02, 0x2a, 0x1f, 0x0a, 0x0b, 0x50, 0x6c, 0x75,
-	0x67, 0x69, 0x6e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x0c, 0x41, 0x43, 0x54, 0x49,
-	0x56, 0x45, 0x5f, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x10, 0x00, 0x32, 0x67, 0x0a, 0x10, 0x54, 0x72,
-	0x63, 0x73, 0x68, 0x54, 0x61, 0x6c, 0x6b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x53,
-	0x0a, 0x0e, 0x52, 0x75, 0x6e, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63, 0x73,
-	0x12, 0x1f, 0x2e, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b, 0x2e,
-	0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x20, 0x2e, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b,
-	0x2e, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x42, 0x58, 0x5a, 0x56, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
-	0x6d, 0x2f, 0x74, 0x72, 0x69, 0x6d, 0x62, 0x6c, 0x65, 0x2d, 0x6f, 0x73, 0x73, 0x2f, 0x74, 0x69,
-	0x65, 0x72, 0x63, 0x65, 0x72, 0x6f, 0x6e, 0x2f, 0x61, 0x74, 0x72, 0x69, 0x75, 0x6d, 0x2f, 0x76,
-	0x65, 0x73, 0x74, 0x69, 0x62, 0x75, 0x6c, 0x75, 0x6d, 0x2f, 0x68, 0x69, 0x76, 0x65, 0x2f, 0x70,
-	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x73, 0x2f, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b,
-	0x2f, 0x74, 0x72, 0x63, 0x73, 0x68, 0x74, 0x61, 0x6c, 0x6b, 0x73, 0x64, 0x6b, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *DiagnosticInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DiagnosticInfo) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *DiagnosticInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *DiagnosticInfo) GetParameters() []*DiagnosticParameter {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *DiagnosticInfo) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+type DiagnosticParameter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Required      bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
+	DefaultValue  string                 `protobuf:"bytes,4,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiagnosticParameter) Reset() {
+	*x = DiagnosticParameter{}
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiagnosticParameter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiagnosticParameter) ProtoMessage() {}
+
+func (x *DiagnosticParameter) ProtoReflect() protoreflect.Message {
+	mi := &file_trcshtalksdk_trcshtalksdk_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiagnosticParameter.ProtoReflect.Descriptor instead.
+func (*DiagnosticParameter) Descriptor() ([]byte, []int) {
+	return file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DiagnosticParameter) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
+func (x *DiagnosticParameter) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *DiagnosticParameter) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *DiagnosticParameter) GetDefaultValue() string {
+	if x != nil {
+		return x.DefaultValue
+	}
+	return ""
+}
+
+var File_trcshtalksdk_trcshtalksdk_proto protoreflect.FileDescriptor
+
+const file_trcshtalksdk_trcshtalksdk_proto_rawDesc = "" +
+	"\n" +
+	"\x1ftrcshtalksdk/trcshtalksdk.proto\x12\ftrcshtalksdk\"\xdd\x03\n" +
+	"\x11DiagnosticRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12;\n" +
+	"\vdiagnostics\x18\x02 \x03(\x0e2\x19.trcshtalksdk.DiagnosticsR\vdiagnostics\x12\x19\n" +
+	"\bquery_id\x18\x03 \x01(\tR\aqueryId\x12\x12\n" +
+	"\x04data\x18\x04 \x03(\tR\x04data\x123\n" +
+	"\aqueries\x18\x05 \x03(\x0e2\x19.trcshtalksdk.PluginQueryR\aqueries\x12)\n" +
+	"\x10diagnostic_names\x18\x06 \x03(\tR\x0fdiagnosticNames\x12O\n" +
+	"\n" +
+	"parameters\x18\a \x03(\v2/.trcshtalksdk.DiagnosticRequest.ParametersEntryR\n" +
+	"parameters\x12\x16\n" +
+	"\x06stream\x18\b \x01(\bR\x06stream\x125\n" +
+	"\x06events\x18\t \x03(\v2\x1d.trcshtalksdk.DiagnosticEventR\x06events\x1a=\n" +
+	"\x0fParametersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"M\n" +
+	"\x12DiagnosticResponse\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x18\n" +
+	"\aresults\x18\x02 \x01(\tR\aresults\"\xcd\x01\n" +
+	"\x0fDiagnosticEvent\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x125\n" +
+	"\x04type\x18\x02 \x01(\x0e2!.trcshtalksdk.DiagnosticEventTypeR\x04type\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\x12\x18\n" +
+	"\aresults\x18\x04 \x01(\tR\aresults\x12\x1c\n" +
+	"\tcompleted\x18\x05 \x01(\x05R\tcompleted\x12\x14\n" +
+	"\x05total\x18\x06 \x01(\x05R\x05total\"O\n" +
+	"\x16ListDiagnosticsRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06plugin\x18\x02 \x01(\tR\x06plugin\"x\n" +
+	"\x17ListDiagnosticsResponse\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12>\n" +
+	"\vdiagnostics\x18\x02 \x03(\v2\x1c.trcshtalksdk.DiagnosticInfoR\vdiagnostics\"\xc0\x01\n" +
+	"\x0eDiagnosticInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06plugin\x18\x02 \x01(\tR\x06plugin\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12A\n" +
+	"\n" +
+	"parameters\x18\x04 \x03(\v2!.trcshtalksdk.DiagnosticParameterR\n" +
+	"parameters\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x05 \x01(\x03R\ttimeoutMs\"\x8c\x01\n" +
+	"\x13DiagnosticParameter\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1a\n" +
+	"\brequired\x18\x03 \x01(\bR\brequired\x12#\n" +
+	"\rdefault_value\x18\x04 \x01(\tR\fdefaultValue*3\n" +
+	"\vDiagnostics\x12\a\n" +
+	"\x03ALL\x10\x00\x12\x10\n" +
+	"\fHEALTH_CHECK\x10\x01\x12\t\n" +
+	"\x05TRCDB\x10\x02*A\n" +
+	"\x13DiagnosticEventType\x12\f\n" +
+	"\bPROGRESS\x10\x00\x12\x12\n" +
+	"\x0ePARTIAL_RESULT\x10\x01\x12\b\n" +
+	"\x04DONE\x10\x02*\x1f\n" +
+	"\vPluginQuery\x12\x10\n" +
+	"\fACTIVE_COUNT\x10\x002\x9e\x02\n" +
+	"\x10TrcshTalkService\x12S\n" +
+	"\x0eRunDiagnostics\x12\x1f.trcshtalksdk.DiagnosticRequest\x1a .trcshtalksdk.DiagnosticResponse\x12^\n" +
+	"\x0fListDiagnostics\x12$.trcshtalksdk.ListDiagnosticsRequest\x1a%.trcshtalksdk.ListDiagnosticsResponse\x12U\n" +
+	"\x11StreamDiagnostics\x12\x1f.trcshtalksdk.DiagnosticRequest\x1a\x1d.trcshtalksdk.DiagnosticEvent0\x01BXZVgithub.com/trimble-oss/tierceron/atrium/vestibulum/hive/plugins/trcshtalk/trcshtalksdkb\x06proto3"
+
 var (
 	file_trcshtalksdk_trcshtalksdk_proto_rawDescOnce sync.Once
-	file_trcshtalksdk_trcshtalksdk_proto_rawDescData = file_trcshtalksdk_trcshtalksdk_proto_rawDesc
+	file_trcshtalksdk_trcshtalksdk_proto_rawDescData []byte
 )
 
 func file_trcshtalksdk_trcshtalksdk_proto_rawDescGZIP() []byte {
 	file_trcshtalksdk_trcshtalksdk_proto_rawDescOnce.Do(func() {
-		file_trcshtalksdk_trcshtalksdk_proto_rawDescData = protoimpl.X.CompressGZIP(file_trcshtalksdk_trcshtalksdk_proto_rawDescData)
+		file_trcshtalksdk_trcshtalksdk_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_trcshtalksdk_trcshtalksdk_proto_rawDesc), len(file_trcshtalksdk_trcshtalksdk_proto_rawDesc)))
 	})
 	return file_trcshtalksdk_trcshtalksdk_proto_rawDescData
 }
 
-var file_trcshtalksdk_trcshtalksdk_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_trcshtalksdk_trcshtalksdk_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_trcshtalksdk_trcshtalksdk_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_trcshtalksdk_trcshtalksdk_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_trcshtalksdk_trcshtalksdk_proto_goTypes = []any{
-	(Diagnostics)(0),           // 0: trcshtalksdk.Diagnostics
-	(PluginQuery)(0),           // 1: trcshtalksdk.PluginQuery
-	(*DiagnosticRequest)(nil),  // 2: trcshtalksdk.DiagnosticRequest
-	(*DiagnosticResponse)(nil), // 3: trcshtalksdk.DiagnosticResponse
+	(Diagnostics)(0),                // 0: trcshtalksdk.Diagnostics
+	(DiagnosticEventType)(0),        // 1: trcshtalksdk.DiagnosticEventType
+	(PluginQuery)(0),                // 2: trcshtalksdk.PluginQuery
+	(*DiagnosticRequest)(nil),       // 3: trcshtalksdk.DiagnosticRequest
+	(*DiagnosticResponse)(nil),      // 4: trcshtalksdk.DiagnosticResponse
+	(*DiagnosticEvent)(nil),         // 5: trcshtalksdk.DiagnosticEvent
+	(*ListDiagnosticsRequest)(nil),  // 6: trcshtalksdk.ListDiagnosticsRequest
+	(*ListDiagnosticsResponse)(nil), // 7: trcshtalksdk.ListDiagnosticsResponse
+	(*DiagnosticInfo)(nil),          // 8: trcshtalksdk.DiagnosticInfo
+	(*DiagnosticParameter)(nil),     // 9: trcshtalksdk.DiagnosticParameter
+	nil,                             // 10: trcshtalksdk.DiagnosticRequest.ParametersEntry
 }
 var file_trcshtalksdk_trcshtalksdk_proto_depIdxs = []int32{
-	0, // 0: trcshtalksdk.DiagnosticRequest.diagnostics:type_name -> trcshtalksdk.Diagnostics
-	1, // 1: trcshtalksdk.DiagnosticRequest.queries:type_name -> trcshtalksdk.PluginQuery
-	2, // 2: trcshtalksdk.TrcshTalkService.RunDiagnostics:input_type -> trcshtalksdk.DiagnosticRequest
-	3, // 3: trcshtalksdk.TrcshTalkService.RunDiagnostics:output_type -> trcshtalksdk.DiagnosticResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: trcshtalksdk.DiagnosticRequest.diagnostics:type_name -> trcshtalksdk.Diagnostics
+	2,  // 1: trcshtalksdk.DiagnosticRequest.queries:type_name -> trcshtalksdk.PluginQuery
+	10, // 2: trcshtalksdk.DiagnosticRequest.parameters:type_name -> trcshtalksdk.DiagnosticRequest.ParametersEntry
+	5,  // 3: trcshtalksdk.DiagnosticRequest.events:type_name -> trcshtalksdk.DiagnosticEvent
+	1,  // 4: trcshtalksdk.DiagnosticEvent.type:type_name -> trcshtalksdk.DiagnosticEventType
+	8,  // 5: trcshtalksdk.ListDiagnosticsResponse.diagnostics:type_name -> trcshtalksdk.DiagnosticInfo
+	9,  // 6: trcshtalksdk.DiagnosticInfo.parameters:type_name -> trcshtalksdk.DiagnosticParameter
+	3,  // 7: trcshtalksdk.TrcshTalkService.RunDiagnostics:input_type -> trcshtalksdk.DiagnosticRequest
+	6,  // 8: trcshtalksdk.TrcshTalkService.ListDiagnostics:input_type -> trcshtalksdk.ListDiagnosticsRequest
+	3,  // 9: trcshtalksdk.TrcshTalkService.StreamDiagnostics:input_type -> trcshtalksdk.DiagnosticRequest
+	4,  // 10: trcshtalksdk.TrcshTalkService.RunDiagnostics:output_type -> trcshtalksdk.DiagnosticResponse
+	7,  // 11: trcshtalksdk.TrcshTalkService.ListDiagnostics:output_type -> trcshtalksdk.ListDiagnosticsResponse
+	5,  // 12: trcshtalksdk.TrcshTalkService.StreamDiagnostics:output_type -> trcshtalksdk.DiagnosticEvent
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_trcshtalksdk_trcshtalksdk_proto_init() }
@@ -330,9 +791,9 @@ func file_trcshtalksdk_trcshtalksdk_proto_init() {
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_trcshtalksdk_trcshtalksdk_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   2,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_trcshtalksdk_trcshtalksdk_proto_rawDesc), len(file_trcshtalksdk_trcshtalksdk_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -342,7 +803,6 @@ func file_trcshtalksdk_trcshtalksdk_proto_init() {
 		MessageInfos:      file_trcshtalksdk_trcshtalksdk_proto_msgTypes,
 	}.Build()
 	File_trcshtalksdk_trcshtalksdk_proto = out.File
-	file_trcshtalksdk_trcshtalksdk_proto_rawDesc = nil
 	file_trcshtalksdk_trcshtalksdk_proto_goTypes = nil
 	file_trcshtalksdk_trcshtalksdk_proto_depIdxs = nil
 }
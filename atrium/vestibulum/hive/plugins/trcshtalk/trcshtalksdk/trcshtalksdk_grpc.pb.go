@@ -19,7 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TrcshTalkService_RunDiagnostics_FullMethodName = "/trcshtalksdk.TrcshTalkService/RunDiagnostics"
+	TrcshTalkService_RunDiagnostics_FullMethodName    = "/trcshtalksdk.TrcshTalkService/RunDiagnostics"
+	TrcshTalkService_ListDiagnostics_FullMethodName   = "/trcshtalksdk.TrcshTalkService/ListDiagnostics"
+	TrcshTalkService_StreamDiagnostics_FullMethodName = "/trcshtalksdk.TrcshTalkService/StreamDiagnostics"
 )
 
 // TrcshTalkServiceClient is the client API for TrcshTalkService service.
@@ -27,6 +29,11 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TrcshTalkServiceClient interface {
 	RunDiagnostics(ctx context.Context, in *DiagnosticRequest, opts ...grpc.CallOption) (*DiagnosticResponse, error)
+	// Lists the diagnostics registered by the kernel's plugins.
+	ListDiagnostics(ctx context.Context, in *ListDiagnosticsRequest, opts ...grpc.CallOption) (*ListDiagnosticsResponse, error)
+	// Runs diagnostics like RunDiagnostics, streaming progress and each
+	// diagnostic's result as it completes.  The last event is DONE.
+	StreamDiagnostics(ctx context.Context, in *DiagnosticRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DiagnosticEvent], error)
 }
 
 type trcshTalkServiceClient struct {
@@ -47,11 +54,45 @@ func (c *trcshTalkServiceClient) RunDiagnostics(ctx context.Context, in *Diagnos
 	return out, nil
 }
 
+func (c *trcshTalkServiceClient) ListDiagnostics(ctx context.Context, in *ListDiagnosticsRequest, opts ...grpc.CallOption) (*ListDiagnosticsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDiagnosticsResponse)
+	err := c.cc.Invoke(ctx, TrcshTalkService_ListDiagnostics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trcshTalkServiceClient) StreamDiagnostics(ctx context.Context, in *DiagnosticRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DiagnosticEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TrcshTalkService_ServiceDesc.Streams[0], TrcshTalkService_StreamDiagnostics_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DiagnosticRequest, DiagnosticEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TrcshTalkService_StreamDiagnosticsClient = grpc.ServerStreamingClient[DiagnosticEvent]
+
 // TrcshTalkServiceServer is the server API for TrcshTalkService service.
 // All implementations must embed UnimplementedTrcshTalkServiceServer
 // for forward compatibility.
 type TrcshTalkServiceServer interface {
 	RunDiagnostics(context.Context, *DiagnosticRequest) (*DiagnosticResponse, error)
+	// Lists the diagnostics registered by the kernel's plugins.
+	ListDiagnostics(context.Context, *ListDiagnosticsRequest) (*ListDiagnosticsResponse, error)
+	// Runs diagnostics like RunDiagnostics, streaming progress and each
+	// diagnostic's result as it completes.  The last event is DONE.
+	StreamDiagnostics(*DiagnosticRequest, grpc.ServerStreamingServer[DiagnosticEvent]) error
 	mustEmbedUnimplementedTrcshTalkServiceServer()
 }
 
@@ -65,6 +106,12 @@ type UnimplementedTrcshTalkServiceServer struct{}
 func (UnimplementedTrcshTalkServiceServer) RunDiagnostics(context.Context, *DiagnosticRequest) (*DiagnosticResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RunDiagnostics not implemented")
 }
+func (UnimplementedTrcshTalkServiceServer) ListDiagnostics(context.Context, *ListDiagnosticsRequest) (*ListDiagnosticsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDiagnostics not implemented")
+}
+func (UnimplementedTrcshTalkServiceServer) StreamDiagnostics(*DiagnosticRequest, grpc.ServerStreamingServer[DiagnosticEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDiagnostics not implemented")
+}
 func (UnimplementedTrcshTalkServiceServer) mustEmbedUnimplementedTrcshTalkServiceServer() {}
 func (UnimplementedTrcshTalkServiceServer) testEmbeddedByValue()                          {}
 
@@ -104,6 +151,35 @@ func _TrcshTalkService_RunDiagnostics_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrcshTalkService_ListDiagnostics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDiagnosticsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrcshTalkServiceServer).ListDiagnostics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TrcshTalkService_ListDiagnostics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrcshTalkServiceServer).ListDiagnostics(ctx, req.(*ListDiagnosticsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrcshTalkService_StreamDiagnostics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DiagnosticRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrcshTalkServiceServer).StreamDiagnostics(m, &grpc.GenericServerStream[DiagnosticRequest, DiagnosticEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TrcshTalkService_StreamDiagnosticsServer = grpc.ServerStreamingServer[DiagnosticEvent]
+
 // TrcshTalkService_ServiceDesc is the grpc.ServiceDesc for TrcshTalkService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +191,17 @@ var TrcshTalkService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RunDiagnostics",
 			Handler:    _TrcshTalkService_RunDiagnostics_Handler,
 		},
+		{
+			MethodName: "ListDiagnostics",
+			Handler:    _TrcshTalkService_ListDiagnostics_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDiagnostics",
+			Handler:       _TrcshTalkService_StreamDiagnostics_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "trcshtalksdk/trcshtalksdk.proto",
 }
@@ -0,0 +1,212 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	pb "github.com/trimble-oss/tierceron/atrium/vestibulum/hive/plugins/trcshtalk/trcshtalksdk"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Properties keys the kernel provides the diagnostics registry and chat
+// requests under (see pkg/core/util/hive/diagnostics and chatrequest).
+const (
+	PropListDiagnostics = "listDiagnostics"
+	PropChatRequest     = "chatRequest"
+)
+
+const defaultDiagnosticTimeout = 30 * time.Second
+
+// builtinDiagnostics run as the legacy Diagnostics enum chat queries rather
+// than through the registry.
+var builtinDiagnostics = map[string]pb.Diagnostics{
+	"healthcheck": pb.Diagnostics_HEALTH_CHECK,
+	"trcdb":       pb.Diagnostics_TRCDB,
+}
+
+// DiagnosticsRegistry gives trcshtalk access to the diagnostics plugins
+// registered with the kernel at Init.
+type DiagnosticsRegistry struct {
+	list        func() []byte
+	chatRequest func(context.Context, string, *tccore.ChatMsg) (*tccore.ChatMsg, error)
+}
+
+// NewDiagnosticsRegistry picks up the kernel's registry from trcshtalk's Init
+// properties.  Without a kernel only the builtin diagnostics are available.
+func NewDiagnosticsRegistry(properties *map[string]any) *DiagnosticsRegistry {
+	registry := &DiagnosticsRegistry{}
+	if properties == nil {
+		return registry
+	}
+	if list, ok := (*properties)[PropListDiagnostics].(func() []byte); ok {
+		registry.list = list
+	}
+	if chatRequest, ok := (*properties)[PropChatRequest].(func(context.Context, string, *tccore.ChatMsg) (*tccore.ChatMsg, error)); ok {
+		registry.chatRequest = chatRequest
+	}
+	return registry
+}
+
+// List returns the builtin and registered diagnostics, only those of plugin
+// if it isn't empty.
+func (r *DiagnosticsRegistry) List(plugin string) []*pb.DiagnosticInfo {
+	infos := []*pb.DiagnosticInfo{
+		{Name: "healthcheck", Plugin: "healthcheck", Description: "Health of the kernel's plugins, also run as Diagnostics HEALTH_CHECK or ALL"},
+		{Name: "trcdb", Plugin: "trcdb", Description: "trcdb tests named in the request data, also run as Diagnostics TRCDB"},
+	}
+	if r != nil && r.list != nil {
+		var registered []json.RawMessage
+		if err := json.Unmarshal(r.list(), &registered); err == nil {
+			for _, definition := range registered {
+				info := &pb.DiagnosticInfo{}
+				if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(definition, info); err != nil {
+					continue
+				}
+				if _, ok := builtinDiagnostics[info.GetName()]; !ok {
+					infos = append(infos, info)
+				}
+			}
+		}
+	}
+	filtered := []*pb.DiagnosticInfo{}
+	for _, info := range infos {
+		if plugin == "" || info.GetPlugin() == plugin {
+			filtered = append(filtered, info)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].GetName() < filtered[j].GetName() })
+	return filtered
+}
+
+// Lookup returns the diagnostic named name.
+func (r *DiagnosticsRegistry) Lookup(name string) (*pb.DiagnosticInfo, bool) {
+	for _, info := range r.List("") {
+		if info.GetName() == name {
+			return info, true
+		}
+	}
+	return nil, false
+}
+
+// Registered reports whether all names are known here.
+func (r *DiagnosticsRegistry) Registered(names []string) bool {
+	for _, name := range names {
+		if _, ok := r.Lookup(name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitDiagnostics separates the request's legacy diagnostics, including
+// builtins requested by name, from the registered diagnostics to run by name.
+func SplitDiagnostics(req *pb.DiagnosticRequest) ([]pb.Diagnostics, []string) {
+	legacy := append([]pb.Diagnostics{}, req.GetDiagnostics()...)
+	named := []string{}
+	for _, name := range req.GetDiagnosticNames() {
+		if diagnostic, ok := builtinDiagnostics[name]; ok {
+			if !contains(legacy, diagnostic) {
+				legacy = append(legacy, diagnostic)
+			}
+		} else if !contains(named, name) {
+			named = append(named, name)
+		}
+	}
+	return legacy, named
+}
+
+// Run invokes the named diagnostics on the plugins that registered them,
+// concurrently and each within its timeout.  Results are reported one line
+// per diagnostic in the requested order.
+func (r *DiagnosticsRegistry) Run(ctx *tccore.ConfigContext, names []string, parameters map[string]string) string {
+	return r.RunContext(context.Background(), ctx, names, parameters, nil)
+}
+
+// RunContext is Run abandoning the diagnostics when reqCtx is done.  The
+// optional onResult is called with each diagnostic's result as it completes.
+func (r *DiagnosticsRegistry) RunContext(reqCtx context.Context, ctx *tccore.ConfigContext, names []string, parameters map[string]string, onResult func(name string, result string)) string {
+	results := make([]string, len(names))
+	declared := map[string]bool{}
+	var resultLock sync.Mutex
+	complete := func(i int, result string) {
+		resultLock.Lock()
+		defer resultLock.Unlock()
+		results[i] = fmt.Sprintf("%s: %s", names[i], result)
+		if onResult != nil {
+			onResult(names[i], result)
+		}
+	}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		info, ok := r.Lookup(name)
+		if !ok {
+			complete(i, "error: unknown diagnostic")
+			continue
+		}
+		resolved := map[string]string{}
+		missing := ""
+		for _, parameter := range info.GetParameters() {
+			declared[parameter.GetName()] = true
+			if value, ok := parameters[parameter.GetName()]; ok {
+				resolved[parameter.GetName()] = value
+			} else if parameter.GetRequired() {
+				missing = parameter.GetName()
+			} else if parameter.GetDefaultValue() != "" {
+				resolved[parameter.GetName()] = parameter.GetDefaultValue()
+			}
+		}
+		if missing != "" {
+			complete(i, "error: missing required parameter "+missing)
+			continue
+		}
+		if r == nil || r.chatRequest == nil {
+			complete(i, "error: diagnostics registry not supported")
+			continue
+		}
+		wg.Add(1)
+		go func(i int, info *pb.DiagnosticInfo, resolved map[string]string) {
+			defer wg.Done()
+			complete(i, r.invoke(reqCtx, ctx, info, resolved))
+		}(i, info, resolved)
+	}
+	wg.Wait()
+
+	undeclared := []string{}
+	for name := range parameters {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+		results = append(results, "warning: parameters not declared by the requested diagnostics: "+strings.Join(undeclared, ", "))
+	}
+	return strings.Join(results, "\n")
+}
+
+func (r *DiagnosticsRegistry) invoke(reqCtx context.Context, ctx *tccore.ConfigContext, info *pb.DiagnosticInfo, parameters map[string]string) string {
+	timeout := time.Duration(info.GetTimeoutMs()) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultDiagnosticTimeout
+	}
+	requestCtx, cancel := context.WithTimeout(reqCtx, timeout)
+	defer cancel()
+
+	name := info.GetName()
+	ctx.Log.Printf("Running %s diagnostic on %s.\n", name, info.GetPlugin())
+	response, err := r.chatRequest(requestCtx, info.GetPlugin(), &tccore.ChatMsg{ChatId: &name, HookResponse: parameters})
+	if err != nil {
+		ctx.Log.Printf("Diagnostic %s failed: %v\n", name, err)
+		return fmt.Sprintf("error: %v", err)
+	}
+	if response == nil || response.Response == nil {
+		return "error: no response"
+	}
+	return *response.Response
+}
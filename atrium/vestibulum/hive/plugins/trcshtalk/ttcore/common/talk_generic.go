@@ -110,6 +110,13 @@ func StartTrashTalkingGeneric(
 // routingId/chatId are passed in so callers don't duplicate the ChatMsg creation line.
 // pluginName kept separate so different callers can reuse even if they vary the name.
 func CollectQueryResponses(ctx *tccore.ConfigContext, routingId *string, pluginName string, chatId *string, queries []string) (messageId string, results string) {
+	return CollectQueryResponsesContext(context.Background(), ctx, routingId, pluginName, chatId, queries, nil)
+}
+
+// CollectQueryResponsesContext is CollectQueryResponses giving up when reqCtx
+// is done.  The optional onResponse is called with each query's response as
+// it arrives.
+func CollectQueryResponsesContext(reqCtx context.Context, ctx *tccore.ConfigContext, routingId *string, pluginName string, chatId *string, queries []string, onResponse func(query string, response string)) (messageId string, results string) {
 	if ctx == nil {
 		return "", ""
 	}
@@ -122,7 +129,16 @@ func CollectQueryResponses(ctx *tccore.ConfigContext, routingId *string, pluginN
 	finished := make(map[string]string)
 	ctx.Log.Printf("Sent queries to kernel: %d\n", len(queries))
 	for {
-		event := <-*ctx.ChatReceiverChan
+		var event *tccore.ChatMsg
+		select {
+		case event = <-*ctx.ChatReceiverChan:
+		case <-reqCtx.Done():
+			ctx.Log.Printf("Stopped waiting for query responses: %v\n", reqCtx.Err())
+			for _, v := range finished {
+				results += v + " "
+			}
+			return messageId, results
+		}
 		ctx.Log.Println("TrcshTalk received message from kernel.")
 		if event.RoutingId != nil {
 			messageId = *event.RoutingId
@@ -130,6 +146,9 @@ func CollectQueryResponses(ctx *tccore.ConfigContext, routingId *string, pluginN
 		if event.Query != nil && len(*event.Query) == 1 && event.Response != nil && (*event).Response != nil {
 			ctx.Log.Printf("Processing response from query: %s\n", *event.Query)
 			finished[(*event.Query)[0]] = *event.Response
+			if onResponse != nil {
+				onResponse((*event.Query)[0], *event.Response)
+			}
 		}
 		if len(finished) == len(queries) {
 			ctx.Log.Println("Formatting responses.")
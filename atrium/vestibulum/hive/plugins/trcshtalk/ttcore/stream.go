@@ -0,0 +1,249 @@
+package ttcore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/trimble-oss/tierceron/atrium/vestibulum/hive/plugins/trcshtalk/trcshtalksdk"
+	"github.com/trimble-oss/tierceron/atrium/vestibulum/hive/plugins/trcshtalk/ttcore/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Results returned to talkback clients posting for a proxied request.
+const (
+	proxyPosted    = "Response posted"
+	proxyCancelled = "Cancelled"
+)
+
+// streamProgressInterval is how often a PROGRESS event is sent while
+// diagnostics are running.  On a talkback client these posts are also how it
+// learns that the caller went away.
+var streamProgressInterval = 5 * time.Second
+
+// Closed proxied requests are remembered this long to answer late posts.
+const closedProxyRequestTTL = 10 * time.Minute
+
+// proxyStream forwards the events a talkback client posts for a proxied
+// StreamDiagnostics call.  done is closed once the call has returned.
+type proxyStream struct {
+	events chan *pb.DiagnosticEvent
+	done   chan struct{}
+}
+
+type closedProxy struct {
+	result string
+	closed time.Time
+}
+
+var (
+	proxyStreams        sync.Map // message id -> *proxyStream
+	closedProxyRequests sync.Map // message id -> *closedProxy
+)
+
+// Runs diagnostics like RunDiagnostics, sending a PROGRESS event when they
+// start, a PARTIAL_RESULT event as each completes and a DONE event with the
+// combined results.  When the caller goes away the diagnostics are cancelled,
+// on a talkback client too if the request was proxied.
+func (s *diagnosticsServiceServer) StreamDiagnostics(req *pb.DiagnosticRequest, stream pb.TrcshTalkService_StreamDiagnosticsServer) error {
+	if len(req.GetDiagnostics()) == 0 && len(req.GetDiagnosticNames()) == 0 {
+		return status.Error(codes.InvalidArgument, "no diagnostics requested")
+	}
+	if req.GetMessageId() == "" {
+		req.MessageId = GenMsgId(configContext.Env, configContext.Region, false)
+	}
+	if runsLocally(req) {
+		var sendErr error
+		streamDiagnostics(stream.Context(), req, func(event *pb.DiagnosticEvent) {
+			if sendErr == nil {
+				sendErr = stream.Send(event)
+			}
+		})
+		return sendErr
+	}
+	return streamProxyRequest(stream.Context(), req, stream.Send)
+}
+
+// streamDiagnostics runs the legacy and named diagnostics of req, emitting
+// their events, and returns the combined results.  Events are emitted one at
+// a time.
+func streamDiagnostics(reqCtx context.Context, req *pb.DiagnosticRequest, emit func(*pb.DiagnosticEvent)) string {
+	cmds, named := common.SplitDiagnostics(req)
+	queries, queryTest := []string{}, ""
+	if len(cmds) > 0 {
+		queries, queryTest = legacyQueries(req, cmds)
+	}
+	total := int32(len(queries) + len(named))
+
+	var emitLock sync.Mutex
+	completed := int32(0)
+	send := func(event *pb.DiagnosticEvent) {
+		emitLock.Lock()
+		defer emitLock.Unlock()
+		if event.GetType() == pb.DiagnosticEventType_PARTIAL_RESULT {
+			completed++
+		}
+		event.MessageId = req.GetMessageId()
+		event.Completed = completed
+		event.Total = total
+		emit(event)
+	}
+	onResult := func(target string, result string) {
+		send(&pb.DiagnosticEvent{Type: pb.DiagnosticEventType_PARTIAL_RESULT, Target: target, Results: result})
+	}
+	send(&pb.DiagnosticEvent{Type: pb.DiagnosticEventType_PROGRESS, Results: fmt.Sprintf("Running %d diagnostics", total)})
+	progressDone := make(chan struct{})
+	ticker := time.NewTicker(streamProgressInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				send(&pb.DiagnosticEvent{Type: pb.DiagnosticEventType_PROGRESS, Results: "Running"})
+			case <-progressDone:
+				return
+			}
+		}
+	}()
+
+	namedResults := ""
+	var wg sync.WaitGroup
+	if len(named) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			namedResults = diagnosticsRegistry.RunContext(reqCtx, configContext, named, req.GetParameters(), onResult)
+		}()
+	}
+	results := ""
+	if len(queries) > 0 {
+		_, results = common.CollectQueryResponsesContext(reqCtx, configContext, &req.MessageId, "trcshtalk", &queryTest, queries, onResult)
+	}
+	wg.Wait()
+	close(progressDone)
+
+	results = joinResults(results, namedResults)
+	if reqCtx.Err() != nil {
+		results = joinResults(results, proxyCancelled)
+	}
+	send(&pb.DiagnosticEvent{Type: pb.DiagnosticEventType_DONE, Results: results})
+	return results
+}
+
+// streamProxyRequest queues req for a talkback client and forwards the events
+// it posts until DONE.  If ctx ends first the request is closed as cancelled,
+// which the client learns from the result of its next post.
+func streamProxyRequest(ctx context.Context, req *pb.DiagnosticRequest, send func(*pb.DiagnosticEvent) error) error {
+	stream := &proxyStream{
+		events: make(chan *pb.DiagnosticEvent, 16),
+		done:   make(chan struct{}),
+	}
+	proxyStreams.Store(req.GetMessageId(), stream)
+	defer func() {
+		proxyStreams.Delete(req.GetMessageId())
+		close(stream.done)
+	}()
+
+	queued := proto.Clone(req).(*pb.DiagnosticRequest)
+	queued.Stream = true
+	select {
+	case proxyRequestChan <- queued:
+	case <-ctx.Done():
+		closeProxyRequest(req.GetMessageId(), proxyCancelled)
+		return ctx.Err()
+	}
+
+	for {
+		select {
+		case event := <-stream.events:
+			if err := send(event); err != nil {
+				closeProxyRequest(req.GetMessageId(), proxyCancelled)
+				return err
+			}
+			if event.GetType() == pb.DiagnosticEventType_DONE {
+				closeProxyRequest(req.GetMessageId(), proxyPosted)
+				return nil
+			}
+		case <-ctx.Done():
+			closeProxyRequest(req.GetMessageId(), proxyCancelled)
+			return ctx.Err()
+		}
+	}
+}
+
+// postProxyEvents hands the events a talkback client posted to the waiting
+// stream.  A final Data post from a client that doesn't stream completes it.
+func postProxyEvents(stream *proxyStream, req *pb.DiagnosticRequest) *pb.DiagnosticResponse {
+	events := req.GetEvents()
+	if len(events) == 0 {
+		events = []*pb.DiagnosticEvent{{
+			MessageId: req.GetMessageId(),
+			Type:      pb.DiagnosticEventType_DONE,
+			Results:   req.GetData()[0],
+		}}
+	}
+	closed := func() *pb.DiagnosticResponse {
+		result, _ := closedProxyRequest(req.GetMessageId())
+		return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: result}
+	}
+	for _, event := range events {
+		// Checked first, a send to the buffered events could still succeed
+		// after the call returned.
+		select {
+		case <-stream.done:
+			return closed()
+		default:
+		}
+		select {
+		case stream.events <- event:
+		case <-stream.done:
+			return closed()
+		}
+	}
+	return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: proxyPosted}
+}
+
+// closeProxyRequest records how a proxied request ended, dropping records
+// older than closedProxyRequestTTL.
+func closeProxyRequest(messageId string, result string) {
+	now := time.Now()
+	closedProxyRequests.Range(func(id, value any) bool {
+		if now.Sub(value.(*closedProxy).closed) > closedProxyRequestTTL {
+			closedProxyRequests.Delete(id)
+		}
+		return true
+	})
+	closedProxyRequests.Store(messageId, &closedProxy{result: result, closed: now})
+}
+
+func closedProxyRequest(messageId string) (string, bool) {
+	if value, ok := closedProxyRequests.Load(messageId); ok {
+		return value.(*closedProxy).result, true
+	}
+	return "", false
+}
+
+// proxyRequestCancelled reports whether the caller of a queued request has
+// already gone away.
+func proxyRequestCancelled(req *pb.DiagnosticRequest) bool {
+	result, ok := closedProxyRequest(req.GetMessageId())
+	return ok && result == proxyCancelled
+}
+
+// trcshTalkBackStream runs a proxied StreamDiagnostics request on a talkback
+// client, posting its events as they happen.  post returns false once the
+// hub reports the request cancelled, which cancels the diagnostics.
+func trcshTalkBackStream(req *pb.DiagnosticRequest, post func(events ...*pb.DiagnosticEvent) bool) *pb.DiagnosticResponse {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := streamDiagnostics(reqCtx, req, func(event *pb.DiagnosticEvent) {
+		if reqCtx.Err() == nil && !post(event) {
+			configContext.Log.Printf("Streamed diagnostics %s cancelled.\n", req.GetMessageId())
+			cancel()
+		}
+	})
+	return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: results}
+}
@@ -0,0 +1,198 @@
+package ttcore
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	pb "github.com/trimble-oss/tierceron/atrium/vestibulum/hive/plugins/trcshtalk/trcshtalksdk"
+	"github.com/trimble-oss/tierceron/atrium/vestibulum/hive/plugins/trcshtalk/ttcore/common"
+)
+
+// setUpStreamTest registers the diagnostics fast, which answers at once, and
+// slow, which only returns when cancelled.  Cancellations of slow are sent on
+// the returned channel.
+func setUpStreamTest(t *testing.T) chan string {
+	configContext = &tccore.ConfigContext{Log: log.New(io.Discard, "", 0)}
+	cancelled := make(chan string, 8)
+	properties := map[string]any{
+		common.PropListDiagnostics: func() []byte {
+			return []byte(`[{"name":"fast","plugin":"test"},{"name":"slow","plugin":"test","timeout_ms":60000}]`)
+		},
+		common.PropChatRequest: func(ctx context.Context, target string, msg *tccore.ChatMsg) (*tccore.ChatMsg, error) {
+			if *msg.ChatId == "slow" {
+				<-ctx.Done()
+				cancelled <- *msg.ChatId
+				return nil, ctx.Err()
+			}
+			response := "ok"
+			return &tccore.ChatMsg{Response: &response}, nil
+		},
+	}
+	diagnosticsRegistry = common.NewDiagnosticsRegistry(&properties)
+	t.Cleanup(func() {
+		configContext = nil
+		diagnosticsRegistry = nil
+	})
+	return cancelled
+}
+
+func TestStreamDiagnosticsEvents(t *testing.T) {
+	setUpStreamTest(t)
+	events := []*pb.DiagnosticEvent{}
+	results := streamDiagnostics(context.Background(), &pb.DiagnosticRequest{MessageId: "m1", DiagnosticNames: []string{"fast", "fast"}}, func(event *pb.DiagnosticEvent) {
+		events = append(events, event)
+	})
+	if results != "fast: ok" {
+		t.Fatalf("Unexpected results %q", results)
+	}
+	expected := []pb.DiagnosticEventType{pb.DiagnosticEventType_PROGRESS, pb.DiagnosticEventType_PARTIAL_RESULT, pb.DiagnosticEventType_DONE}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.GetType() != expected[i] || event.GetMessageId() != "m1" || event.GetTotal() != 1 {
+			t.Fatalf("Unexpected event %d: %v", i, event)
+		}
+	}
+	if events[0].GetCompleted() != 0 || events[1].GetCompleted() != 1 || events[1].GetTarget() != "fast" || events[1].GetResults() != "ok" {
+		t.Fatalf("Unexpected progress %v", events)
+	}
+	if events[2].GetCompleted() != 1 || events[2].GetResults() != results {
+		t.Fatalf("Unexpected DONE event %v", events[2])
+	}
+}
+
+func TestStreamDiagnosticsCancelled(t *testing.T) {
+	cancelled := setUpStreamTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *pb.DiagnosticEvent, 8)
+	go func() {
+		for event := range events {
+			if event.GetType() == pb.DiagnosticEventType_PARTIAL_RESULT {
+				cancel()
+			}
+		}
+	}()
+	results := streamDiagnostics(ctx, &pb.DiagnosticRequest{MessageId: "m2", DiagnosticNames: []string{"fast", "slow"}}, func(event *pb.DiagnosticEvent) {
+		events <- event
+	})
+	close(events)
+	if <-cancelled != "slow" {
+		t.Fatal("Expected slow to be cancelled")
+	}
+	if !strings.Contains(results, "fast: ok") || !strings.HasSuffix(results, proxyCancelled) {
+		t.Fatalf("Unexpected results %q", results)
+	}
+}
+
+// talkBackPost posts events for messageId the way a talkback client does,
+// returning false once the hub reports the request cancelled.
+func talkBackPost(messageId string) func(events ...*pb.DiagnosticEvent) bool {
+	return func(events ...*pb.DiagnosticEvent) bool {
+		value, ok := proxyStreams.Load(messageId)
+		if !ok {
+			result, _ := closedProxyRequest(messageId)
+			return result != proxyCancelled
+		}
+		return postProxyEvents(value.(*proxyStream), &pb.DiagnosticRequest{MessageId: messageId, Events: events}).GetResults() != proxyCancelled
+	}
+}
+
+func TestStreamProxyRequest(t *testing.T) {
+	setUpStreamTest(t)
+	sent := make(chan *pb.DiagnosticEvent, 8)
+	returned := make(chan error, 1)
+	go func() {
+		returned <- streamProxyRequest(context.Background(), &pb.DiagnosticRequest{MessageId: "m3", DiagnosticNames: []string{"fast"}}, func(event *pb.DiagnosticEvent) error {
+			sent <- event
+			return nil
+		})
+	}()
+	queued := <-proxyRequestChan
+	if !queued.GetStream() || queued.GetMessageId() != "m3" {
+		t.Fatalf("Expected a streamed request to be queued, got %v", queued)
+	}
+	if response := trcshTalkBackStream(queued, talkBackPost("m3")); response.GetResults() != "fast: ok" {
+		t.Fatalf("Unexpected talkback response %v", response)
+	}
+	if err := <-returned; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	close(sent)
+	types := []pb.DiagnosticEventType{}
+	for event := range sent {
+		types = append(types, event.GetType())
+	}
+	if len(types) != 3 || types[0] != pb.DiagnosticEventType_PROGRESS || types[1] != pb.DiagnosticEventType_PARTIAL_RESULT || types[2] != pb.DiagnosticEventType_DONE {
+		t.Fatalf("Unexpected events forwarded %v", types)
+	}
+	if result, _ := closedProxyRequest("m3"); result != proxyPosted {
+		t.Fatalf("Expected the request to be closed as posted, got %q", result)
+	}
+
+	// A client that doesn't stream completes the call with its Data post.
+	go func() {
+		returned <- streamProxyRequest(context.Background(), &pb.DiagnosticRequest{MessageId: "m4"}, func(event *pb.DiagnosticEvent) error {
+			if event.GetType() != pb.DiagnosticEventType_DONE || event.GetResults() != "legacy results" {
+				t.Errorf("Unexpected event %v", event)
+			}
+			return nil
+		})
+	}()
+	<-proxyRequestChan
+	value, _ := proxyStreams.Load("m4")
+	if response := postProxyEvents(value.(*proxyStream), &pb.DiagnosticRequest{MessageId: "m4", Data: []string{"legacy results"}}); response.GetResults() != proxyPosted {
+		t.Fatalf("Unexpected response %v", response)
+	}
+	if err := <-returned; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestStreamProxyRequestCancelled(t *testing.T) {
+	defer func(interval time.Duration) { streamProgressInterval = interval }(streamProgressInterval)
+	streamProgressInterval = 10 * time.Millisecond
+	cancelled := setUpStreamTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan error, 1)
+	go func() {
+		returned <- streamProxyRequest(ctx, &pb.DiagnosticRequest{MessageId: "m5", DiagnosticNames: []string{"slow"}}, func(event *pb.DiagnosticEvent) error {
+			return nil
+		})
+	}()
+	queued := <-proxyRequestChan
+	talkBack := make(chan *pb.DiagnosticResponse, 1)
+	go func() {
+		talkBack <- trcshTalkBackStream(queued, talkBackPost("m5"))
+	}()
+	cancel()
+	if err := <-returned; err != context.Canceled {
+		t.Fatalf("Expected the call to be cancelled, got %v", err)
+	}
+
+	// The next progress post tells the talkback client, which cancels slow.
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancellation did not reach the talkback client")
+	}
+	if response := <-talkBack; !strings.HasSuffix(response.GetResults(), proxyCancelled) {
+		t.Fatalf("Unexpected talkback response %v", response)
+	}
+	value := &proxyStream{events: make(chan *pb.DiagnosticEvent, 16), done: make(chan struct{})}
+	close(value.done)
+	for i := 0; i < 100; i++ {
+		if response := postProxyEvents(value, &pb.DiagnosticRequest{MessageId: "m5", Events: []*pb.DiagnosticEvent{{}}}); response.GetResults() != proxyCancelled {
+			t.Fatalf("Expected posts after the call returned to be cancelled, got %v", response)
+		}
+	}
+	if len(value.events) != 0 {
+		t.Fatalf("Expected no events to be accepted after the call returned, %d were", len(value.events))
+	}
+}
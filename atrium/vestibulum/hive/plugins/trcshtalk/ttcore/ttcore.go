@@ -26,9 +26,10 @@ type diagnosticsServiceServer struct {
 }
 
 var (
-	configContext *tccore.ConfigContext
-	grpcServer    *grpc.Server
-	dfstat        *tccore.TTDINode
+	configContext       *tccore.ConfigContext
+	grpcServer          *grpc.Server
+	dfstat              *tccore.TTDINode
+	diagnosticsRegistry *common.DiagnosticsRegistry
 )
 
 var (
@@ -42,20 +43,47 @@ var (
 // Returns DiagnosticResponse, forwarding the MessageId of the DiagnosticRequest,
 // and providing the results of the diagnostics ran.
 func (s *diagnosticsServiceServer) RunDiagnostics(ctx context.Context, req *pb.DiagnosticRequest) (*pb.DiagnosticResponse, error) {
-	if len(req.GetDiagnostics()) == 0 {
+	if len(req.GetDiagnostics()) == 0 && len(req.GetDiagnosticNames()) == 0 {
 		if response, handled := postProxyResponse(req); handled {
 			return response, nil
 		}
 		return dequeueProxyRequest(ctx, req)
 	}
-	if requestSupportedByDeployments(req.GetDiagnostics(), common.SupportedDeploymentsSet(configContext)) {
+	if runsLocally(req) {
 		return runLocalDiagnostics(req)
 	}
 	return enqueueProxyRequest(ctx, req)
 }
 
+// runsLocally reports whether req can be run by this kernel rather than
+// proxied to a talkback client.  Diagnostics not registered here may be
+// registered by a talkback kernel.
+func runsLocally(req *pb.DiagnosticRequest) bool {
+	supportedDeployments := common.SupportedDeploymentsSet(configContext)
+	return requestSupportedByDeployments(req, supportedDeployments) &&
+		(len(supportedDeployments) == 0 || diagnosticsRegistry.Registered(req.GetDiagnosticNames()))
+}
+
+// Lists the builtin diagnostics and those registered by the kernel's plugins.
+func (s *diagnosticsServiceServer) ListDiagnostics(ctx context.Context, req *pb.ListDiagnosticsRequest) (*pb.ListDiagnosticsResponse, error) {
+	return &pb.ListDiagnosticsResponse{
+		MessageId:   req.GetMessageId(),
+		Diagnostics: diagnosticsRegistry.List(req.GetPlugin()),
+	}, nil
+}
+
 func runLocalDiagnostics(req *pb.DiagnosticRequest) (*pb.DiagnosticResponse, error) {
-	cmds := req.GetDiagnostics()
+	cmds, named := common.SplitDiagnostics(req)
+	if len(cmds) == 0 {
+		return &pb.DiagnosticResponse{
+			MessageId: req.GetMessageId(),
+			Results:   diagnosticsRegistry.Run(configContext, named, req.GetParameters()),
+		}, nil
+	}
+	namedResults := ""
+	if len(named) > 0 {
+		namedResults = diagnosticsRegistry.Run(configContext, named, req.GetParameters())
+	}
 	queries := []string{}
 	queryTest := req.GetQueryId() + ":"
 	if slices.Contains(cmds, pb.Diagnostics_ALL) {
@@ -118,7 +146,7 @@ func runLocalDiagnostics(req *pb.DiagnosticRequest) (*pb.DiagnosticResponse, err
 			configContext.Log.Printf("Sending response to chat from kernel: %s\n", results)
 			return &pb.DiagnosticResponse{
 				MessageId: *event.RoutingId,
-				Results:   results,
+				Results:   joinResults(results, namedResults),
 			}, nil
 		default:
 			configContext.Log.Printf("Received response from query: %s\n", *(*event).Query)
@@ -134,7 +162,7 @@ func runLocalDiagnostics(req *pb.DiagnosticRequest) (*pb.DiagnosticResponse, err
 				configContext.Log.Printf("Sending response to chat: %s\n", results)
 				return &pb.DiagnosticResponse{
 					MessageId: *event.RoutingId,
-					Results:   results,
+					Results:   joinResults(results, namedResults),
 				}, nil
 			}
 		}
@@ -149,6 +177,7 @@ func enqueueProxyRequest(ctx context.Context, req *pb.DiagnosticRequest) (*pb.Di
 	select {
 	case proxyRequestChan <- req:
 	case <-ctx.Done():
+		closeProxyRequest(req.GetMessageId(), proxyCancelled)
 		return nil, ctx.Err()
 	}
 
@@ -156,6 +185,7 @@ func enqueueProxyRequest(ctx context.Context, req *pb.DiagnosticRequest) (*pb.Di
 	case response := <-responseChan:
 		return response, nil
 	case <-ctx.Done():
+		closeProxyRequest(req.GetMessageId(), proxyCancelled)
 		return nil, ctx.Err()
 	}
 }
@@ -167,7 +197,10 @@ func dequeueProxyRequest(ctx context.Context, req *pb.DiagnosticRequest) (*pb.Di
 	if pendingRequests == 0 {
 		select {
 		case proxyRequest = <-proxyRequestChan:
-			if !requestSupportedByDeployments(proxyRequest.GetDiagnostics(), supportedDeployments) {
+			if proxyRequestCancelled(proxyRequest) {
+				return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: ""}, nil
+			}
+			if !requestSupportedByDeployments(proxyRequest, supportedDeployments) {
 				proxyRequestChan <- proxyRequest
 				return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: ""}, nil
 			}
@@ -177,7 +210,11 @@ func dequeueProxyRequest(ctx context.Context, req *pb.DiagnosticRequest) (*pb.Di
 	} else {
 		for i := 0; i < pendingRequests; i++ {
 			proxyRequest = <-proxyRequestChan
-			if requestSupportedByDeployments(proxyRequest.GetDiagnostics(), supportedDeployments) {
+			if proxyRequestCancelled(proxyRequest) {
+				proxyRequest = nil
+				continue
+			}
+			if requestSupportedByDeployments(proxyRequest, supportedDeployments) {
 				break
 			}
 			proxyRequestChan <- proxyRequest
@@ -196,23 +233,40 @@ func dequeueProxyRequest(ctx context.Context, req *pb.DiagnosticRequest) (*pb.Di
 }
 
 func postProxyResponse(req *pb.DiagnosticRequest) (*pb.DiagnosticResponse, bool) {
-	if len(req.GetData()) == 0 {
+	if len(req.GetData()) == 0 && len(req.GetEvents()) == 0 {
 		return nil, false
 	}
+	if streamValue, ok := proxyStreams.Load(req.GetMessageId()); ok {
+		return postProxyEvents(streamValue.(*proxyStream), req), true
+	}
+	if result, ok := closedProxyRequest(req.GetMessageId()); ok {
+		return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: result}, true
+	}
+	if len(req.GetData()) == 0 {
+		// Events for a stream nobody is listening to any more.
+		return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: proxyCancelled}, true
+	}
 	responseChanValue, ok := proxyResponseChans.Load(req.GetMessageId())
 	if !ok {
 		return nil, false
 	}
 	response := &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: req.GetData()[0]}
 	responseChanValue.(chan *pb.DiagnosticResponse) <- response
-	return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: "Response posted"}, true
+	closeProxyRequest(req.GetMessageId(), proxyPosted)
+	return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: proxyPosted}, true
 }
 
-func requestSupportedByDeployments(diagnostics []pb.Diagnostics, supportedDeployments map[string]struct{}) bool {
+func requestSupportedByDeployments(req *pb.DiagnosticRequest, supportedDeployments map[string]struct{}) bool {
 	if len(supportedDeployments) == 0 {
 		return true
 	}
+	diagnostics, named := common.SplitDiagnostics(req)
 	requiredDeployments := map[string]struct{}{}
+	for _, name := range named {
+		if info, ok := diagnosticsRegistry.Lookup(name); ok {
+			requiredDeployments[info.GetPlugin()] = struct{}{}
+		}
+	}
 	for _, diagnostic := range diagnostics {
 		switch diagnostic {
 		case pb.Diagnostics_ALL, pb.Diagnostics_HEALTH_CHECK:
@@ -232,6 +286,18 @@ func requestSupportedByDeployments(diagnostics []pb.Diagnostics, supportedDeploy
 	return true
 }
 
+// joinResults appends the results of diagnostics run by name to those of
+// the legacy diagnostics.
+func joinResults(results string, namedResults string) string {
+	if namedResults == "" {
+		return results
+	}
+	if results == "" {
+		return namedResults
+	}
+	return results + "\n" + namedResults
+}
+
 func GetConfigContext(pluginName string) *tccore.ConfigContext { return configContext }
 func GetConfigPaths(pluginName string) []string {
 	return common.GetConfigPaths(coreopts.IsTrcshTalkBackLocal())
@@ -243,6 +309,7 @@ func Init(pluginName string, properties *map[string]interface{}) {
 		return
 	}
 	configContext = ctx
+	diagnosticsRegistry = common.NewDiagnosticsRegistry(properties)
 	if coreopts.IsTrcshTalkBackLocal() {
 		_ = common.AttachMashupCert(configContext, properties)
 	}
@@ -289,7 +356,12 @@ func processTrcshTalkRequest(serverName string, port int, ttbToken *string, isRe
 		ttbToken,
 		isRemote,
 		proto.Message(diagReq),
-		func(m proto.Message, id string) { m.(*pb.DiagnosticRequest).MessageId = id },
+		func(m proto.Message, id string) {
+			// Replies and stream events keep the id of the request they answer.
+			if r := m.(*pb.DiagnosticRequest); r.MessageId == "" {
+				r.MessageId = id
+			}
+		},
 		newResp,
 		func(m proto.Message) string {
 			if r, ok := m.(*pb.DiagnosticResponse); ok {
@@ -345,7 +417,20 @@ func StartTrashTalking(remoteServerName string, port int, ttbToken *string, isRe
 			r := &pb.DiagnosticRequest{}
 			return r, protojson.Unmarshal([]byte(data), r)
 		},
-		func(r any) any { return TrcshTalkBack(r.(*pb.DiagnosticRequest)) },
+		func(r any) any {
+			req := r.(*pb.DiagnosticRequest)
+			if !req.GetStream() {
+				return TrcshTalkBack(req)
+			}
+			return trcshTalkBackStream(req, func(events ...*pb.DiagnosticEvent) bool {
+				resp, err := processTrcshTalkRequest(remoteServerName, port, ttbToken, isRemote, &pb.DiagnosticRequest{MessageId: req.GetMessageId(), Events: events}, func() proto.Message { return &pb.DiagnosticResponse{} })
+				if err != nil {
+					// Keep going, the result is still posted when done.
+					return true
+				}
+				return resp.(*pb.DiagnosticResponse).GetResults() != proxyCancelled
+			})
+		},
 		func(orig any, tb any) any {
 			return &pb.DiagnosticRequest{MessageId: orig.(*pb.DiagnosticRequest).MessageId, Data: []string{tb.(*pb.DiagnosticResponse).Results}}
 		},
@@ -354,7 +439,22 @@ func StartTrashTalking(remoteServerName string, port int, ttbToken *string, isRe
 
 // Keep TrcshTalkBack local since its logic is pb-specific and thus not part of reusable common code.
 func TrcshTalkBack(req *pb.DiagnosticRequest) *pb.DiagnosticResponse {
-	cmds := req.GetDiagnostics()
+	cmds, named := common.SplitDiagnostics(req)
+	namedResults := ""
+	if len(named) > 0 {
+		namedResults = diagnosticsRegistry.Run(configContext, named, req.GetParameters())
+		if len(cmds) == 0 {
+			return &pb.DiagnosticResponse{MessageId: req.GetMessageId(), Results: namedResults}
+		}
+	}
+	queries, queryTest := legacyQueries(req, cmds)
+	msgID, results := common.CollectQueryResponses(configContext, &req.MessageId, "trcshtalk", &queryTest, queries)
+	return &pb.DiagnosticResponse{MessageId: msgID, Results: joinResults(results, namedResults)}
+}
+
+// legacyQueries returns the chat queries and query id running the legacy
+// Diagnostics cmds of req.
+func legacyQueries(req *pb.DiagnosticRequest, cmds []pb.Diagnostics) ([]string, string) {
 	queries := []string{}
 	queryTest := req.GetQueryId() + ":"
 	if slices.Contains(cmds, pb.Diagnostics_ALL) {
@@ -386,8 +486,7 @@ func TrcshTalkBack(req *pb.DiagnosticRequest) *pb.DiagnosticResponse {
 			}
 		}
 	}
-	msgID, results := common.CollectQueryResponses(configContext, &req.MessageId, "trcshtalk", &queryTest, queries)
-	return &pb.DiagnosticResponse{MessageId: msgID, Results: results}
+	return queries, queryTest
 }
 
 // startOnce is a pointer so it can be reinitialized on stop to allow restart.
@@ -422,6 +521,8 @@ func stop(pluginName string) {
 	dfstat = nil
 	proxyRequestChan = make(chan *pb.DiagnosticRequest, 128)
 	proxyResponseChans = sync.Map{}
+	proxyStreams = sync.Map{}
+	closedProxyRequests = sync.Map{}
 	// Reset once so start can happen again if needed.
 	startOnce = &sync.Once{}
 }
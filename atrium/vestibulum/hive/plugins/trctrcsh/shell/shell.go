@@ -1790,7 +1790,7 @@ func (m *ShellModel) executeCommand(cmd string) ([]string, bool) {
 			output = append(output, "  su       - Obtain elevated access for write operations")
 		} else {
 			output = append(output, "  tinit    - Run trcinit commands (elevated mode only)")
-			output = append(output, "  tv       - List/read/patch secret store values (tv list|get|patch <path> ...)")
+			output = append(output, "  tv       - List/read/patch secret store values (tv list|get|patch|diff|history|rollback|delete <path> ...)")
 			// output = append(output, "  tpub     - Run trcpub commands (elevated mode only)")
 		}
 		output = append(output, "  exit     - Exit shell")
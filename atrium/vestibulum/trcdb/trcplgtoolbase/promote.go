@@ -0,0 +1,138 @@
+package trcplgtoolbase
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/trimble-oss/tierceron/pkg/core/util/repository"
+	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
+	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
+)
+
+// Certified plugins are promoted through environments in this order, one
+// environment at a time.
+var promotionPath = []string{"dev", "QA", "staging", "prod"}
+
+// promotedKeys are the certification fields that make up a plugin release.
+var promotedKeys = []string{"trcsha256", "trcplugin", "trcrelease", "trctype", "trcprojectservice", "trcbootstrap"}
+
+// checkPromotion ensures toEnv follows fromEnv on the promotion path.
+func checkPromotion(fromEnv string, toEnv string) error {
+	for i := 0; i+1 < len(promotionPath); i++ {
+		if promotionPath[i] != fromEnv {
+			continue
+		}
+		if promotionPath[i+1] != toEnv {
+			return fmt.Errorf("plugins certified in %s can only be promoted to %s", fromEnv, promotionPath[i+1])
+		}
+		return nil
+	}
+	return fmt.Errorf("unable to promote from %s, plugins are promoted %s", fromEnv, strings.Join(promotionPath, " -> "))
+}
+
+// promotePlugin copies the certification of a plugin in fromEnv to toEnv once
+// its sha is found in the registry.  The certification in toEnv records who
+// promoted it, from where and when.
+func promotePlugin(driverConfig *config.DriverConfig, mod *helperkv.Modifier, pluginToolConfig map[string]any, pluginName string, fromEnv string, toEnv string) error {
+	// Certification always operates on env basis.
+	fromEnv = eUtils.GetEnvBasis(fromEnv)
+	toEnv = eUtils.GetEnvBasis(toEnv)
+	if err := checkPromotion(fromEnv, toEnv); err != nil {
+		return err
+	}
+	certifyPath := fmt.Sprintf("super-secrets/Index/TrcVault/trcplugin/%s/Certify", strings.Split(pluginName, ":")[0])
+
+	env, envBasis := mod.Env, mod.EnvBasis
+	defer func() {
+		mod.Env, mod.EnvBasis = env, envBasis
+	}()
+
+	mod.Env, mod.EnvBasis = fromEnv, fromEnv
+	fromRecord, err := mod.ReadData(certifyPath)
+	if err != nil || len(fromRecord) == 0 {
+		return fmt.Errorf("plugin %s is not certified in %s", pluginName, fromEnv)
+	}
+	sha, _ := fromRecord["trcsha256"].(string)
+	plugin, _ := fromRecord["trcplugin"].(string)
+	if len(sha) == 0 || len(plugin) == 0 {
+		return fmt.Errorf("certification of plugin %s in %s is missing trcsha256 or trcplugin", pluginName, fromEnv)
+	}
+
+	driverConfig.CoreConfig.Log.Printf("Verifying image %s with sha %s for promotion to %s\n", plugin, sha, toEnv)
+	pluginToolConfig["trcplugin"] = plugin
+	pluginToolConfig["trcsha256"] = sha
+	delete(pluginToolConfig, "imagesha256")
+	if err := repository.GetImageAndShaFromDownload(driverConfig, pluginToolConfig); err != nil {
+		return fmt.Errorf("unable to verify image %s: %w", plugin, err)
+	}
+	if imageSha, _ := pluginToolConfig["imagesha256"].(string); imageSha != sha {
+		return fmt.Errorf("image %s with certified sha %s not found in registry", plugin, sha)
+	}
+
+	mod.Env, mod.EnvBasis = toEnv, toEnv
+	// ReadData returns no record and no error when toEnv has no certification
+	// yet.
+	toRecord, err := mod.ReadData(certifyPath)
+	if err != nil {
+		return fmt.Errorf("unable to read certification of plugin %s in %s: %w", pluginName, toEnv, err)
+	}
+	if toRecord["trcsha256"] == sha {
+		fmt.Fprintf(os.Stderr, "Plugin %s with sha %s already promoted to %s.\n", pluginName, sha, toEnv)
+		return nil
+	}
+
+	_, err = mod.Write(certifyPath, promotedRecord(fromRecord, toRecord, fromEnv, promotedBy(mod.Actor()), time.Now()), driverConfig.CoreConfig.Log)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Plugin %s with sha %s promoted from %s to %s.\n", pluginName, sha, fromEnv, toEnv)
+	return nil
+}
+
+// promotedRecord returns toRecord updated with the release certified in
+// fromRecord.  Region variants of the release and deployment fields, such as
+// trcsha256~<region> or deployed~<region>, describe the release being
+// replaced and are dropped.
+func promotedRecord(fromRecord map[string]any, toRecord map[string]any, fromEnv string, promotedBy string, promotedAt time.Time) map[string]any {
+	record := map[string]any{}
+	for key, value := range toRecord {
+		if baseKey, _, regional := strings.Cut(key, "~"); regional && (slices.Contains(promotedKeys, baseKey) || baseKey == "copied" || baseKey == "deployed") {
+			continue
+		}
+		record[key] = value
+	}
+	for _, key := range promotedKeys {
+		if value, ok := fromRecord[key]; ok {
+			record[key] = value
+		} else {
+			delete(record, key)
+		}
+	}
+	record["trcpromotedfrom"] = fromEnv
+	record["trcpromotedby"] = promotedBy
+	record["trcpromotedat"] = promotedAt.UTC().Format(time.RFC3339)
+	// The promoted release has yet to be deployed.
+	record["copied"] = false
+	record["deployed"] = false
+	return record
+}
+
+// promotedBy names the identity behind the token making a promotion.
+func promotedBy(actor *audit.Actor) string {
+	switch {
+	case actor == nil:
+		return "unknown"
+	case len(actor.DisplayName) > 0:
+		return actor.DisplayName
+	case len(actor.Role) > 0:
+		return actor.Role
+	case len(actor.Accessor) > 0:
+		return actor.Accessor
+	}
+	return "unknown"
+}
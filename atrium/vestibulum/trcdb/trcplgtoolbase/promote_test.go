@@ -0,0 +1,86 @@
+package trcplgtoolbase
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckPromotion(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		ok   bool
+	}{
+		{"dev", "QA", true},
+		{"QA", "staging", true},
+		{"staging", "prod", true},
+		{"dev", "staging", false},
+		{"dev", "prod", false},
+		{"QA", "dev", false},
+		{"prod", "dev", false},
+		{"dev", "dev", false},
+		{"RQA", "staging", false},
+		{"", "QA", false},
+	}
+	for _, test := range tests {
+		if err := checkPromotion(test.from, test.to); (err == nil) != test.ok {
+			t.Errorf("checkPromotion(%q, %q): expected ok %v, got %v", test.from, test.to, test.ok, err)
+		}
+	}
+}
+
+func TestPromotedRecord(t *testing.T) {
+	fromRecord := map[string]any{
+		"trcsha256":          "abc123",
+		"trcplugin":          "trcshtalk",
+		"trctype":            "trcshpluginservice",
+		"trcsha256~eastus":   "abc123",
+		"copied":             true,
+		"deployed":           true,
+		"trcdeployroot":      "/usr/local/trcshk",
+		"trcprojectservice":  "Hive/TrcshTalk",
+		"deployed~eastus":    true,
+		"trcpromotedfrom":    "dev",
+		"trcpromotedby":      "someone",
+		"trcpromotedat":      "2026-01-01T00:00:00Z",
+		"trcrelease~westus2": "1.0.0",
+	}
+	toRecord := map[string]any{
+		"trcsha256":          "def456",
+		"trcplugin":          "trcshtalk",
+		"trcrelease":         "0.9.0",
+		"trctype":            "trcshpluginservice",
+		"trcdeployroot":      "/usr/local/trcshk/qa",
+		"trcsha256~eastus":   "def456",
+		"copied~eastus":      true,
+		"deployed~westus2":   true,
+		"copied":             true,
+		"deployed":           true,
+		"instances":          "2",
+		"instances~westus2":  "3",
+		"trcbootstrap~other": "x",
+	}
+	promotedAt := time.Date(2026, 10, 18, 12, 30, 0, 0, time.FixedZone("MST", -7*60*60))
+	record := promotedRecord(fromRecord, toRecord, "dev", "token-deployer", promotedAt)
+	expected := map[string]any{
+		"trcsha256":         "abc123",
+		"trcplugin":         "trcshtalk",
+		"trctype":           "trcshpluginservice",
+		"trcprojectservice": "Hive/TrcshTalk",
+		"trcdeployroot":     "/usr/local/trcshk/qa",
+		"instances":         "2",
+		"instances~westus2": "3",
+		"trcpromotedfrom":   "dev",
+		"trcpromotedby":     "token-deployer",
+		"trcpromotedat":     "2026-10-18T19:30:00Z",
+		"copied":            false,
+		"deployed":          false,
+	}
+	if !reflect.DeepEqual(record, expected) {
+		t.Fatalf("promotedRecord returned %v, expected %v", record, expected)
+	}
+	if toRecord["trcsha256~eastus"] != "def456" || len(toRecord) != 13 {
+		t.Fatalf("Expected toRecord to be left as is, got %v", toRecord)
+	}
+}
@@ -37,100 +37,192 @@ import (
 	trcgitmgmtbase "github.com/trimble-oss/tierceron/atrium/vestibulum/trcdb/trcgitmgmtbase"
 )
 
-func CommonMain(envPtr *string,
-	envCtxPtr *string,
-	tokenNamePtr *string,
-	regionPtr *string,
-	flagset *flag.FlagSet,
-	argLines []string,
-	trcshDriverConfig *capauth.TrcshDriverConfig,
-	mainPluginHandler ...*hive.PluginHandler,
-) error {
-	var flagEnvPtr *string
-	var tokenPtr *string
-	var addrPtr *string
-	var logFilePtr *string
+// plgtoolFlags are the flags CommonMain defines.
+type plgtoolFlags struct {
+	env                *string
+	token              *string
+	addr               *string
+	logFile            *string
+	defineService      *bool
+	certifyImage       *bool
+	certifyInfoImage   *bool
+	pluginservicestart *bool
+	pluginservicestop  *bool
+	winservicestop     *bool
+	winservicestart    *bool
+	codebundledeploy   *bool
+	agentdeploy        *bool
+	projectservice     *string
+	buildImage         *string
+	pushImage          *bool
+	outputDestination  *string
+	pushAlias          *string
+	startDir           *string
+	insecure           *bool
+	deployroot         *string
+	deploysubpath      *string
+	serviceName        *string
+	pathParam          *string
+	codeBundle         *string
+	expandTarget       *bool
+	trcbootstrap       *string
+	instances          *string
+	restartPolicy      *string
+	restartMax         *string
+	restartWindow      *string
+	restartBackoff     *string
+	restartBackoffMax  *string
+	pluginName         *string
+	pluginNameAlias    *string
+	pluginType         *string
+	sha256             *string
+	checkDeployed      *bool
+	checkCopied        *bool
+	promote            *bool
+	from               *string
+	to                 *string
+	newrelicAppName    *string
+	newrelicLicenseKey *string
+	updateAPIM         *bool
+	exclude            *string
+	certPath           *string
+}
 
-	// Main functions are as follows:
-	if flagset == nil {
-		if trcshDriverConfig != nil && trcshDriverConfig.DriverConfig != nil {
-			eUtils.LogInfo(trcshDriverConfig.DriverConfig.CoreConfig, "Version: "+"1.06")
-		} else {
-			fmt.Fprintln(os.Stderr, "Version: 1.06")
-		}
-		flagset = flag.NewFlagSet(argLines[0], flag.ContinueOnError)
+// defineFlags defines CommonMain's flags on flagset.  standalone is true if
+// CommonMain created flagset, a caller's flagset already has the rest of the
+// connection flags.
+func defineFlags(flagset *flag.FlagSet, standalone bool) *plgtoolFlags {
+	flags := &plgtoolFlags{}
+	if standalone {
 		// set and ignore..
-		flagEnvPtr = flagset.String("env", "dev", "Environment to configure")
+		flags.env = flagset.String("env", "dev", "Environment to configure")
 		flagset.String("addr", "", "API endpoint for the vault")
 		flagset.String("token", "", "Vault access token")
 		flagset.String("region", "", "Region to be processed") // If this is blank -> use context otherwise override context.
 		flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"plgtool.log", "Output path for log files")
-		flagset.Usage = func() {
-			fmt.Fprintf(flagset.Output(), "Usage of %s:\n", argLines[0])
-			flagset.PrintDefaults()
-		}
 	} else {
-		tokenPtr = flagset.String("token", "", "Vault access token")
-		addrPtr = flagset.String("addr", "", "API endpoint for the vault")
+		flags.token = flagset.String("token", "", "Vault access token")
+		flags.addr = flagset.String("addr", "", "API endpoint for the vault")
 		if flagset.Lookup("log") == nil {
-			logFilePtr = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"config.log", "Output path for log file")
+			flags.logFile = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"config.log", "Output path for log file")
 		}
 	}
-	defineServicePtr := flagset.Bool("defineService", false, "Specified when defining a service.")
-	certifyImagePtr := flagset.Bool("certify", false, "Used to certifies vault plugin.")
-	certifyInfoImagePtr := flagset.Bool("certifyInfo", false, "Used to certifies vault plugin.")
+	flags.defineService = flagset.Bool("defineService", false, "Specified when defining a service.")
+	flags.certifyImage = flagset.Bool("certify", false, "Used to certifies vault plugin.")
+	flags.certifyInfoImage = flagset.Bool("certifyInfo", false, "Used to certifies vault plugin.")
 	// These functions only valid for pluginType trcshservice
-	pluginservicestartPtr := flagset.Bool("pluginservicestart", false, "To start a trcshell kernel service for a particular plugin.")
-	pluginservicestopPtr := flagset.Bool("pluginservicestop", false, "To stop a trcshell kernel service for a particular plugin.")
-	winservicestopPtr := flagset.Bool("winservicestop", false, "To stop a windows service for a particular plugin.")
-	winservicestartPtr := flagset.Bool("winservicestart", false, "To start a windows service for a particular plugin.")
-	codebundledeployPtr := flagset.Bool("codebundledeploy", false, "To deploy a code bundle.")
-	agentdeployPtr := flagset.Bool("agentdeploy", false, "To initiate deployment on agent.")
-	projectservicePtr := flagset.String("projectservice", "", "Provide template path in form project/service")
-	buildImagePtr := flagset.String("buildImage", "", "Path to Dockerfile to build")
-	pushImagePtr := flagset.Bool("pushImage", false, "Push an image to the registry.")
-	outputDestinationPtr := flagset.String("o", "", "Command output destination")
-	pushAliasPtr := flagset.String("pushAlias", "", "Image name:tag to push to registry, separated by commas (eg: egg:plant,egg:salad,egg:bar).")
+	flags.pluginservicestart = flagset.Bool("pluginservicestart", false, "To start a trcshell kernel service for a particular plugin.")
+	flags.pluginservicestop = flagset.Bool("pluginservicestop", false, "To stop a trcshell kernel service for a particular plugin.")
+	flags.winservicestop = flagset.Bool("winservicestop", false, "To stop a windows service for a particular plugin.")
+	flags.winservicestart = flagset.Bool("winservicestart", false, "To start a windows service for a particular plugin.")
+	flags.codebundledeploy = flagset.Bool("codebundledeploy", false, "To deploy a code bundle.")
+	flags.agentdeploy = flagset.Bool("agentdeploy", false, "To initiate deployment on agent.")
+	flags.projectservice = flagset.String("projectservice", "", "Provide template path in form project/service")
+	flags.buildImage = flagset.String("buildImage", "", "Path to Dockerfile to build")
+	flags.pushImage = flagset.Bool("pushImage", false, "Push an image to the registry.")
+	flags.outputDestination = flagset.String("o", "", "Command output destination")
+	flags.pushAlias = flagset.String("pushAlias", "", "Image name:tag to push to registry, separated by commas (eg: egg:plant,egg:salad,egg:bar).")
 
 	// Common flags...
-	startDirPtr := flagset.String("startDir", coreopts.BuildOptions.GetFolderPrefix(nil)+"_templates", "Template directory")
-	insecurePtr := flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
+	flags.startDir = flagset.String("startDir", coreopts.BuildOptions.GetFolderPrefix(nil)+"_templates", "Template directory")
+	flags.insecure = flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
 
 	// defineService flags...
-	deployrootPtr := flagset.String("deployroot", "", "Optional path for deploying services to.")
-	deploysubpathPtr := flagset.String("deploysubpath", "", "Subpath under root to deliver code bundles.")
-	serviceNamePtr := flagset.String("serviceName", "", "Optional name of service to use in managing service.")
-	pathParamPtr := flagset.String("pathParam", "", "Optional path placeholder replacement to use in managing service.")
-	codeBundlePtr := flagset.String("codeBundle", "", "Code bundle to deploy.")
-	expandTargetPtr := flagset.Bool("expandTarget", false, "Used to unzip files at deploy path")
-	trcbootstrapPtr := flagset.String("trcbootstrap", "/deploy/deploy.trc", "Used to unzip files at deploy path")
-	instancesPtr := flagset.String("instances", "", "Used to specify pod instances for deployment")
+	flags.deployroot = flagset.String("deployroot", "", "Optional path for deploying services to.")
+	flags.deploysubpath = flagset.String("deploysubpath", "", "Subpath under root to deliver code bundles.")
+	flags.serviceName = flagset.String("serviceName", "", "Optional name of service to use in managing service.")
+	flags.pathParam = flagset.String("pathParam", "", "Optional path placeholder replacement to use in managing service.")
+	flags.codeBundle = flagset.String("codeBundle", "", "Code bundle to deploy.")
+	flags.expandTarget = flagset.Bool("expandTarget", false, "Used to unzip files at deploy path")
+	flags.trcbootstrap = flagset.String("trcbootstrap", "/deploy/deploy.trc", "Used to unzip files at deploy path")
+	flags.instances = flagset.String("instances", "", "Used to specify pod instances for deployment")
+	flags.restartPolicy = flagset.String("restartPolicy", "", "Kernel restart policy for a crashing plugin: never, on-failure or always.")
+	flags.restartMax = flagset.String("restartMax", "", "Restarts allowed within the restart window before the kernel gives up.")
+	flags.restartWindow = flagset.String("restartWindow", "", "Window restarts are counted in, e.g. 10m.")
+	flags.restartBackoff = flagset.String("restartBackoff", "", "Initial delay between restarts, doubled on each restart, e.g. 1s.")
+	flags.restartBackoffMax = flagset.String("restartBackoffMax", "", "Upper bound on the delay between restarts, e.g. 5m.")
 
 	// Common plugin flags...
-	pluginNamePtr := flagset.String("pluginName", "", "Used to certify vault plugin")
-	pluginNameAliasPtr := flagset.String("pluginNameAlias", "", "Name used to define an alias for a plugin")
-	pluginTypePtr := flagset.String("pluginType", "vault", "Used to indicate type of plugin.  Default is vault.")
+	flags.pluginName = flagset.String("pluginName", "", "Used to certify vault plugin")
+	flags.pluginNameAlias = flagset.String("pluginNameAlias", "", "Name used to define an alias for a plugin")
+	flags.pluginType = flagset.String("pluginType", "vault", "Used to indicate type of plugin.  Default is vault.")
 
 	// Certify flags...
-	sha256Ptr := flagset.String("sha256", "", "Used to certify vault plugin") // This has to match the image that is pulled -> then we write the vault.
-	checkDeployedPtr := flagset.Bool("checkDeployed", false, "Used to check if plugin has been copied, deployed, & certified")
-	checkCopiedPtr := flagset.Bool("checkCopied", false, "Used to check if plugin has been copied & certified")
+	flags.sha256 = flagset.String("sha256", "", "Used to certify vault plugin") // This has to match the image that is pulled -> then we write the vault.
+	flags.checkDeployed = flagset.Bool("checkDeployed", false, "Used to check if plugin has been copied, deployed, & certified")
+	flags.checkCopied = flagset.Bool("checkCopied", false, "Used to check if plugin has been copied & certified")
 
-	// NewRelic flags...
-	newrelicAppNamePtr := flagset.String("newRelicAppName", "", "App name for New Relic")
-	newrelicLicenseKeyPtr := flagset.String("newRelicLicenseKey", "", "License key for New Relic")
+	// Promote flags...
+	flags.promote = flagset.Bool("promote", false, "Used to promote a certified plugin to the next environment: dev, QA, staging, prod.")
+	flags.from = flagset.String("from", "", "Environment to promote a certified plugin from.")
+	flags.to = flagset.String("to", "", "Environment to promote a certified plugin to.")
 
-	certifyInit := false
+	// NewRelic flags...
+	flags.newrelicAppName = flagset.String("newRelicAppName", "", "App name for New Relic")
+	flags.newrelicLicenseKey = flagset.String("newRelicLicenseKey", "", "License key for New Relic")
 
 	// APIM flags
-	updateAPIMPtr := flagset.Bool("updateAPIM", false, "Used to update Azure APIM")
+	flags.updateAPIM = flagset.Bool("updateAPIM", false, "Used to update Azure APIM")
 
 	// Repository commands
 	// We need to keep the getCmd flag for compatibility with existing checks in the code
-	excludePtr := flagset.String("exclude", "trc_templates", "Comma-delimited list of directories to exclude from download")
+	flags.exclude = flagset.String("exclude", "trc_templates", "Comma-delimited list of directories to exclude from download")
 
 	// Cert flags
-	certPathPtr := flagset.String("certPath", "", "Path to certificate to push to Azure")
+	flags.certPath = flagset.String("certPath", "", "Path to certificate to push to Azure")
+	return flags
+}
+
+// Flags returns the flags CommonMain defines, standalone or on a caller's
+// flagset, e.g. to check trcsh scripts.
+func Flags(standalone bool) *flag.FlagSet {
+	flagset := flag.NewFlagSet("trcplgtool", flag.ContinueOnError)
+	defineFlags(flagset, standalone)
+	return flagset
+}
+
+func CommonMain(envPtr *string,
+	envCtxPtr *string,
+	tokenNamePtr *string,
+	regionPtr *string,
+	flagset *flag.FlagSet,
+	argLines []string,
+	trcshDriverConfig *capauth.TrcshDriverConfig,
+	mainPluginHandler ...*hive.PluginHandler,
+) error {
+	// Main functions are as follows:
+	standalone := flagset == nil
+	if flagset == nil {
+		if trcshDriverConfig != nil && trcshDriverConfig.DriverConfig != nil {
+			eUtils.LogInfo(trcshDriverConfig.DriverConfig.CoreConfig, "Version: "+"1.06")
+		} else {
+			fmt.Fprintln(os.Stderr, "Version: 1.06")
+		}
+		flagset = flag.NewFlagSet(argLines[0], flag.ContinueOnError)
+		flagset.Usage = func() {
+			fmt.Fprintf(flagset.Output(), "Usage of %s:\n", argLines[0])
+			flagset.PrintDefaults()
+		}
+	}
+	flags := defineFlags(flagset, standalone)
+	flagEnvPtr, tokenPtr, addrPtr, logFilePtr := flags.env, flags.token, flags.addr, flags.logFile
+	defineServicePtr, certifyImagePtr, certifyInfoImagePtr := flags.defineService, flags.certifyImage, flags.certifyInfoImage
+	pluginservicestartPtr, pluginservicestopPtr, winservicestopPtr := flags.pluginservicestart, flags.pluginservicestop, flags.winservicestop
+	winservicestartPtr, codebundledeployPtr, agentdeployPtr := flags.winservicestart, flags.codebundledeploy, flags.agentdeploy
+	projectservicePtr, buildImagePtr, pushImagePtr := flags.projectservice, flags.buildImage, flags.pushImage
+	outputDestinationPtr, pushAliasPtr, startDirPtr := flags.outputDestination, flags.pushAlias, flags.startDir
+	insecurePtr, deployrootPtr, deploysubpathPtr := flags.insecure, flags.deployroot, flags.deploysubpath
+	serviceNamePtr, pathParamPtr, codeBundlePtr := flags.serviceName, flags.pathParam, flags.codeBundle
+	expandTargetPtr, trcbootstrapPtr, instancesPtr := flags.expandTarget, flags.trcbootstrap, flags.instances
+	restartPolicyPtr, restartMaxPtr, restartWindowPtr := flags.restartPolicy, flags.restartMax, flags.restartWindow
+	restartBackoffPtr, restartBackoffMaxPtr, pluginNamePtr := flags.restartBackoff, flags.restartBackoffMax, flags.pluginName
+	pluginNameAliasPtr, pluginTypePtr, sha256Ptr := flags.pluginNameAlias, flags.pluginType, flags.sha256
+	checkDeployedPtr, checkCopiedPtr, promotePtr := flags.checkDeployed, flags.checkCopied, flags.promote
+	fromPtr, toPtr, newrelicAppNamePtr := flags.from, flags.to, flags.newrelicAppName
+	newrelicLicenseKeyPtr, updateAPIMPtr, excludePtr := flags.newrelicLicenseKey, flags.updateAPIM, flags.exclude
+	certPathPtr := flags.certPath
+
+	certifyInit := false
 	isGetCommand := false
 	repoName := ""
 	isRunnableKernelPlugin := false
@@ -263,6 +355,15 @@ func CommonMain(envPtr *string,
 		return errors.New("must use -pluginName flag to use -certifyInfo flag")
 	}
 
+	if *promotePtr && (len(*pluginNamePtr) == 0 || len(*fromPtr) == 0 || len(*toPtr) == 0) {
+		fmt.Fprintln(os.Stderr, "Must use -pluginName, -from && -to flags to use -promote flag")
+		return errors.New("must use -pluginName, -from && -to flags to use -promote flag")
+	}
+	if *promotePtr && eUtils.GetEnvBasis(*toPtr) != eUtils.GetEnvBasis(*envPtr) {
+		fmt.Fprintf(os.Stderr, "-to must be the env basis of -env %s to use -promote flag\n", *envPtr)
+		return fmt.Errorf("-to must be the env basis of -env %s to use -promote flag", *envPtr)
+	}
+
 	if *checkDeployedPtr && (len(*pluginNamePtr) == 0) {
 		fmt.Fprintln(os.Stderr, "Must use -pluginName flag to use -checkDeployed flag")
 		return errors.New("must use -pluginName flag to use -checkDeployed flag")
@@ -323,7 +424,7 @@ func CommonMain(envPtr *string,
 		*pluginTypePtr = "trcshpluginservice"
 	}
 
-	if !*updateAPIMPtr && len(*buildImagePtr) == 0 && !*pushImagePtr && !isGetCommand {
+	if !*updateAPIMPtr && len(*buildImagePtr) == 0 && !*pushImagePtr && !*promotePtr && !isGetCommand {
 		switch *pluginTypePtr {
 		case "vault": // A vault plugin
 			if trcshDriverConfig.DriverConfig.CoreConfig.IsShell {
@@ -555,6 +656,16 @@ func CommonMain(envPtr *string,
 		}
 	}
 
+	if *promotePtr {
+		trcshDriverConfigBase.DriverConfig.CoreConfig.Log.Printf("Promote begin activities\n")
+		err := promotePlugin(trcshDriverConfigBase.DriverConfig, mod, pluginToolConfig, *pluginNamePtr, *fromPtr, *toPtr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		return nil
+	}
+
 	if *certifyImagePtr {
 		trcshDriverConfigBase.DriverConfig.CoreConfig.Log.Printf("Certify begin activities\n")
 	}
@@ -601,6 +712,11 @@ func CommonMain(envPtr *string,
 	pluginToolConfig["pluginNamePtr"] = *pluginNamePtr
 	pluginToolConfig["serviceNamePtr"] = *serviceNamePtr
 	pluginToolConfig["instancesPtr"] = *instancesPtr
+	pluginToolConfig["restartPolicyPtr"] = *restartPolicyPtr
+	pluginToolConfig["restartMaxPtr"] = *restartMaxPtr
+	pluginToolConfig["restartWindowPtr"] = *restartWindowPtr
+	pluginToolConfig["restartBackoffPtr"] = *restartBackoffPtr
+	pluginToolConfig["restartBackoffMaxPtr"] = *restartBackoffMaxPtr
 	pluginToolConfig["projectservicePtr"] = *projectservicePtr
 	pluginToolConfig["deployrootPtr"] = *deployrootPtr
 	pluginToolConfig["deploysubpathPtr"] = *deploysubpathPtr
@@ -787,6 +903,25 @@ func CommonMain(envPtr *string,
 		if instances, ok := pluginToolConfig["instancesPtr"].(string); ok && instances != "" {
 			writeMap["instances"] = instances
 		}
+		if restartPolicy, ok := pluginToolConfig["restartPolicyPtr"].(string); ok && restartPolicy != "" {
+			writeMap["trcrestartpolicy"] = restartPolicy
+		}
+		if restartMax, ok := pluginToolConfig["restartMaxPtr"].(string); ok && restartMax != "" {
+			writeMap["trcrestartmax"] = restartMax
+		}
+		if restartWindow, ok := pluginToolConfig["restartWindowPtr"].(string); ok && restartWindow != "" {
+			writeMap["trcrestartwindow"] = restartWindow
+		}
+		if restartBackoff, ok := pluginToolConfig["restartBackoffPtr"].(string); ok && restartBackoff != "" {
+			writeMap["trcrestartbackoff"] = restartBackoff
+		}
+		if restartBackoffMax, ok := pluginToolConfig["restartBackoffMaxPtr"].(string); ok && restartBackoffMax != "" {
+			writeMap["trcrestartbackoffmax"] = restartBackoffMax
+		}
+		if err := hive.CheckRestartPolicy(writeMap); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
 
 		_, err = mod.Write(pluginToolConfig["pluginpath"].(string), writeMap, trcshDriverConfigBase.DriverConfig.CoreConfig.Log)
 		if err != nil {
@@ -1015,6 +1150,7 @@ func CommonMain(envPtr *string,
 							}
 						}
 						pluginHandler.Signature = sha
+						pluginHandler.PluginPath = pathToSO
 					}
 				} else {
 					fmt.Fprintf(os.Stderr, "Handler not initialized for plugin to start: %s\n", *pluginNamePtr)
@@ -207,6 +207,9 @@ func dataFlowStatPullRemote(tfmContextI flowcore.FlowMachineContext, tfContextI
 func CreateTableTriggers(tfmContextI flowcore.FlowMachineContext, tfContextI flowcore.FlowContext) {
 	tfmContext := tfmContextI.(*core.TrcFlowMachineContext)
 	tfContext := tfContextI.(*core.TrcFlowContext)
+	if tfmContext.IsChannelCdc() {
+		return
+	}
 	tfmContext.GetTableModifierLock().Lock()
 	changeTableName := tfContext.FlowHeader.TableName() + "_Changes"
 	tfmContext.CallDBQuery(tfContext, map[string]any{"TrcQuery": "DROP TABLE " + tfmContext.TierceronEngine.Database.Name() + "." + changeTableName}, nil, false, "DELETE", nil, "")
@@ -0,0 +1,114 @@
+package native
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/trimble-oss/tierceron/atrium/vestibulum/trcsh/kube/native/trcoverlay"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+// Kubernetes manifest templates of a project/service live under
+// trc_templates/<Project>/<Service>/kube/base, with per environment overlays
+// under kube/overlays/<overlay>.
+const (
+	manifestBaseDir    = "kube/base"
+	manifestOverlayDir = "kube/overlays"
+)
+
+// IsTemplateApply reports whether a kubectl action is the apply templates
+// directive:
+//
+//	kubectl apply --templates=<Project>/<Service> [--overlay=<env>] [--dry-run]
+//
+// which applies manifests rendered from vault backed templates.
+func IsTemplateApply(action string, deployArgs []string) bool {
+	if action != "apply" {
+		return false
+	}
+	for _, deployArg := range deployArgs {
+		if strings.HasPrefix(deployArg, "--templates=") {
+			return true
+		}
+	}
+	return false
+}
+
+// ManifestRenderDir is the MemFs directory the templates of projectService
+// are rendered into for KubeApplyTemplates.
+func ManifestRenderDir(projectService string) string {
+	// trcconfig trims Project/Service from rendered paths, so it must not
+	// appear in the directory itself.
+	return "kube_rendered/" + strings.ReplaceAll(projectService, "/", "_")
+}
+
+// KubeApplyTemplates applies the manifests rendered for the apply templates
+// directive, with the manifests of its overlay merged into the base
+// manifests.  The merged manifests are written to MemFs only, and not
+// applied with --dry-run.
+func KubeApplyTemplates(trcKubeDeploymentConfig *TrcKubeConfig, driverConfig *config.DriverConfig) error {
+	directive := trcKubeDeploymentConfig.KubeDirective
+	if directive == nil || len(directive.Templates) == 0 {
+		return fmt.Errorf("expected kubectl apply --templates=<Project>/<Service>")
+	}
+	renderDir := ManifestRenderDir(directive.Templates)
+
+	base, err := readManifests(driverConfig, fmt.Sprintf("%s/%s", renderDir, manifestBaseDir))
+	if err != nil || len(base) == 0 {
+		return fmt.Errorf("no manifests rendered from trc_templates/%s/%s", directive.Templates, manifestBaseDir)
+	}
+	var overlays [][]byte
+	if len(directive.Overlay) > 0 {
+		overlayDir := fmt.Sprintf("%s/%s/%s", renderDir, manifestOverlayDir, directive.Overlay)
+		if overlays, err = readManifests(driverConfig, overlayDir); err != nil {
+			driverConfig.CoreConfig.Log.Printf("No %s overlay for %s\n", directive.Overlay, directive.Templates)
+		}
+	}
+	manifests, err := trcoverlay.Merge(base, overlays)
+	if err != nil {
+		return err
+	}
+
+	manifestPath := fmt.Sprintf("%s/manifests.yaml", renderDir)
+	driverConfig.MemFs.WriteToMemFile(driverConfig.CoreConfig, &manifests, manifestPath)
+	if directive.DryRun {
+		driverConfig.CoreConfig.Log.Printf("Dry run, manifests for %s not applied: %s\n", directive.Templates, manifestPath)
+		return nil
+	}
+	directive.FromFilePath = manifestPath
+	return KubeApply(trcKubeDeploymentConfig, driverConfig)
+}
+
+// readManifests reads the yaml and json files in a MemFs directory in name
+// order.
+func readManifests(driverConfig *config.DriverConfig, dir string) ([][]byte, error) {
+	files, err := driverConfig.MemFs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, file := range files {
+		name := file.Name()
+		if !file.IsDir() && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".json")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	manifests := [][]byte{}
+	for _, name := range names {
+		memFile, err := driverConfig.MemFs.Open(fmt.Sprintf("%s/%s", dir, name))
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := io.ReadAll(memFile)
+		memFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
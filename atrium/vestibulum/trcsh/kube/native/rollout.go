@@ -0,0 +1,117 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/trimble-oss/tierceron/atrium/vestibulum/trcsh/kube/native/trcrollout"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// IsRolloutVerify reports whether a kubectl action is the rollout verify
+// directive:
+//
+//	kubectl rollout verify deployment/<name> [-n <namespace>] [--timeout=5m] [--rollback]
+//
+// which kubectl itself doesn't have.
+func IsRolloutVerify(action string, deployArgs []string) bool {
+	return action == "rollout" && len(deployArgs) > 0 && deployArgs[0] == "verify"
+}
+
+func parseRolloutDirective(trcKubeDirective *TrcKubeDirective, deployArgs []string) *TrcKubeDirective {
+	trcKubeDirective.Type = "verify"
+	trcKubeDirective.Timeout = trcrollout.DefaultTimeout
+	for i := 0; i < len(deployArgs); i++ {
+		flag, value, hasValue := strings.Cut(deployArgs[i], "=")
+		if !hasValue && (flag == "-n" || flag == "--namespace" || flag == "--timeout") && i+1 < len(deployArgs) {
+			i++
+			value = deployArgs[i]
+		}
+		switch flag {
+		case "-n", "--namespace":
+			trcKubeDirective.Namespace = value
+		case "--timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				timeout = -1 // Rejected by KubeRollout.
+			}
+			trcKubeDirective.Timeout = timeout
+		case "--rollback":
+			trcKubeDirective.Rollback = !hasValue || value == "true"
+		default:
+			if kind, name, ok := strings.Cut(deployArgs[i], "/"); ok {
+				trcKubeDirective.Object = rolloutKind(kind)
+				trcKubeDirective.Name = name
+			} else if trcKubeDirective.Object == "" {
+				trcKubeDirective.Object = rolloutKind(deployArgs[i])
+			} else if trcKubeDirective.Name == "" {
+				trcKubeDirective.Name = deployArgs[i]
+			}
+		}
+	}
+	return trcKubeDirective
+}
+
+func rolloutKind(kind string) string {
+	switch strings.ToLower(kind) {
+	case "deployment", "deployments", "deploy", "deployment.apps":
+		return trcrollout.Deployment
+	case "statefulset", "statefulsets", "sts", "statefulset.apps":
+		return trcrollout.StatefulSet
+	}
+	return kind
+}
+
+// KubeRollout waits for the rollout named by the rollout verify directive.
+// Events and logs of failing pods are written to MemFs under
+// rollout/<kind>/<name> and, with --rollback, the workload is rolled back to
+// its previous revision.
+func KubeRollout(trcKubeDeploymentConfig *TrcKubeConfig, driverConfig *config.DriverConfig) error {
+	directive := trcKubeDeploymentConfig.KubeDirective
+	if directive == nil || directive.Type != "verify" || len(directive.Name) == 0 {
+		return fmt.Errorf("expected kubectl rollout verify <kind>/<name>")
+	}
+	if directive.Timeout < 0 {
+		return fmt.Errorf("invalid rollout verify timeout")
+	}
+
+	apiConfig, err := loadInMemoryKubeConfig(trcKubeDeploymentConfig, clientcmd.RecommendedHomeFile)
+	if err != nil {
+		return err
+	}
+	overrides := &clientcmd.ConfigOverrides{}
+	if trcKubeDeploymentConfig.KubeContext != nil {
+		overrides.CurrentContext = trcKubeDeploymentConfig.KubeContext.Context
+		overrides.Context.Namespace = trcKubeDeploymentConfig.KubeContext.Namespace
+	}
+	clientConfig := clientcmd.NewDefaultClientConfig(*apiConfig, overrides)
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	namespace := directive.Namespace
+	if len(namespace) == 0 {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return err
+		}
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	return trcrollout.Verify(context.Background(), clientset, &trcrollout.Options{
+		Kind:      directive.Object,
+		Name:      directive.Name,
+		Namespace: namespace,
+		Timeout:   directive.Timeout,
+		Rollback:  directive.Rollback,
+		ReportDir: fmt.Sprintf("rollout/%s/%s", directive.Object, directive.Name),
+	}, func(path string, data []byte) {
+		driverConfig.MemFs.WriteToMemFile(driverConfig.CoreConfig, &data, path)
+	}, driverConfig.CoreConfig.Log)
+}
@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/trimble-oss/tierceron/pkg/utils/config"
 	corev1 "k8s.io/api/core/v1"
@@ -51,6 +52,15 @@ type TrcKubeDirective struct {
 	Name         string
 	FromFilePath string
 	DryRun       bool
+
+	// rollout verify...
+	Namespace string
+	Timeout   time.Duration
+	Rollback  bool
+
+	// apply --templates...
+	Templates string // Project/Service
+	Overlay   string
 }
 
 type TrcKubeConfig struct {
@@ -244,10 +254,19 @@ func ParseTrcKubeDeployDirective(trcKubeDirective *TrcKubeDirective, deployArgs
 		trcKubeDirective.Object = ""
 		trcKubeDirective.Type = ""
 		trcKubeDirective.DryRun = false
+		trcKubeDirective.Namespace = ""
+		trcKubeDirective.Timeout = 0
+		trcKubeDirective.Rollback = false
+		trcKubeDirective.Templates = ""
+		trcKubeDirective.Overlay = ""
 	}
 	trcKubeDirective.Action = deployArgs[0]
 	deployArgs = deployArgs[1:]
 
+	if IsRolloutVerify(trcKubeDirective.Action, deployArgs) {
+		return parseRolloutDirective(trcKubeDirective, deployArgs[1:])
+	}
+
 	for i := range deployArgs {
 		if trcKubeDirective.Action == "create" && (deployArgs[i] == "secret" || deployArgs[i] == "configmap") {
 			trcKubeDirective.Object = deployArgs[i]
@@ -268,6 +287,14 @@ func ParseTrcKubeDeployDirective(trcKubeDirective *TrcKubeDirective, deployArgs
 				}
 			case "--dry-run":
 				trcKubeDirective.DryRun = true
+			case "--templates":
+				if len(argsSlice) > 1 {
+					trcKubeDirective.Templates = argsSlice[1]
+				}
+			case "--overlay":
+				if len(argsSlice) > 1 {
+					trcKubeDirective.Overlay = argsSlice[1]
+				}
 			case "-f": // From apply...
 				if len(deployArgs) > i {
 					trcKubeDirective.FromFilePath = deployArgs[i+1]
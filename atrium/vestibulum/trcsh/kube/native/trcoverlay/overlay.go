@@ -0,0 +1,179 @@
+// Package trcoverlay applies kustomize style overlays to Kubernetes manifests.
+package trcoverlay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// resource is a manifest document.
+type resource struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+	object     map[string]any
+}
+
+func (r *resource) String() string {
+	return fmt.Sprintf("%s %s/%s", r.apiVersion, r.kind, r.name)
+}
+
+// matches reports whether overlay r patches resource base.  An overlay
+// without a namespace matches base in any namespace.
+func (r *resource) matches(base *resource) bool {
+	return r.apiVersion == base.apiVersion && r.kind == base.kind && r.name == base.name &&
+		(len(r.namespace) == 0 || r.namespace == base.namespace)
+}
+
+// Merge applies overlays to the base manifests and returns the resulting
+// multi document yaml.  Each overlay document is a patch for the base
+// resource with the same apiVersion, kind and name:  a strategic merge patch
+// for kinds client-go knows and a JSON merge patch for others.  Overlay
+// documents with "$patch: delete" remove their base resource and those
+// matching no base resource are added.
+func Merge(base [][]byte, overlays [][]byte) ([]byte, error) {
+	resources, err := decode(base)
+	if err != nil {
+		return nil, err
+	}
+	patches, err := decode(overlays)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, patch := range patches {
+		match := -1
+		for i, resource := range resources {
+			if patch.matches(resource) {
+				match = i
+				break
+			}
+		}
+		switch {
+		case patch.object["$patch"] == "delete":
+			if match < 0 {
+				return nil, fmt.Errorf("overlay deletes missing resource %s", patch)
+			}
+			resources = append(resources[:match], resources[match+1:]...)
+		case match < 0:
+			resources = append(resources, patch)
+		default:
+			merged, err := mergeResource(resources[match], patch)
+			if err != nil {
+				return nil, fmt.Errorf("overlay of %s: %w", patch, err)
+			}
+			resources[match] = merged
+		}
+	}
+
+	manifests := &bytes.Buffer{}
+	for i, resource := range resources {
+		document, err := yaml.Marshal(resource.object)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			manifests.WriteString("---\n")
+		}
+		manifests.Write(document)
+	}
+	return manifests.Bytes(), nil
+}
+
+// decode splits manifests into their resources, skipping empty documents.
+func decode(manifests [][]byte) ([]*resource, error) {
+	resources := []*resource{}
+	for _, manifest := range manifests {
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+		for {
+			document, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			object := map[string]any{}
+			if err := yaml.Unmarshal(document, &object); err != nil {
+				return nil, err
+			}
+			if len(object) == 0 {
+				continue
+			}
+			resource := &resource{object: object}
+			resource.apiVersion, _ = object["apiVersion"].(string)
+			resource.kind, _ = object["kind"].(string)
+			if metadata, ok := object["metadata"].(map[string]any); ok {
+				resource.namespace, _ = metadata["namespace"].(string)
+				resource.name, _ = metadata["name"].(string)
+			}
+			if len(resource.apiVersion) == 0 || len(resource.kind) == 0 || len(resource.name) == 0 {
+				return nil, fmt.Errorf("manifest is missing apiVersion, kind or metadata.name:\n%s", document)
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func mergeResource(base *resource, patch *resource) (*resource, error) {
+	merged := &resource{apiVersion: base.apiVersion, kind: base.kind, namespace: base.namespace, name: base.name}
+	dataStruct, err := scheme.Scheme.New(schema.FromAPIVersionAndKind(base.apiVersion, base.kind))
+	if err != nil {
+		// Not a built in kind, no patch strategy known.
+		merged.object = mergePatch(base.object, patch.object).(map[string]any)
+		return merged, nil
+	}
+
+	original, err := json.Marshal(base.object)
+	if err != nil {
+		return nil, err
+	}
+	patchBytes, err := json.Marshal(patch.object)
+	if err != nil {
+		return nil, err
+	}
+	mergedBytes, err := strategicpatch.StrategicMergePatch(original, patchBytes, dataStruct)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(mergedBytes, &merged.object); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// mergePatch applies an RFC 7386 JSON merge patch.
+func mergePatch(original any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	originalObject, ok := original.(map[string]any)
+	if !ok {
+		originalObject = map[string]any{}
+	}
+	merged := map[string]any{}
+	for key, value := range originalObject {
+		merged[key] = value
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = mergePatch(merged[key], value)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,123 @@
+package trcoverlay
+
+import (
+	"strings"
+	"testing"
+)
+
+const base = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: hello
+  namespace: hello-dev
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: hello
+        image: hello:1.0
+      - name: sidecar
+        image: sidecar:1.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello
+---
+# Empty document.
+`
+
+const widget = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: hello
+spec:
+  size: small
+  color: blue
+`
+
+const overlay = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: hello
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: hello
+        image: hello:2.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello
+$patch: delete
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: hello
+spec:
+  size: large
+  color: null
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: hello
+`
+
+func TestMerge(t *testing.T) {
+	manifests, err := Merge([][]byte{[]byte(base), []byte(widget)}, [][]byte{[]byte(overlay)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: hello
+  namespace: hello-dev
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: hello:2.0
+        name: hello
+      - image: sidecar:1.0
+        name: sidecar
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: hello
+spec:
+  size: large
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: hello
+`
+	if string(manifests) != expected {
+		t.Fatalf("manifests:\n%s", manifests)
+	}
+
+	if manifests, err := Merge([][]byte{[]byte(base)}, nil); err != nil || strings.Count(string(manifests), "---") != 1 {
+		t.Fatalf("err %v, manifests:\n%s", err, manifests)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	for _, overlay := range []string{
+		"apiVersion: v1\nkind: Service\n",
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: missing\n$patch: delete\n",
+		"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: hello\nspec:\n  $patch: bogus\n",
+	} {
+		if _, err := Merge([][]byte{[]byte(base)}, [][]byte{[]byte(overlay)}); err == nil {
+			t.Errorf("expected error for overlay:\n%s", overlay)
+		}
+	}
+}
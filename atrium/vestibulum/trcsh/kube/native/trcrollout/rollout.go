@@ -0,0 +1,344 @@
+// Package trcrollout waits for Deployment and StatefulSet rollouts, collecting
+// events and logs of failing pods and optionally rolling back.
+package trcrollout
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Kinds of workloads a rollout can be verified for.
+const (
+	Deployment  = "deployment"
+	StatefulSet = "statefulset"
+)
+
+const (
+	DefaultTimeout = 5 * time.Minute
+
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+	logTailLines       = 200
+)
+
+// pollInterval is how often the rollout status is checked.
+var pollInterval = 2 * time.Second
+
+// Options describe the rollout to verify.
+type Options struct {
+	Kind      string // Deployment or StatefulSet
+	Name      string
+	Namespace string
+	Timeout   time.Duration
+	Rollback  bool // Roll back to the previous revision if the rollout fails.
+
+	// ReportDir is where events and container logs of failing pods are written.
+	ReportDir string
+}
+
+// WriteFunc writes a diagnostics file, usually to MemFs.
+type WriteFunc func(path string, data []byte)
+
+// statusFunc reports whether a rollout is done, what it is waiting for and
+// the selector of its pods.  An error means the rollout failed.
+type statusFunc func(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (bool, string, *metav1.LabelSelector, error)
+
+// Verify waits for the rollout of opts.Kind opts.Name to complete.  If it
+// fails or doesn't complete within opts.Timeout, events and logs of the pods
+// that aren't ready are written under opts.ReportDir and, if opts.Rollback,
+// the workload is rolled back to its previous revision.
+func Verify(ctx context.Context, clientset kubernetes.Interface, opts *Options, write WriteFunc, logger *log.Logger) error {
+	var status statusFunc
+	switch opts.Kind {
+	case Deployment:
+		status = deploymentStatus
+	case StatefulSet:
+		status = statefulSetStatus
+	default:
+		return fmt.Errorf("unsupported rollout kind %q, expected %s or %s", opts.Kind, Deployment, StatefulSet)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	workload := fmt.Sprintf("%s/%s", opts.Kind, opts.Name)
+
+	var selector *metav1.LabelSelector
+	message := ""
+	rolloutErr := wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		done, waiting, podSelector, err := status(ctx, clientset, opts.Namespace, opts.Name)
+		if podSelector != nil {
+			selector = podSelector
+		}
+		if waiting != message && len(waiting) > 0 {
+			logf(logger, "%s\n", waiting)
+		}
+		message = waiting
+		return done, err
+	})
+	if rolloutErr == nil {
+		logf(logger, "%s successfully rolled out\n", workload)
+		return nil
+	}
+	if wait.Interrupted(rolloutErr) {
+		rolloutErr = fmt.Errorf("timed out after %s: %s", timeout, message)
+	}
+	rolloutErr = fmt.Errorf("rollout of %s failed: %w", workload, rolloutErr)
+	logf(logger, "%v\n", rolloutErr)
+
+	if selector != nil && write != nil {
+		if err := collectDiagnostics(ctx, clientset, opts, selector, write, logger); err != nil {
+			logf(logger, "Unable to collect diagnostics for %s: %v\n", workload, err)
+		}
+	}
+
+	if opts.Rollback {
+		var revision int64
+		var err error
+		if opts.Kind == Deployment {
+			revision, err = rollbackDeployment(ctx, clientset, opts.Namespace, opts.Name)
+		} else {
+			revision, err = rollbackStatefulSet(ctx, clientset, opts.Namespace, opts.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("%w, rollback failed: %v", rolloutErr, err)
+		}
+		logf(logger, "Rolled back %s to revision %d\n", workload, revision)
+		return fmt.Errorf("%w, rolled back to revision %d", rolloutErr, revision)
+	}
+	return rolloutErr
+}
+
+func logf(logger *log.Logger, format string, v ...any) {
+	if logger != nil {
+		logger.Printf(format, v...)
+	}
+}
+
+// deploymentStatus follows kubectl rollout status for deployments.
+func deploymentStatus(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (bool, string, *metav1.LabelSelector, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, "", nil, err
+	}
+	selector := deployment.Spec.Selector
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, "Waiting for deployment spec update to be observed...", selector, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, "", selector, fmt.Errorf("deployment %q exceeded its progress deadline", name)
+		}
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", name, status.UpdatedReplicas, replicas), selector, nil
+	case status.Replicas > status.UpdatedReplicas:
+		return false, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", name, status.Replicas-status.UpdatedReplicas), selector, nil
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return false, fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", name, status.AvailableReplicas, status.UpdatedReplicas), selector, nil
+	}
+	return true, "", selector, nil
+}
+
+// statefulSetStatus follows kubectl rollout status for statefulsets.
+func statefulSetStatus(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (bool, string, *metav1.LabelSelector, error) {
+	statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, "", nil, err
+	}
+	selector := statefulSet.Spec.Selector
+	if statefulSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return false, "", selector, fmt.Errorf("rollout of statefulset %q can only be verified for the %s strategy", name, appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+	status := statefulSet.Status
+	if status.ObservedGeneration == 0 || statefulSet.Generation > status.ObservedGeneration {
+		return false, "Waiting for statefulset spec update to be observed...", selector, nil
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if status.ReadyReplicas < replicas {
+		return false, fmt.Sprintf("Waiting for %d pods to be ready...", replicas-status.ReadyReplicas), selector, nil
+	}
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition > 0 {
+		if status.UpdatedReplicas < replicas-*rollingUpdate.Partition {
+			return false, fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...", status.UpdatedReplicas, replicas-*rollingUpdate.Partition), selector, nil
+		}
+		return true, "", selector, nil
+	}
+	if status.UpdateRevision != status.CurrentRevision {
+		return false, fmt.Sprintf("Waiting for statefulset rolling update to complete %d pods at revision %s...", status.UpdatedReplicas, status.UpdateRevision), selector, nil
+	}
+	return true, "", selector, nil
+}
+
+// collectDiagnostics writes the events and container logs of pods that
+// aren't ready to <ReportDir>/<pod>/events.txt and <ReportDir>/<pod>/<container>.log.
+func collectDiagnostics(ctx context.Context, clientset kubernetes.Interface, opts *Options, selector *metav1.LabelSelector, write WriteFunc, logger *log.Logger) error {
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return err
+	}
+	pods, err := clientset.CoreV1().Pods(opts.Namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if podReady(&pod) {
+			continue
+		}
+		podDir := fmt.Sprintf("%s/%s", opts.ReportDir, pod.Name)
+
+		report := &strings.Builder{}
+		fmt.Fprintf(report, "Pod %s: %s\n", pod.Name, pod.Status.Phase)
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			switch {
+			case containerStatus.State.Waiting != nil:
+				fmt.Fprintf(report, "Container %s: waiting, %s: %s\n", containerStatus.Name, containerStatus.State.Waiting.Reason, containerStatus.State.Waiting.Message)
+			case containerStatus.State.Terminated != nil:
+				fmt.Fprintf(report, "Container %s: terminated, %s (exit code %d)\n", containerStatus.Name, containerStatus.State.Terminated.Reason, containerStatus.State.Terminated.ExitCode)
+			}
+		}
+		events, err := clientset.CoreV1().Events(opts.Namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s", pod.Name),
+		})
+		if err != nil {
+			logf(logger, "Unable to list events of pod %s: %v\n", pod.Name, err)
+		} else {
+			for _, event := range events.Items {
+				if event.InvolvedObject.Kind != "Pod" || event.InvolvedObject.Name != pod.Name {
+					continue
+				}
+				fmt.Fprintf(report, "%s\t%s\t%s\t%s\n", event.LastTimestamp.Format(time.RFC3339), event.Type, event.Reason, event.Message)
+			}
+		}
+		write(podDir+"/events.txt", []byte(report.String()))
+
+		tailLines := int64(logTailLines)
+		for _, container := range pod.Spec.Containers {
+			logs, err := clientset.CoreV1().Pods(opts.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name, TailLines: &tailLines}).DoRaw(ctx)
+			if err != nil {
+				logf(logger, "Unable to get logs of container %s in pod %s: %v\n", container.Name, pod.Name, err)
+				continue
+			}
+			write(fmt.Sprintf("%s/%s.log", podDir, container.Name), logs)
+		}
+		logf(logger, "Diagnostics of pod %s written to %s\n", pod.Name, podDir)
+	}
+	return nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// rollbackDeployment sets the pod template of a deployment back to that of
+// its ReplicaSet with the previous revision, as kubectl rollout undo does.
+func rollbackDeployment(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (int64, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	currentRevision, err := strconv.ParseInt(deployment.Annotations[revisionAnnotation], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("deployment %q has no revision", name)
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return 0, err
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return 0, err
+	}
+	var previous *appsv1.ReplicaSet
+	previousRevision := int64(0)
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(replicaSet, deployment) {
+			continue
+		}
+		revision, err := strconv.ParseInt(replicaSet.Annotations[revisionAnnotation], 10, 64)
+		if err != nil || revision >= currentRevision || revision <= previousRevision {
+			continue
+		}
+		previous, previousRevision = replicaSet, revision
+	}
+	if previous == nil {
+		return 0, fmt.Errorf("deployment %q has no previous revision", name)
+	}
+
+	template := previous.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	deployment.Spec.Template = *template
+	if _, err := clientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return 0, err
+	}
+	return previousRevision, nil
+}
+
+// rollbackStatefulSet patches a statefulset with its previous
+// ControllerRevision, as kubectl rollout undo does.
+func rollbackStatefulSet(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (int64, error) {
+	statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return 0, err
+	}
+	controllerRevisions, err := clientset.AppsV1().ControllerRevisions(namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return 0, err
+	}
+	currentRevision := int64(-1)
+	for _, controllerRevision := range controllerRevisions.Items {
+		if controllerRevision.Name == statefulSet.Status.UpdateRevision {
+			currentRevision = controllerRevision.Revision
+		}
+	}
+	if currentRevision < 0 {
+		return 0, fmt.Errorf("statefulset %q has no revision %q", name, statefulSet.Status.UpdateRevision)
+	}
+	var previous *appsv1.ControllerRevision
+	for i := range controllerRevisions.Items {
+		controllerRevision := &controllerRevisions.Items[i]
+		if !metav1.IsControlledBy(controllerRevision, statefulSet) || controllerRevision.Revision >= currentRevision {
+			continue
+		}
+		if previous == nil || controllerRevision.Revision > previous.Revision {
+			previous = controllerRevision
+		}
+	}
+	if previous == nil {
+		return 0, fmt.Errorf("statefulset %q has no previous revision", name)
+	}
+
+	if _, err := clientset.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, previous.Data.Raw, metav1.PatchOptions{}); err != nil {
+		return 0, err
+	}
+	return previous.Revision, nil
+}
@@ -0,0 +1,157 @@
+package trcrollout
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func init() {
+	pollInterval = 10 * time.Millisecond
+}
+
+var labels = map[string]string{"app": "trcshtalk"}
+
+func podTemplate(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "trcshtalk", Image: image}}},
+	}
+}
+
+func failingPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "trcshtalk-1", Namespace: "dev", Labels: labels},
+		Spec:       podTemplate("trcshtalk:2").Spec,
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "trcshtalk",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+			}},
+		},
+	}
+}
+
+func failingPodEvent() *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "trcshtalk-1.1", Namespace: "dev"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "trcshtalk-1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Failed",
+		Message:        "Failed to pull image",
+	}
+}
+
+func TestVerifyDeployment(t *testing.T) {
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "trcshtalk", Namespace: "dev", UID: "d1", Annotations: map[string]string{revisionAnnotation: "2"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: podTemplate("trcshtalk:2"),
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	opts := &Options{Kind: Deployment, Name: "trcshtalk", Namespace: "dev", Rollback: true, ReportDir: "rollout/deployment/trcshtalk"}
+
+	clientset := fake.NewSimpleClientset(deployment)
+	if err := Verify(context.Background(), clientset, opts, nil, nil); err != nil {
+		t.Fatalf("ready deployment: %v", err)
+	}
+
+	deployment.Status = appsv1.DeploymentStatus{
+		Replicas:        2,
+		UpdatedReplicas: 1,
+		Conditions:      []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"}},
+	}
+	owner := []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))}
+	replicaSet := func(name string, revision string, image string) *appsv1.ReplicaSet {
+		template := podTemplate(image)
+		template.Labels = map[string]string{"app": "trcshtalk", appsv1.DefaultDeploymentUniqueLabelKey: name}
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev", Labels: labels, OwnerReferences: owner, Annotations: map[string]string{revisionAnnotation: revision}},
+			Spec:       appsv1.ReplicaSetSpec{Template: template},
+		}
+	}
+	clientset = fake.NewSimpleClientset(deployment, failingPod(), failingPodEvent(),
+		replicaSet("trcshtalk-a", "1", "trcshtalk:1"), replicaSet("trcshtalk-b", "2", "trcshtalk:2"))
+	written := map[string]string{}
+	err := Verify(context.Background(), clientset, opts, func(path string, data []byte) { written[path] = string(data) }, nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeded its progress deadline, rolled back to revision 1") {
+		t.Fatalf("failed deployment: %v", err)
+	}
+	events := written["rollout/deployment/trcshtalk/trcshtalk-1/events.txt"]
+	if !strings.Contains(events, "waiting, ImagePullBackOff") || !strings.Contains(events, "Failed to pull image") {
+		t.Errorf("events: %q", events)
+	}
+	if _, ok := written["rollout/deployment/trcshtalk/trcshtalk-1/trcshtalk.log"]; !ok {
+		t.Errorf("no container log in %v", written)
+	}
+	rolledBack, _ := clientset.AppsV1().Deployments("dev").Get(context.Background(), "trcshtalk", metav1.GetOptions{})
+	if image := rolledBack.Spec.Template.Spec.Containers[0].Image; image != "trcshtalk:1" {
+		t.Errorf("rolled back to %s", image)
+	}
+	if _, ok := rolledBack.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Errorf("rolled back template labels %v", rolledBack.Spec.Template.Labels)
+	}
+}
+
+func TestVerifyStatefulSetTimeout(t *testing.T) {
+	replicas := int32(1)
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "trcshtalk", Namespace: "dev", UID: "s1", Generation: 2},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       &replicas,
+			Selector:       &metav1.LabelSelector{MatchLabels: labels},
+			Template:       podTemplate("trcshtalk:2"),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 2, CurrentRevision: "trcshtalk-a", UpdateRevision: "trcshtalk-b"},
+	}
+	owner := []metav1.OwnerReference{*metav1.NewControllerRef(statefulSet, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))}
+	controllerRevision := func(name string, revision int64, image string) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dev", Labels: labels, OwnerReferences: owner},
+			Data:       runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"trcshtalk","image":"` + image + `"}]}}}}`)},
+			Revision:   revision,
+		}
+	}
+	clientset := fake.NewSimpleClientset(statefulSet, failingPod(),
+		controllerRevision("trcshtalk-a", 1, "trcshtalk:1"), controllerRevision("trcshtalk-b", 2, "trcshtalk:2"))
+	patched := false
+	clientset.PrependReactor("patch", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patched = action.(k8stesting.PatchAction).GetPatchType() == types.StrategicMergePatchType
+		return false, nil, nil
+	})
+
+	written := map[string]string{}
+	opts := &Options{Kind: StatefulSet, Name: "trcshtalk", Namespace: "dev", Timeout: 50 * time.Millisecond, Rollback: true, ReportDir: "rollout"}
+	err := Verify(context.Background(), clientset, opts, func(path string, data []byte) { written[path] = string(data) }, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms: Waiting for 1 pods to be ready..., rolled back to revision 1") {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := written["rollout/trcshtalk-1/events.txt"]; !ok || !patched {
+		t.Errorf("written %v, patched %v", written, patched)
+	}
+	rolledBack, _ := clientset.AppsV1().StatefulSets("dev").Get(context.Background(), "trcshtalk", metav1.GetOptions{})
+	if image := rolledBack.Spec.Template.Spec.Containers[0].Image; image != "trcshtalk:1" {
+		t.Errorf("rolled back to %s", image)
+	}
+}
+
+func TestVerifyUnsupportedKind(t *testing.T) {
+	if err := Verify(context.Background(), fake.NewSimpleClientset(), &Options{Kind: "daemonset", Name: "x"}, nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
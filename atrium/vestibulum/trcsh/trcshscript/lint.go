@@ -0,0 +1,268 @@
+package trcshscript
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/trimble-oss/tierceron/atrium/vestibulum/trcdb/trcplgtoolbase"
+	"github.com/trimble-oss/tierceron/pkg/cli/trcconfigbase"
+	"github.com/trimble-oss/tierceron/pkg/cli/trcinitbase"
+	"github.com/trimble-oss/tierceron/pkg/cli/trcpubbase"
+	"github.com/trimble-oss/tierceron/pkg/cli/trcsubbase"
+)
+
+// Commands trcsh runs as a shell (processPluginCmds) and as a drone
+// (processDroneCmds and roleBasedRunner).  Any other command is ignored.
+var (
+	ShellCommands = []string{"trccertinit", "trcpub", "trcconfig", "trcplgtool", "kubectl"}
+	DroneCommands = []string{"trcconfig", "trcplgtool", "trcsub"}
+)
+
+// toolFlagSets return the flags each tool's CommonMain defines, standalone as
+// trcsh runs it or on a caller's flagset.
+var toolFlagSets = map[string]func(standalone bool) *flag.FlagSet{
+	"trcconfig":   trcconfigbase.Flags,
+	"trcsub":      trcsubbase.Flags,
+	"trcpub":      trcpubbase.Flags,
+	"trccertinit": trcinitbase.Flags,
+	"trcplgtool":  trcplgtoolbase.Flags,
+}
+
+// toolFlags returns the flags of each tool, true for boolean flags.  kubectl
+// flags aren't checked.  Built on first use as flag defaults depend on the
+// build options.
+var toolFlags = sync.OnceValue(func() map[string]map[string]bool {
+	tools := map[string]map[string]bool{}
+	for tool, flagSet := range toolFlagSets {
+		flags := map[string]bool{}
+		for _, standalone := range []bool{true, false} {
+			flagSet(standalone).VisitAll(func(f *flag.Flag) {
+				boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+				flags[f.Name] = ok && boolFlag.IsBoolFlag()
+			})
+		}
+		tools[tool] = flags
+	}
+	return tools
+})
+
+// trcconfig flags that write rendered configurations, secrets included, to
+// the file system.
+var diskOutputFlags = []string{"outputDir", "keystore", "certDestPath", "outputFile"}
+
+// Issue is a problem Lint found in a script.
+type Issue struct {
+	Line    int
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// Lint checks a script without running it.  Commands are split as
+// ProcessDeploy splits them and checked against the commands trcsh runs as a
+// drone or as a shell, the flags of each tool and the laws of trc shell.
+func Lint(content string, drone bool) []Issue {
+	script, err := Parse(content)
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			return []Issue{{Line: parseErr.Line, Message: parseErr.Message}}
+		}
+		return []Issue{{Message: err.Error()}}
+	}
+	issues := []Issue{}
+	script.Walk(func(statement *Statement) {
+		if statement.Kind != Command && statement.Kind != Try {
+			return
+		}
+		for _, message := range lintPipeline(statement.Text, drone) {
+			issues = append(issues, Issue{Line: statement.Line, Message: message})
+		}
+	})
+	return issues
+}
+
+func lintPipeline(pipeline string, drone bool) []string {
+	messages := []string{}
+	for _, deployLine := range strings.Split(pipeline, "|") {
+		deployLine = strings.TrimSpace(deployLine)
+		if deployLine == "" {
+			messages = append(messages, "empty command in pipeline")
+			continue
+		}
+		deployArgs := strings.Split(deployLine, " ")
+		control := deployArgs[0]
+		if !slices.Contains(allowedCommands(drone), control) {
+			messages = append(messages, fmt.Sprintf("%s is not run by trcsh as a %s and would be ignored", control, role(drone)))
+			continue
+		}
+		for _, arg := range deployArgs[1:] {
+			if strings.HasPrefix(arg, ">") {
+				messages = append(messages, fmt.Sprintf("%s: redirecting output writes configurations to the file system", control))
+			}
+		}
+		if control == "kubectl" {
+			if slices.Contains(deployArgs, "secret") && (slices.Contains(deployArgs, "-o") || hasFlag(deployArgs, "output")) &&
+				!strings.Contains(pipeline, "|") {
+				messages = append(messages, "kubectl: printing a secret writes it to standard output")
+			}
+			continue
+		}
+		messages = append(messages, lintFlags(control, deployArgs[1:])...)
+	}
+	return messages
+}
+
+// lintFlags checks args the way the tool's flagset would parse them.
+func lintFlags(control string, args []string) []string {
+	messages := []string{}
+	flags := toolFlags()[control]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" {
+			messages = append(messages, fmt.Sprintf("%s: repeated spaces make an empty argument, flags after it are ignored", control))
+			return messages
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			messages = append(messages, fmt.Sprintf("%s: unexpected argument %q, flags after it are ignored", control, arg))
+			return messages
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+		isBool, ok := flags[name]
+		if !ok {
+			messages = append(messages, fmt.Sprintf("%s: unknown flag -%s", control, name))
+			continue
+		}
+		if !isBool && !hasValue {
+			if i+1 >= len(args) {
+				messages = append(messages, fmt.Sprintf("%s: flag -%s needs a value", control, name))
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		if control == "trcconfig" && slices.Contains(diskOutputFlags, name) {
+			if name == "outputFile" && value == "-" {
+				messages = append(messages, "trcconfig: -outputFile=- prints secrets to standard output")
+			} else {
+				messages = append(messages, fmt.Sprintf("trcconfig: -%s writes configurations to the file system", name))
+			}
+		}
+	}
+	return messages
+}
+
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		flagName, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && flagName == name {
+			return true
+		}
+	}
+	return false
+}
+
+func allowedCommands(drone bool) []string {
+	if drone {
+		return DroneCommands
+	}
+	return ShellCommands
+}
+
+func role(drone bool) string {
+	if drone {
+		return "drone"
+	}
+	return "shell"
+}
+
+// DryRun runs a script writing the commands it would run, with the MemFs
+// paths they use, to out instead of running them.  Every command succeeds.
+func DryRun(content string, drone bool, out io.Writer) error {
+	script, err := Parse(content)
+	if err != nil {
+		return err
+	}
+	return script.Run(func(pipeline string) error {
+		fmt.Fprintln(out, pipeline)
+		for _, deployLine := range strings.Split(pipeline, "|") {
+			deployArgs := strings.Split(strings.TrimSpace(deployLine), " ")
+			control := deployArgs[0]
+			if !slices.Contains(allowedCommands(drone), control) {
+				fmt.Fprintf(out, "    %s: ignored as a %s\n", control, role(drone))
+				continue
+			}
+			if paths := memFsPaths(control, deployArgs[1:]); paths != "" {
+				fmt.Fprintf(out, "    %s: %s\n", control, paths)
+			}
+		}
+		if strings.Contains(pipeline, "|") {
+			fmt.Fprintln(out, "    pipe: memfs io/STDIO")
+		}
+		return nil
+	}, nil)
+}
+
+// memFsPaths describes the MemFs paths a command reads and writes when run
+// by trcsh without a deployment config (see roleBasedRunner).
+func memFsPaths(control string, args []string) string {
+	value := func(name string, defaultValue string) string {
+		for i, arg := range args {
+			flagName, flagValue, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if !strings.HasPrefix(arg, "-") || flagName != name {
+				continue
+			}
+			if hasValue {
+				return flagValue
+			}
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+		return defaultValue
+	}
+	switch control {
+	case "trcconfig":
+		return fmt.Sprintf("reads memfs %s, writes memfs %s", value("startDir", "trc_templates"), value("endDir", "."))
+	case "trcsub":
+		return "writes memfs trc_templates"
+	case "trcpub":
+		return fmt.Sprintf("reads memfs %s", value("dir", "trc_templates"))
+	case "kubectl":
+		if len(args) > 2 && args[0] == "rollout" && args[1] == "verify" {
+			kind, name, _ := strings.Cut(args[2], "/")
+			return fmt.Sprintf("writes memfs rollout/%s/%s if the rollout fails", kind, name)
+		}
+		if templates := value("templates", ""); len(args) > 0 && args[0] == "apply" && templates != "" {
+			return fmt.Sprintf("renders trc_templates/%s/kube to memfs kube_rendered/%s", templates, strings.ReplaceAll(templates, "/", "_"))
+		}
+		reads := []string{}
+		for i, arg := range args {
+			if arg == "-f" && i+1 < len(args) {
+				reads = append(reads, args[i+1])
+			} else if source, ok := strings.CutPrefix(arg, "--from-file="); ok {
+				_, path, hasKey := strings.Cut(source, "=")
+				if !hasKey {
+					path = source
+				}
+				reads = append(reads, path)
+			}
+		}
+		for i, path := range reads {
+			if path == "-" {
+				reads[i] = "io/STDIO"
+			}
+		}
+		if len(reads) > 0 {
+			return "reads memfs " + strings.Join(reads, ", memfs ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package trcshscript
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/trimble-oss/tierceron/buildopts/coreopts"
+	coreoptsloader "github.com/trimble-oss/tierceron/buildoptsstub/coreopts"
+)
+
+func TestLint(t *testing.T) {
+	coreopts.NewOptionsBuilder(coreoptsloader.LoadOptions())
+	content := `#!/bin/bash
+trcplgtool -pluginservicestop
+trcconfig -env=dev -outputDir=/tmp/out
+trcplgtool -env=dev -pushimage -pluginName=x
+trcplgtool  -codebundledeploy
+kubectl create secret generic key --from-file=key.pem --dry-run=client -o yaml | kubectl apply -f -
+trcsub -env=dev
+retry 2
+    trcpub -dir
+end
+`
+	issues := []string{}
+	for _, issue := range Lint(content, false) {
+		issues = append(issues, issue.String())
+	}
+	expected := []string{
+		"line 3: trcconfig: -outputDir writes configurations to the file system",
+		"line 4: trcplgtool: unknown flag -pushimage",
+		"line 5: trcplgtool: repeated spaces make an empty argument, flags after it are ignored",
+		"line 7: trcsub is not run by trcsh as a shell and would be ignored",
+		"line 9: trcpub: flag -dir needs a value",
+	}
+	if !slices.Equal(issues, expected) {
+		t.Fatalf("issues:\n%s", strings.Join(issues, "\n"))
+	}
+
+	if issues := Lint("if failed\n", true); len(issues) != 1 || issues[0].Line != 1 {
+		t.Fatalf("issues: %v", issues)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	out := &strings.Builder{}
+	err := DryRun("set NS=dev\ntrcconfig -env=${NS} -endDir=deploy\nkubectl create configmap c --from-file=config.yml --dry-run=client -o yaml | kubectl apply -f -\n", false, out)
+	expected := `trcconfig -env=dev -endDir=deploy
+    trcconfig: reads memfs trc_templates, writes memfs deploy
+kubectl create configmap c --from-file=config.yml --dry-run=client -o yaml | kubectl apply -f -
+    kubectl: reads memfs config.yml
+    kubectl: reads memfs io/STDIO
+    pipe: memfs io/STDIO
+`
+	if err != nil || out.String() != expected {
+		t.Fatalf("err %v, output:\n%s", err, out.String())
+	}
+}
+
+// TestToolTables keeps the lint tables in sync with the commands trcsh runs
+// and the flags the tools define.
+func TestToolTables(t *testing.T) {
+	coreopts.NewOptionsBuilder(coreoptsloader.LoadOptions())
+	trcsh := parseSource(t, "../../trcshbase/trcsh.go")
+	shellCommands := switchCases(trcsh, "processPluginCmds")
+	droneCommands := switchCases(trcsh, "roleBasedRunner")
+	if !sameSet(shellCommands, ShellCommands) || !sameSet(droneCommands, DroneCommands) {
+		t.Errorf("commands changed: shell %v, drone %v", shellCommands, droneCommands)
+	}
+
+	for tool, path := range map[string]string{
+		"trcconfig":   "../../../../pkg/cli/trcconfigbase/trcconfig.go",
+		"trcsub":      "../../../../pkg/cli/trcsubbase/trcsub.go",
+		"trcpub":      "../../../../pkg/cli/trcpubbase/trcpub.go",
+		"trccertinit": "../../../../pkg/cli/trcinitbase/init.go",
+		"trcplgtool":  "../../trcdb/trcplgtoolbase/trcplgtoolbase.go",
+	} {
+		flags := flagsetFlags(parseSource(t, path))
+		if !maps.Equal(flags, toolFlags()[tool]) {
+			t.Errorf("%s flags changed: %v", tool, flags)
+		}
+	}
+}
+
+func parseSource(t *testing.T, path string) *ast.File {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// switchCases returns the cases of the switch on control in function name.
+func switchCases(file *ast.File, name string) []string {
+	cases := []string{}
+	for _, decl := range file.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Name.Name != name {
+			continue
+		}
+		ast.Inspect(function, func(node ast.Node) bool {
+			switchStmt, ok := node.(*ast.SwitchStmt)
+			if !ok {
+				return true
+			}
+			if tag, ok := switchStmt.Tag.(*ast.Ident); !ok || tag.Name != "control" {
+				return true
+			}
+			for _, stmt := range switchStmt.Body.List {
+				for _, expr := range stmt.(*ast.CaseClause).List {
+					if literal, ok := expr.(*ast.BasicLit); ok {
+						value, _ := strconv.Unquote(literal.Value)
+						cases = append(cases, value)
+					}
+				}
+			}
+			return false
+		})
+	}
+	return cases
+}
+
+// flagsetFlags returns the flags defined with flagset.<Type>("name", ...).
+func flagsetFlags(file *ast.File) map[string]bool {
+	flags := map[string]bool{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if receiver, ok := selector.X.(*ast.Ident); !ok || receiver.Name != "flagset" {
+			return true
+		}
+		if literal, ok := call.Args[0].(*ast.BasicLit); ok && literal.Kind == token.STRING {
+			name, _ := strconv.Unquote(literal.Value)
+			flags[name] = selector.Sel.Name == "Bool"
+		}
+		return true
+	})
+	return flags
+}
+
+func sameSet(a []string, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
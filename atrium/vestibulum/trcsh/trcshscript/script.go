@@ -0,0 +1,434 @@
+// Package trcshscript parses and runs .trc deploy scripts.
+//
+// A .trc script is a list of commands, one per line, each a pipeline of
+// tool invocations separated by "|".  Lines starting with # are comments.
+// Scripts may also use a few statements for control flow:
+//
+//	set NAME=value          sets a script variable
+//	export NAME=value       sets a script variable and, while the script runs,
+//	                        the environment variable
+//	try <command>           runs command, continuing if it fails
+//	if succeeded            runs the block if the previous command succeeded
+//	if failed               runs the block if the previous command failed
+//	if a == b, if a != b    runs the block depending on the comparison
+//	else
+//	retry N [delay D]       runs the block up to N times until it succeeds
+//	on-failure              block run when a later command fails the script
+//	end
+//
+// ${NAME} in a line is replaced by the variable's value, taken from the
+// environment if the script didn't set it and the caller listed NAME in
+// Script.Env, and left as is otherwise.  Other environment variables are
+// never expanded, as commands end up in logs.
+// ${status} is the result of the previous command, 0 or 1.  A command that
+// fails outside of try or retry stops the script after running the
+// on-failure block, if any.
+//
+// For example:
+//
+//	set PLUGIN=trcshtalk
+//	try trcplgtool -pluginservicestop -pluginName=${PLUGIN}
+//	on-failure
+//	    trcplgtool -pluginservicestart -pluginName=${PLUGIN}
+//	end
+//	retry 3 delay 10s
+//	    trcplgtool -codebundledeploy -pluginName=${PLUGIN}
+//	end
+//	trcplgtool -pluginservicestart -pluginName=${PLUGIN}
+//
+// Scripts without statements run exactly as plain lists of commands.
+package trcshscript
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of a Statement.
+type Kind int
+
+const (
+	Command Kind = iota
+	Set
+	Export
+	Try
+	If
+	Retry
+	OnFailure
+)
+
+// Condition is the condition of an if statement.  Op is succeeded, failed,
+// == or !=.
+type Condition struct {
+	Op    string
+	Left  string
+	Right string
+}
+
+// Statement is a parsed line of a script, with the blocks it owns.
+type Statement struct {
+	Kind Kind
+	Line int
+
+	// Command and Try.
+	Text string
+
+	// Set and Export.
+	Name  string
+	Value string
+
+	// If.
+	Cond Condition
+	Else []*Statement
+
+	// Retry.
+	Count int
+	Delay time.Duration
+
+	// If, Retry and OnFailure.
+	Body []*Statement
+}
+
+// Script is a parsed .trc script.
+type Script struct {
+	Statements []*Statement
+
+	// Env names the environment variables ${NAME} may expand.
+	Env []string
+}
+
+// Runner runs a command line of a script.
+type Runner func(command string) error
+
+// Error is a command that failed the script.
+type Error struct {
+	Line    int
+	Command string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s: %v", e.Line, e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ParseError is a line of a script that can't be parsed.
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func parseErrorf(line int, format string, v ...any) error {
+	return &ParseError{Line: line, Message: fmt.Sprintf(format, v...)}
+}
+
+// StatusVar is the variable holding the previous command's result.
+const StatusVar = "status"
+
+var (
+	nameRegex      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	referenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// sleep is replaced in tests.
+var sleep = time.Sleep
+
+// Parse parses a script.  Errors name the offending line.
+func Parse(content string) (*Script, error) {
+	script := &Script{}
+	type block struct {
+		statement *Statement
+		inElse    bool
+	}
+	stack := []*block{}
+	add := func(statement *Statement) {
+		if len(stack) == 0 {
+			script.Statements = append(script.Statements, statement)
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.inElse {
+			top.statement.Else = append(top.statement.Else, statement)
+		} else {
+			top.statement.Body = append(top.statement.Body, statement)
+		}
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNumber := i + 1
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyword, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch keyword {
+		case "set", "export":
+			name, value, ok := strings.Cut(rest, "=")
+			name = strings.TrimSpace(name)
+			if !ok || !nameRegex.MatchString(name) {
+				return nil, parseErrorf(lineNumber, "expected %s NAME=value", keyword)
+			}
+			if name == StatusVar {
+				return nil, parseErrorf(lineNumber, "%s is read only", StatusVar)
+			}
+			kind := Set
+			if keyword == "export" {
+				kind = Export
+			}
+			add(&Statement{Kind: kind, Line: lineNumber, Name: name, Value: strings.TrimSpace(value)})
+		case "try":
+			if rest == "" {
+				return nil, parseErrorf(lineNumber, "try needs a command")
+			}
+			add(&Statement{Kind: Try, Line: lineNumber, Text: rest})
+		case "if":
+			cond, err := parseCondition(rest)
+			if err != nil {
+				return nil, parseErrorf(lineNumber, "%v", err)
+			}
+			statement := &Statement{Kind: If, Line: lineNumber, Cond: cond}
+			add(statement)
+			stack = append(stack, &block{statement: statement})
+		case "else":
+			if rest != "" || len(stack) == 0 || stack[len(stack)-1].statement.Kind != If || stack[len(stack)-1].inElse {
+				return nil, parseErrorf(lineNumber, "else without if")
+			}
+			stack[len(stack)-1].inElse = true
+		case "retry":
+			statement, err := parseRetry(rest)
+			if err != nil {
+				return nil, parseErrorf(lineNumber, "%v", err)
+			}
+			statement.Line = lineNumber
+			add(statement)
+			stack = append(stack, &block{statement: statement})
+		case "on-failure":
+			if rest != "" {
+				return nil, parseErrorf(lineNumber, "unexpected %q after on-failure", rest)
+			}
+			if len(stack) > 0 {
+				return nil, parseErrorf(lineNumber, "on-failure must not be nested")
+			}
+			statement := &Statement{Kind: OnFailure, Line: lineNumber}
+			add(statement)
+			stack = append(stack, &block{statement: statement})
+		case "end":
+			if rest != "" || len(stack) == 0 {
+				return nil, parseErrorf(lineNumber, "end without block")
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			add(&Statement{Kind: Command, Line: lineNumber, Text: line})
+		}
+	}
+	if len(stack) > 0 {
+		return nil, parseErrorf(stack[len(stack)-1].statement.Line, "block is missing its end")
+	}
+	return script, nil
+}
+
+// Walk calls fn for each statement of the script in source order, including
+// those in blocks that might not run.
+func (s *Script) Walk(fn func(*Statement)) {
+	walk(s.Statements, fn)
+}
+
+func walk(statements []*Statement, fn func(*Statement)) {
+	for _, statement := range statements {
+		fn(statement)
+		walk(statement.Body, fn)
+		walk(statement.Else, fn)
+	}
+}
+
+func parseCondition(text string) (Condition, error) {
+	fields := strings.Fields(text)
+	switch {
+	case len(fields) == 1 && (fields[0] == "succeeded" || fields[0] == "failed"):
+		return Condition{Op: fields[0]}, nil
+	case len(fields) == 3 && (fields[1] == "==" || fields[1] == "!="):
+		return Condition{Op: fields[1], Left: fields[0], Right: fields[2]}, nil
+	}
+	return Condition{}, fmt.Errorf("expected if succeeded, if failed or if a == b, got %q", text)
+}
+
+func parseRetry(text string) (*Statement, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 1 && !(len(fields) == 3 && fields[1] == "delay") {
+		return nil, fmt.Errorf("expected retry N [delay D], got %q", text)
+	}
+	count, err := strconv.Atoi(fields[0])
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid retry count %q", fields[0])
+	}
+	statement := &Statement{Kind: Retry, Count: count}
+	if len(fields) == 3 {
+		if statement.Delay, err = time.ParseDuration(fields[2]); err != nil || statement.Delay < 0 {
+			return nil, fmt.Errorf("invalid retry delay %q", fields[2])
+		}
+	}
+	return statement, nil
+}
+
+// run is the state of a running script.
+type run struct {
+	runner    Runner
+	logger    *log.Logger
+	env       []string
+	vars      map[string]string
+	exported  map[string]*string // Environment before the script's exports.
+	status    error
+	ran       bool
+	onFailure []*Statement
+}
+
+// Run runs the script's commands with runner.  It returns the *Error of
+// the command that failed the script.  Environment variables the script
+// exported are restored when it returns.
+func (s *Script) Run(runner Runner, logger *log.Logger) error {
+	r := &run{runner: runner, logger: logger, env: s.Env, vars: map[string]string{}, exported: map[string]*string{}}
+	defer r.restoreEnv()
+	err := r.exec(s.Statements)
+	if err != nil && r.onFailure != nil {
+		onFailure := r.onFailure
+		r.onFailure = nil
+		r.logf("Running on-failure block: %v\n", err)
+		if handlerErr := r.exec(onFailure); handlerErr != nil {
+			r.logf("on-failure block failed: %v\n", handlerErr)
+		}
+	}
+	return err
+}
+
+func (r *run) restoreEnv() {
+	for name, value := range r.exported {
+		if value == nil {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, *value)
+		}
+	}
+}
+
+func (r *run) logf(format string, v ...any) {
+	if r.logger != nil {
+		r.logger.Printf(format, v...)
+	}
+}
+
+func (r *run) exec(statements []*Statement) error {
+	for _, statement := range statements {
+		switch statement.Kind {
+		case Command:
+			if err := r.command(statement); err != nil {
+				return err
+			}
+		case Try:
+			if err := r.command(statement); err != nil {
+				r.logf("Continuing after failure: %v\n", err)
+			}
+		case Set, Export:
+			value := r.expand(statement.Value)
+			r.vars[statement.Name] = value
+			if statement.Kind == Export {
+				if _, ok := r.exported[statement.Name]; !ok {
+					var previous *string
+					if value, ok := os.LookupEnv(statement.Name); ok {
+						previous = &value
+					}
+					r.exported[statement.Name] = previous
+				}
+				if err := os.Setenv(statement.Name, value); err != nil {
+					return &Error{Line: statement.Line, Command: statement.Name, Err: err}
+				}
+			}
+		case If:
+			matched, err := r.condition(statement.Cond)
+			if err != nil {
+				return &Error{Line: statement.Line, Command: "if", Err: err}
+			}
+			block := statement.Else
+			if matched {
+				block = statement.Body
+			}
+			if err := r.exec(block); err != nil {
+				return err
+			}
+		case Retry:
+			var err error
+			for attempt := 1; attempt <= statement.Count; attempt++ {
+				if err = r.exec(statement.Body); err == nil {
+					break
+				}
+				if attempt < statement.Count {
+					r.logf("Retrying after failure %d of %d: %v\n", attempt, statement.Count, err)
+					sleep(statement.Delay)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		case OnFailure:
+			r.onFailure = statement.Body
+		}
+	}
+	return nil
+}
+
+func (r *run) command(statement *Statement) error {
+	command := r.expand(statement.Text)
+	err := r.runner(command)
+	r.ran = true
+	r.status = err
+	if err != nil {
+		return &Error{Line: statement.Line, Command: command, Err: err}
+	}
+	return nil
+}
+
+func (r *run) condition(cond Condition) (bool, error) {
+	switch cond.Op {
+	case "succeeded", "failed":
+		if !r.ran {
+			return false, fmt.Errorf("if %s before any command", cond.Op)
+		}
+		return (r.status == nil) == (cond.Op == "succeeded"), nil
+	}
+	return (r.expand(cond.Left) == r.expand(cond.Right)) == (cond.Op == "=="), nil
+}
+
+// expand replaces ${NAME} references.  References to variables that are
+// neither set nor allowed from the environment are left as they are, as
+// plain scripts pass them through to the tools.
+func (r *run) expand(text string) string {
+	return referenceRegex.ReplaceAllStringFunc(text, func(reference string) string {
+		name := referenceRegex.FindStringSubmatch(reference)[1]
+		if name == StatusVar {
+			if r.status != nil {
+				return "1"
+			}
+			return "0"
+		}
+		if value, ok := r.vars[name]; ok {
+			return value
+		}
+		if slices.Contains(r.env, name) {
+			if value, ok := os.LookupEnv(name); ok {
+				return value
+			}
+		}
+		return reference
+	})
+}
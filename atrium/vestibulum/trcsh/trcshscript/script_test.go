@@ -0,0 +1,121 @@
+package trcshscript
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runScript(t *testing.T, content string, failing map[string]int) ([]string, error) {
+	t.Helper()
+	script, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	script.Env = []string{"TRC_TEST_ENV"}
+	ran := []string{}
+	err = script.Run(func(command string) error {
+		ran = append(ran, command)
+		if failing[command] > 0 {
+			failing[command]--
+			return errors.New("failed")
+		}
+		return nil
+	}, nil)
+	return ran, err
+}
+
+func TestPlainScript(t *testing.T) {
+	ran, err := runScript(t, "#!/bin/bash\n# comment\ntrcplgtool -pluginservicestop\n\n  trcconfig -env=dev | trcsub\n", nil)
+	if err != nil || strings.Join(ran, ";") != "trcplgtool -pluginservicestop;trcconfig -env=dev | trcsub" {
+		t.Fatalf("ran %q, err %v", ran, err)
+	}
+
+	ran, err = runScript(t, "a\nb\nc\n", map[string]int{"b": 1})
+	var scriptErr *Error
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 2 || len(ran) != 2 {
+		t.Fatalf("ran %q, err %v", ran, err)
+	}
+}
+
+func TestControlFlow(t *testing.T) {
+	sleep = func(time.Duration) {}
+	t.Setenv("TRC_TEST_ENV", "dev")
+	content := `
+set PLUGIN=trcshtalk
+export TRC_TEST_DEPLOY=${PLUGIN}-${TRC_TEST_ENV}
+on-failure
+    start ${PLUGIN}
+end
+try stop ${PLUGIN}
+if failed
+    echo stop ${status}
+else
+    echo stopped
+end
+if ${TRC_TEST_ENV} == dev
+    retry 3 delay 1s
+        deploy ${TRC_TEST_DEPLOY}
+    end
+end
+start ${PLUGIN}
+`
+	ran, err := runScript(t, content, map[string]int{"stop trcshtalk": 1, "deploy trcshtalk-dev": 2})
+	expected := "stop trcshtalk;echo stop 1;deploy trcshtalk-dev;deploy trcshtalk-dev;deploy trcshtalk-dev;start trcshtalk"
+	if err != nil || strings.Join(ran, ";") != expected {
+		t.Fatalf("ran %q, err %v", ran, err)
+	}
+
+	ran, err = runScript(t, content, map[string]int{"deploy trcshtalk-dev": 3})
+	expected = "stop trcshtalk;echo stopped;deploy trcshtalk-dev;deploy trcshtalk-dev;deploy trcshtalk-dev;start trcshtalk"
+	if err == nil || strings.Join(ran, ";") != expected {
+		t.Fatalf("ran %q, err %v", ran, err)
+	}
+
+	ran, err = runScript(t, "deploy -pluginName=x:${TRC_TEST_UNDEFINED}\n", nil)
+	if err != nil || ran[0] != "deploy -pluginName=x:${TRC_TEST_UNDEFINED}" {
+		t.Fatalf("ran %q, err %v", ran, err)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Setenv("TRC_TEST_ENV", "dev")
+	t.Setenv("TRC_TEST_SECRET", "secret")
+	t.Setenv("TRC_TEST_REGION", "west")
+	content := `
+export TRC_TEST_REGION=east
+export TRC_TEST_DEPLOY=${TRC_TEST_ENV}
+export TRC_TEST_REGION=north
+echo ${TRC_TEST_SECRET} ${TRC_TEST_REGION}
+`
+	ran, err := runScript(t, content, nil)
+	if err != nil || ran[0] != "echo ${TRC_TEST_SECRET} north" {
+		t.Fatalf("ran %q, err %v", ran, err)
+	}
+	if region := os.Getenv("TRC_TEST_REGION"); region != "west" {
+		t.Fatalf("Expected TRC_TEST_REGION to be restored, got %q", region)
+	}
+	if _, ok := os.LookupEnv("TRC_TEST_DEPLOY"); ok {
+		t.Fatal("Expected TRC_TEST_DEPLOY to be unset after the script")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{
+		"if succeeded\na\n",
+		"end\n",
+		"else\n",
+		"retry 0\na\nend\n",
+		"retry 2 delay soon\na\nend\n",
+		"if a = b\nend\n",
+		"set 1X=a\n",
+		"set status=1\n",
+		"retry 2\non-failure\nend\nend\n",
+	} {
+		if _, err := Parse(content); err == nil {
+			t.Errorf("expected error parsing %q", content)
+		}
+	}
+}
@@ -3,7 +3,7 @@
 // This package implements the core functionality for the Tierceron shell (trcsh) agent,
 // which is responsible for:
 //   - Bootstrap and initialization of the trcsh environment
-//   - Deployment management and orchestration
+//   - Deployment management and orchestration, running .trc scripts (see trcshscript)
 //   - Plugin lifecycle management
 //   - Certificate and authentication handling
 //   - Region-specific configuration and validation
@@ -37,6 +37,7 @@ import (
 	"github.com/trimble-oss/tierceron/atrium/vestibulum/trcsh/deployutil"
 	kube "github.com/trimble-oss/tierceron/atrium/vestibulum/trcsh/kube/native"
 	"github.com/trimble-oss/tierceron/atrium/vestibulum/trcsh/trcshauth"
+	"github.com/trimble-oss/tierceron/atrium/vestibulum/trcsh/trcshscript"
 	"github.com/trimble-oss/tierceron/buildopts/coreopts"
 	"github.com/trimble-oss/tierceron/buildopts/deployopts"
 	"github.com/trimble-oss/tierceron/pkg/capauth"
@@ -406,6 +407,29 @@ func EnableDeployer(driverConfigPtr *config.DriverConfig,
 	go ProcessDeploy(trcshDriverConfig.FeatherCtx, trcshDriverConfig, trcPath, projServ, dronePtr)
 }
 
+// checkScript lints the script at scriptPath, or prints the commands it would
+// run if dryRun, for the role trcsh is running as.  Nothing is run.
+func checkScript(scriptPath string, drone bool, dryRun bool) error {
+	if len(scriptPath) == 0 {
+		return errors.New("script to check is required")
+	}
+	content, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return trcshscript.DryRun(string(content), drone, os.Stdout)
+	}
+	issues := trcshscript.Lint(string(content), drone)
+	for _, issue := range issues {
+		fmt.Printf("%s: %s\n", scriptPath, issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%s: %d issues found", scriptPath, len(issues))
+	}
+	return nil
+}
+
 // This is a controller program that can act as any command line utility.
 // The Tierceron Shell runs tierceron and kubectl commands in a secure shell.
 
@@ -450,6 +474,8 @@ func CommonMain(envPtr *string, envCtxPtr *string,
 	tracelessPtr := flagset.Bool("traceless", false, "Trace less") // For running with staging env and dev behavior
 	droneFlagPtr = flagset.Bool("drone", false, "Run as drone.")
 	addrPtr := flagset.String("addr", "", "API endpoint for the vault")
+	lintPtr := flagset.Bool("lint", false, "Check a .trc script without running it.")
+	dryRunPtr := flagset.Bool("dryrun", false, "Print the commands a .trc script would run without running them.")
 	isShellRunner := (configMap != nil)
 	isShell := false
 
@@ -512,6 +538,18 @@ func CommonMain(envPtr *string, envCtxPtr *string,
 	}
 	projectServicePtr = projectServiceFlagPtr
 
+	if *lintPtr || *dryRunPtr {
+		scriptPath := *trcPathPtr
+		if len(scriptPath) == 0 {
+			scriptPath = flagset.Arg(0)
+		}
+		if err := checkScript(scriptPath, *dronePtr, *dryRunPtr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		return nil
+	}
+
 	if isShell && !*dronePtr && (projectServicePtr == nil || len(*projectServicePtr) == 0) {
 		eUtils.LogSyncAndExit(nil, "Script exiting, projectService flag is required", -1)
 	}
@@ -971,6 +1009,7 @@ func CommonMain(envPtr *string, envCtxPtr *string,
 			kernelPluginHandler = hive.InitKernel(fmt.Sprintf("%s-%d", kernelName, kernelID))
 			kernelPluginHandler.ConfigContext.Log = driverConfigPtr.CoreConfig.Log
 			go kernelPluginHandler.DynamicReloader(trcshDriverConfig.DriverConfig)
+			kernelPluginHandler.StartAdminServer(trcshDriverConfig.DriverConfig)
 		}
 
 		trcshDriverConfig.DriverConfig.CoreConfig.Log.Println("Completed bootstrapping and continuing to initialize services.")
@@ -1340,18 +1379,10 @@ func roleBasedRunner(
 			err = trcplgtoolbase.CommonMain(&envDefaultPtr, &gTrcshConfig.EnvContext, &tokenName, &region, nil, deployArgLines, trcshDriverConfig)
 		}
 	case "trcconfig":
-		roleEntityPtr := new(string)
-		*roleEntityPtr = "configrole"
-		trcshDriverConfig.DriverConfig.CoreConfig.CurrentRoleEntityPtr = roleEntityPtr
-
-		if trcshDriverConfig.DriverConfig.CoreConfig.EnvBasis == "itdev" || (prod.IsProd() && prod.IsStagingProd(trcshDriverConfig.DriverConfig.CoreConfig.EnvBasis)) ||
-			trcshDriverConfig.DriverConfig.CoreConfig.Env == "itdev" || (prod.IsProd() && prod.IsStagingProd(trcshDriverConfig.DriverConfig.CoreConfig.Env)) {
-			if !kernelopts.BuildOptions.IsKernel() {
-				trcshDriverConfig.DriverConfig.OutputMemCache = false
-			}
-			// itdev, staging, and prod always key off TRC_ENV stored in trcshDriverConfig.DriverConfig.CoreConfig.Env.
-			envDefaultPtr = trcshDriverConfig.DriverConfig.CoreConfig.Env
-			tokenName = "config_token_" + eUtils.GetEnvBasis(trcshDriverConfig.DriverConfig.CoreConfig.Env)
+		var pinnedEnv bool
+		envDefaultPtr, tokenName, pinnedEnv = useConfigRole(trcshDriverConfig.DriverConfig)
+		if pinnedEnv && !kernelopts.BuildOptions.IsKernel() {
+			trcshDriverConfig.DriverConfig.OutputMemCache = false
 		}
 		if trcshDriverConfig.DriverConfig.IsDrone && eUtils.IsWindows() {
 			// Can this be enabled without disrupting the kernel on linux?
@@ -1379,6 +1410,71 @@ func roleBasedRunner(
 	return err
 }
 
+// useConfigRole switches driverConfig to the config role, returning the env
+// and token trcconfig runs with.  pinnedEnv is true for itdev, staging, and
+// prod, which always key off TRC_ENV stored in CoreConfig.Env.
+func useConfigRole(driverConfig *config.DriverConfig) (envDefault string, tokenName string, pinnedEnv bool) {
+	roleEntityPtr := new(string)
+	*roleEntityPtr = "configrole"
+	driverConfig.CoreConfig.CurrentRoleEntityPtr = roleEntityPtr
+
+	coreConfig := driverConfig.CoreConfig
+	if coreConfig.EnvBasis == "itdev" || (prod.IsProd() && prod.IsStagingProd(coreConfig.EnvBasis)) ||
+		coreConfig.Env == "itdev" || (prod.IsProd() && prod.IsStagingProd(coreConfig.Env)) {
+		return coreConfig.Env, "config_token_" + eUtils.GetEnvBasis(coreConfig.Env), true
+	}
+	return coreConfig.EnvBasis, "config_token_" + coreConfig.EnvBasis, false
+}
+
+// renderKubeTemplates renders the templates of projectService through
+// trcconfig into the MemFs directory the kubectl apply templates directive
+// reads manifests from.  Unlike trcconfig script lines, the output always
+// stays in memory.
+func renderKubeTemplates(region string, trcshDriverConfig *capauth.TrcshDriverConfig, env string, projectService string) error {
+	driverConfig := trcshDriverConfig.DriverConfig
+	startDir, endDir, outputMemCache := driverConfig.StartDir, driverConfig.EndDir, driverConfig.OutputMemCache
+	fileFilter, wantCerts, isShellSubProcess := driverConfig.FileFilter, driverConfig.CoreConfig.WantCerts, driverConfig.IsShellSubProcess
+	currentRoleEntityPtr := driverConfig.CoreConfig.CurrentRoleEntityPtr
+	defer func() {
+		driverConfig.StartDir, driverConfig.EndDir, driverConfig.OutputMemCache = startDir, endDir, outputMemCache
+		driverConfig.FileFilter, driverConfig.CoreConfig.WantCerts, driverConfig.IsShellSubProcess = fileFilter, wantCerts, isShellSubProcess
+		driverConfig.CoreConfig.CurrentRoleEntityPtr = currentRoleEntityPtr
+	}()
+
+	envDefault, tokenName, _ := useConfigRole(driverConfig)
+	driverConfig.FileFilter = nil
+	driverConfig.CoreConfig.WantCerts = false
+	driverConfig.IsShellSubProcess = true
+	driverConfig.OutputMemCache = true
+	driverConfig.StartDir = []string{"trc_templates"}
+	if driverConfig.DeploymentConfig != nil {
+		if trcDeployRoot, ok := (*driverConfig.DeploymentConfig)["trcdeployroot"]; ok {
+			driverConfig.StartDir = []string{fmt.Sprintf("%s/trc_templates", trcDeployRoot.(string))}
+		}
+	}
+	renderDir := kube.ManifestRenderDir(projectService)
+	driverConfig.EndDir = renderDir
+	if driverConfig.MemFs != nil {
+		driverConfig.MemFs.ClearCache(renderDir)
+	}
+
+	driverConfig.CoreConfig.Log.Printf("Rendering kube manifest templates for %s\n", projectService)
+	renderArgs := []string{"trcconfig", "-env=" + env, "-servicesWanted=" + projectService, "-endDir=" + renderDir}
+	return trcconfigbase.CommonMain(&envDefault, &gTrcshConfig.EnvContext, &tokenName, &region, nil, renderArgs, driverConfig)
+}
+
+// deployStepError is a failed deploy script step with the status trcsh exits
+// with when the script doesn't handle the failure.
+type deployStepError struct {
+	deployLine string
+	exitCode   int
+	err        error
+}
+
+func (e *deployStepError) Error() string { return e.err.Error() }
+
+func (e *deployStepError) Unwrap() error { return e.err }
+
 func processPluginCmds(trcKubeDeploymentConfig **kube.TrcKubeConfig,
 	onceKubeInit *sync.Once,
 	PipeOS trcshio.TrcshReadWriteCloser,
@@ -1389,7 +1485,7 @@ func processPluginCmds(trcKubeDeploymentConfig **kube.TrcKubeConfig,
 	argsOrig []string,
 	deployArgLines []string,
 	configCount *int,
-) {
+) error {
 	trcshDriverConfig.DriverConfig.CoreConfig.Log.Printf("Processing control: %s\n", control)
 
 	switch control {
@@ -1430,7 +1526,7 @@ func processPluginCmds(trcKubeDeploymentConfig **kube.TrcKubeConfig,
 	case "trcconfig":
 		err := roleBasedRunner(region, trcshDriverConfig, control, argsOrig, deployArgLines, configCount)
 		if err != nil {
-			eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, fmt.Sprintf("trcconfig - unexpected failure: %s", err.Error()), 1)
+			return &deployStepError{exitCode: 1, err: fmt.Errorf("trcconfig - unexpected failure: %s", err.Error())}
 		}
 	case "trcplgtool":
 		// Utilize elevated CToken to perform certifications if asked.
@@ -1513,7 +1609,7 @@ func processPluginCmds(trcKubeDeploymentConfig **kube.TrcKubeConfig,
 		trcshDriverConfig.DriverConfig.CoreConfig.TokenCache = gTrcshConfig.TokenCache
 		err := roleBasedRunner(region, trcshDriverConfig, control, argsOrig, deployArgLines, configCount)
 		if err != nil {
-			eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, fmt.Sprintf("trcplgtool - unexpected failure: %s", err.Error()), 1)
+			return &deployStepError{exitCode: 1, err: fmt.Errorf("trcplgtool - unexpected failure: %s", err.Error())}
 		}
 
 	case "kubectl":
@@ -1532,21 +1628,40 @@ func processPluginCmds(trcKubeDeploymentConfig **kube.TrcKubeConfig,
 		(*(*trcKubeDeploymentConfig)).PipeOS = PipeOS
 
 		kubectlErrChan := make(chan error, 1)
+		kubectlTimeout := 15 * time.Second
+		kubeCtl := kube.KubeCtl
+		if len(os.Args) > 1 && kube.IsRolloutVerify(os.Args[1], os.Args[2:]) {
+			// Waits for the rollout, so allow for its timeout.
+			(*trcKubeDeploymentConfig).KubeDirective = kube.ParseTrcKubeDeployDirective((*trcKubeDeploymentConfig).KubeDirective, os.Args[1:])
+			kubectlTimeout += (*trcKubeDeploymentConfig).KubeDirective.Timeout
+			kubeCtl = kube.KubeRollout
+		} else if len(os.Args) > 1 && kube.IsTemplateApply(os.Args[1], os.Args[2:]) {
+			directive := kube.ParseTrcKubeDeployDirective((*trcKubeDeploymentConfig).KubeDirective, os.Args[1:])
+			(*trcKubeDeploymentConfig).KubeDirective = directive
+			if len(directive.Overlay) == 0 {
+				directive.Overlay = eUtils.GetEnvBasis(env)
+			}
+			if err := renderKubeTemplates(region, trcshDriverConfig, env, directive.Templates); err != nil {
+				return &deployStepError{exitCode: 1, err: fmt.Errorf("kubectl - template render failure: %s", err.Error())}
+			}
+			kubeCtl = kube.KubeApplyTemplates
+		}
 
 		go func(dConfig *config.DriverConfig) {
 			dConfig.CoreConfig.Log.Println("Executing kubectl")
-			kubectlErrChan <- kube.KubeCtl(*trcKubeDeploymentConfig, dConfig)
+			kubectlErrChan <- kubeCtl(*trcKubeDeploymentConfig, dConfig)
 		}(trcshDriverConfig.DriverConfig)
 
 		select {
-		case <-time.After(15 * time.Second):
-			eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, "Kubernetes connection stalled or timed out.  Possible kubernetes ip change", -1)
+		case <-time.After(kubectlTimeout):
+			return &deployStepError{exitCode: -1, err: errors.New("Kubernetes connection stalled or timed out.  Possible kubernetes ip change")}
 		case kubeErr := <-kubectlErrChan:
 			if kubeErr != nil {
-				eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, kubeErr.Error(), -1)
+				return &deployStepError{exitCode: -1, err: kubeErr}
 			}
 		}
 	}
+	return nil
 }
 
 func processDroneCmds(_ *kube.TrcKubeConfig,
@@ -1892,7 +2007,21 @@ collaboratorReRun:
 		}
 	}
 
-	deployArgLines := strings.Split(string(content), "\n")
+	deployScript, err := trcshscript.Parse(string(content))
+	if err != nil {
+		if *dronePtr {
+			trcshDriverConfig.DriverConfig.CoreConfig.Log.Printf("Invalid deployment script: %s\n", err.Error())
+			go func(dMesg string) {
+				trcshDriverConfig.DriverConfig.DeploymentCtlMessageChan <- dMesg
+				closeCleanupMessaging(trcshDriverConfig)
+			}(fmt.Sprintf("Invalid deployment script - %s\n", strings.ReplaceAll(err.Error(), ":", "-")))
+			content = nil
+			goto collaboratorReRun
+		}
+		eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, fmt.Sprintf("Invalid deployment script: %s\n", err.Error()), 1)
+	}
+	// Only non secret environment is available to scripts, as commands are logged.
+	deployScript.Env = []string{"TRC_ENV", "TRC_REGION", "AGENT_ENV", "HOSTNAME"}
 	configCount := strings.Count(string(content), "trcconfig") // Uses this to close result channel on last run.
 	argsOrig := os.Args
 
@@ -1900,11 +2029,7 @@ collaboratorReRun:
 	var onceKubeInit sync.Once
 	var PipeOS trcshio.TrcshReadWriteCloser
 
-	for _, deployPipeline := range deployArgLines {
-		deployPipeline = strings.TrimLeft(deployPipeline, " \t\r\n")
-		if strings.HasPrefix(deployPipeline, "#") || deployPipeline == "" {
-			continue
-		}
+	err = deployScript.Run(func(deployPipeline string) error {
 		// Print current process line.
 		if trcshDriverConfig.DriverConfig.CoreConfig.IsEditor {
 			trcshDriverConfig.DriverConfig.CoreConfig.Log.Println(deployPipeline)
@@ -1916,7 +2041,8 @@ collaboratorReRun:
 		}
 		deployPipeSplit := strings.Split(deployPipeline, "|")
 
-		if PipeOS, err = trcshDriverConfig.DriverConfig.MemFs.Create("io/STDIO"); err != nil {
+		var pipeErr error
+		if PipeOS, pipeErr = trcshDriverConfig.DriverConfig.MemFs.Create("io/STDIO"); pipeErr != nil {
 			eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, "Failure to open io stream.", -1)
 		}
 
@@ -1990,16 +2116,7 @@ collaboratorReRun:
 						// Critical agent setup error.
 						eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, "Critical agent setup error.", -1)
 					}
-					errMessage := err.Error()
-					errMessageFiltered := strings.ReplaceAll(errMessage, ":", "-")
-					deliverableMsg := fmt.Sprintf("%s encountered errors - %s\n", deployLine, errMessageFiltered)
-					go func(dMesg string) {
-						trcshDriverConfig.DriverConfig.DeploymentCtlMessageChan <- dMesg
-						closeCleanupMessaging(trcshDriverConfig)
-					}(deliverableMsg)
-
-					content = nil
-					goto collaboratorReRun
+					return &deployStepError{deployLine: deployLine, exitCode: 1, err: err}
 				}
 			} else {
 				trcshDriverConfig.DriverConfig.CoreConfig.Log.Println(deployLine)
@@ -2009,7 +2126,7 @@ collaboratorReRun:
 					region = trcshDriverConfig.DriverConfig.CoreConfig.Regions[0]
 				}
 
-				processPluginCmds(
+				err := processPluginCmds(
 					&trcKubeDeploymentConfig,
 					&onceKubeInit,
 					PipeOS,
@@ -2021,8 +2138,39 @@ collaboratorReRun:
 					strings.Split(deployLine, " "),
 					&configCount,
 				)
+				if err != nil {
+					return err
+				}
 			}
 		}
+		return nil
+	}, trcshDriverConfig.DriverConfig.CoreConfig.Log)
+	if err != nil {
+		// Failures the script didn't handle end the deployment.
+		var stepErr *deployStepError
+		if !errors.As(err, &stepErr) {
+			stepErr = &deployStepError{exitCode: 1, err: err}
+		}
+		if *dronePtr {
+			deployLine := stepErr.deployLine
+			if deployLine == "" {
+				var scriptErr *trcshscript.Error
+				if errors.As(err, &scriptErr) {
+					deployLine = scriptErr.Command
+				}
+			}
+			errMessage := stepErr.Error()
+			errMessageFiltered := strings.ReplaceAll(errMessage, ":", "-")
+			deliverableMsg := fmt.Sprintf("%s encountered errors - %s\n", deployLine, errMessageFiltered)
+			go func(dMesg string) {
+				trcshDriverConfig.DriverConfig.DeploymentCtlMessageChan <- dMesg
+				closeCleanupMessaging(trcshDriverConfig)
+			}(deliverableMsg)
+
+			content = nil
+			goto collaboratorReRun
+		}
+		eUtils.LogSyncAndExit(trcshDriverConfig.DriverConfig.CoreConfig.Log, stepErr.Error(), stepErr.exitCode)
 	}
 	if *dronePtr && !gTrcshConfig.IsShellRunner {
 		closeCleanupMessaging(trcshDriverConfig)
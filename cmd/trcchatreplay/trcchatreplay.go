@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/trimble-oss/tierceron-core/v2/buildopts/kernelopts"
+	kernelloadopts "github.com/trimble-oss/tierceron-core/v2/buildopts/kernelopts"
+	"github.com/trimble-oss/tierceron/atrium/buildopts/flowcoreopts"
+	flowcoreloadopts "github.com/trimble-oss/tierceron/atrium/buildopts/flowcoreopts"
+	"github.com/trimble-oss/tierceron/atrium/buildopts/flowopts"
+	flowloadopts "github.com/trimble-oss/tierceron/atrium/buildopts/flowopts"
+	buildloadopts "github.com/trimble-oss/tierceron/buildopts"
+	coreloadopts "github.com/trimble-oss/tierceron/buildopts/coreopts"
+	"github.com/trimble-oss/tierceron/buildopts/pluginopts"
+	pluginloadopts "github.com/trimble-oss/tierceron/buildopts/pluginopts"
+
+	"github.com/trimble-oss/tierceron-core/v2/core/coreconfig"
+	"github.com/trimble-oss/tierceron/buildopts"
+	"github.com/trimble-oss/tierceron/buildopts/coreopts"
+	"github.com/trimble-oss/tierceron/pkg/core/util/hive"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+// Replays a kernel chat capture (TRCSHK_CHAT_TRACE_FILE) against stubbed
+// plugins and reports any message delivered differently than captured.
+// Exits non zero on differences so it can back regression tests.
+func main() {
+	flowopts.NewOptionsBuilder(flowloadopts.LoadOptions())
+	flowcoreopts.NewOptionsBuilder(flowcoreloadopts.LoadOptions())
+	kernelopts.NewOptionsBuilder(kernelloadopts.LoadOptions())
+	pluginopts.NewOptionsBuilder(pluginloadopts.LoadOptions())
+	buildopts.NewOptionsBuilder(buildloadopts.LoadOptions())
+	coreopts.NewOptionsBuilder(coreloadopts.LoadOptions())
+	fmt.Fprintln(os.Stderr, "Version: "+"1.0")
+	flagset := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flagset.Usage = func() {
+		fmt.Fprintf(flagset.Output(), "Usage of %s:\n", os.Args[0])
+		flagset.PrintDefaults()
+	}
+	capturePtr := flagset.String("capture", "", "Chat trace capture to replay")
+	settlePtr := flagset.Duration("settle", 3*time.Second, "Time to wait for deliveries after the last message")
+	verbosePtr := flagset.Bool("verbose", false, "Log kernel chat handling")
+	flagset.Parse(os.Args[1:])
+
+	if *capturePtr == "" {
+		flagset.Usage()
+		os.Exit(1)
+	}
+	captureFile, err := os.Open(*capturePtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to open capture: %v\n", err)
+		os.Exit(1)
+	}
+	records, err := hive.ReadChatTrace(captureFile)
+	captureFile.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to read capture %s: %v\n", *capturePtr, err)
+		os.Exit(1)
+	}
+
+	logger := log.New(os.Stderr, "[trcchatreplay]", log.LstdFlags)
+	if !*verbosePtr {
+		logger.SetOutput(io.Discard)
+	}
+	driverConfig := &config.DriverConfig{
+		CoreConfig: &coreconfig.CoreConfig{
+			ExitOnFailure: true,
+			Log:           logger,
+		},
+	}
+	result, err := hive.ReplayChatTrace(driverConfig, records, *settlePtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Replay failed: %v\n", err)
+		os.Exit(1)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+	if !result.Ok() {
+		os.Exit(1)
+	}
+}
@@ -1,16 +1,19 @@
 package trcconfigbase
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	cmap "github.com/orcaman/concurrent-map/v2"
@@ -72,10 +75,139 @@ var STARTDIR_DEFAULT string
 
 var ENDDIR_DEFAULT = "."
 
+// writePackagedOutput writes the templates rendered for -outputFormat.  Output
+// only goes to stdout when asked for with -outputFile=-.
+func writePackagedOutput(driverConfig *config.DriverConfig, configCtx *config.ConfigContext, outputFormat string, outputFile string, namespace string) error {
+	rendered := configCtx.RenderedTemplates()
+	if len(rendered) == 0 {
+		return errors.New("no templates were rendered for -outputFormat")
+	}
+	packaged, err := vcutils.RenderOutput(outputFormat, namespace, rendered)
+	if err != nil {
+		return err
+	}
+	if outputFile == "-" {
+		if driverConfig.IsShellCommand {
+			_, err = outWriter.Write(packaged)
+		} else {
+			_, err = os.Stdout.Write(packaged)
+		}
+		return err
+	}
+	if outputFile == "" {
+		outputFile = filepath.Join(driverConfig.EndDir, vcutils.DefaultOutputFileName(outputFormat))
+	}
+	if driverConfig.OutputMemCache {
+		driverConfig.MemFs.WriteToMemFile(driverConfig.CoreConfig, &packaged, outputFile)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFile), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, packaged, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintln(outWriter, "Wrote "+outputFormat+" output to "+outputFile)
+	return nil
+}
+
 func PrintVersion() {
 	fmt.Fprintln(outWriter, "Version: "+"1.33")
 }
 
+// configFlags are the flags CommonMain defines.
+type configFlags struct {
+	env            *string
+	token          *string
+	addr           *string
+	startDir       *string
+	endDir         *string
+	outputDir      *string
+	secretMode     *bool
+	servicesWanted *string
+	sw             *string
+	wantCerts      *bool
+	certDestPath   *string
+	keyStore       *string
+	logFile        *string
+	ping           *bool
+	zc             *bool
+	fileFilter     *string
+	templateInfo   *bool
+	insecure       *bool
+	noVault        *bool
+	outputFormat   *string
+	outputFile     *string
+	namespace      *string
+	watch          *bool
+	watchInterval  *time.Duration
+	reloadCmd      *string
+	reloadPid      *int
+	reloadSignal   *string
+	strict         *bool
+}
+
+// defineFlags defines CommonMain's flags on flagset.  standalone is true if
+// CommonMain created flagset, a caller's flagset already has the rest of the
+// connection flags.
+func defineFlags(flagset *flag.FlagSet, standalone bool) *configFlags {
+	flags := &configFlags{}
+	if standalone {
+		flags.env = flagset.String("env", "", "Environment to configure")
+		flagset.String("addr", "", "API endpoint for the vault")
+		flagset.String("token", "", "Vault access token")
+		flagset.String("secretID", "", "Secret for app role ID")
+		flagset.String("region", "", "Region to be processed") // If this is blank -> use context otherwise override context.
+		flagset.String("appRoleID", "", "Public app role ID")
+		flagset.String("tokenName", "", "Token name used by this"+coreopts.BuildOptions.GetFolderPrefix(nil)+"config to access the vault")
+	} else {
+		flags.token = flagset.String("token", "", "Vault access token")
+		flags.addr = flagset.String("addr", "", "API endpoint for the vault")
+	}
+	flags.startDir = flagset.String("startDir", STARTDIR_DEFAULT, "Template directory")
+	flags.endDir = flagset.String("endDir", ENDDIR_DEFAULT, "Directory to put configured templates into")
+	flags.outputDir = flagset.String("outputDir", "", "Output directory for file system (specify to enable file system output)")
+	flags.secretMode = flagset.Bool("secretMode", true, "Only override secret values in templates?")
+	flags.servicesWanted = flagset.String("servicesWanted", "", "Services to pull template values for, in the form 'service1,service2' (defaults to all services)")
+	flags.sw = flagset.String("sw", "", "Alias for -servicesWanted")
+	flags.wantCerts = flagset.Bool("certs", false, "Pull certificates into directory specified by endDirPtr")
+	flags.certDestPath = flagset.String("certDestPath", "", "Override templated cert destination paths. Format of tmplFileName:certDirPath/file.pfx")
+	flags.keyStore = flagset.String("keystore", "", "Put certificates into this keystore file.")
+	flags.logFile = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"config.log", "Output path for log file")
+	flags.ping = flagset.Bool("ping", false, "Ping vault.")
+	flags.zc = flagset.Bool("zc", false, "Zero config (no configuration option).")
+	flags.fileFilter = flagset.String("filter", "", "Filter files for diff")
+	flags.templateInfo = flagset.Bool("templateInfo", false, "Version information about templates")
+	flags.insecure = flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
+	flags.noVault = flagset.Bool("novault", false, "Don't pull configuration data from vault.")
+	flags.outputFormat = flagset.String("outputFormat", "", "Package rendered templates as "+strings.Join(vcutils.OutputFormats, ", ")+" instead of writing individual files")
+	flags.outputFile = flagset.String("outputFile", "", "File to write -outputFormat to (defaults to a file in endDir).  Use - to print to stdout, secrets included.")
+	flags.namespace = flagset.String("namespace", "", "Kubernetes namespace for -outputFormat secret or configmap")
+	flags.watch = flagset.Bool("watch", false, "Keep running and reconfigure templates when their vault values change.")
+	flags.watchInterval = flagset.Duration("watchInterval", time.Minute, "How often -watch checks vault for changed values")
+	flags.reloadCmd = flagset.String("reloadCmd", "", "Command -watch runs after reconfiguring templates")
+	flags.reloadPid = flagset.Int("reloadPid", 0, "Process -watch signals after reconfiguring templates")
+	flags.reloadSignal = flagset.String("reloadSignal", "HUP", "Signal sent to -reloadPid")
+	flags.strict = flagset.Bool("strict", false, "Fail if a template references keys that have no value (missingkey=error).")
+	return flags
+}
+
+// defineDiffFlags defines the flags CommonMain only supports outside of trcsh.
+func defineDiffFlags(flagset *flag.FlagSet) (*bool, *bool) {
+	diffPtr := flagset.Bool("diff", false, "Diff files")
+	versionInfoPtr := flagset.Bool("versions", false, "Version information about values")
+	return diffPtr, versionInfoPtr
+}
+
+// Flags returns the flags CommonMain defines, standalone or on a caller's
+// flagset, e.g. to check trcsh scripts.
+func Flags(standalone bool) *flag.FlagSet {
+	flagset := flag.NewFlagSet("trcconfig", flag.ContinueOnError)
+	defineFlags(flagset, standalone)
+	defineDiffFlags(flagset)
+	return flagset
+}
+
 func CommonMain(envDefaultPtr *string,
 	envCtxPtr *string,
 	tokenNamePtr *string,
@@ -96,10 +228,7 @@ func CommonMain(envDefaultPtr *string,
 		FileSysIndex:  -1,
 		ConfigWg:      sync.WaitGroup{},
 	}
-	var envPtr *string = nil
-	var tokenPtr *string = nil
-	var addrPtr *string = nil
-
+	standalone := flagset == nil
 	if flagset == nil {
 		if driverConfig == nil || driverConfig.CoreConfig == nil || !driverConfig.CoreConfig.IsEditor {
 			PrintVersion() // For trcsh
@@ -118,34 +247,18 @@ func CommonMain(envDefaultPtr *string,
 				fmt.Fprintf(flagset.Output(), "  -ofs\n\t\tOpen file selector to choose output directory (trcshz only)\n")
 			}
 		}
-
-		envPtr = flagset.String("env", "", "Environment to configure")
-		flagset.String("addr", "", "API endpoint for the vault")
-		flagset.String("token", "", "Vault access token")
-		flagset.String("secretID", "", "Secret for app role ID")
-		flagset.String("region", "", "Region to be processed") // If this is blank -> use context otherwise override context.
-		flagset.String("appRoleID", "", "Public app role ID")
-		flagset.String("tokenName", "", "Token name used by this"+coreopts.BuildOptions.GetFolderPrefix(nil)+"config to access the vault")
-	} else {
-		tokenPtr = flagset.String("token", "", "Vault access token")
-		addrPtr = flagset.String("addr", "", "API endpoint for the vault")
-	}
-	startDirPtr := flagset.String("startDir", STARTDIR_DEFAULT, "Template directory")
-	endDirPtr := flagset.String("endDir", ENDDIR_DEFAULT, "Directory to put configured templates into")
-	outputDirPtr := flagset.String("outputDir", "", "Output directory for file system (specify to enable file system output)")
-	secretMode := flagset.Bool("secretMode", true, "Only override secret values in templates?")
-	servicesWanted := flagset.String("servicesWanted", "", "Services to pull template values for, in the form 'service1,service2' (defaults to all services)")
-	swPtr := flagset.String("sw", "", "Alias for -servicesWanted")
-	wantCertsPtr := flagset.Bool("certs", false, "Pull certificates into directory specified by endDirPtr")
-	certDestPathPtr := flagset.String("certDestPath", "", "Override templated cert destination paths. Format of tmplFileName:certDirPath/file.pfx")
-	keyStorePtr := flagset.String("keystore", "", "Put certificates into this keystore file.")
-	logFilePtr := flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"config.log", "Output path for log file")
-	pingPtr := flagset.Bool("ping", false, "Ping vault.")
-	zcPtr := flagset.Bool("zc", false, "Zero config (no configuration option).")
-	fileFilterPtr := flagset.String("filter", "", "Filter files for diff")
-	templateInfoPtr := flagset.Bool("templateInfo", false, "Version information about templates")
-	insecurePtr := flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
-	noVaultPtr := flagset.Bool("novault", false, "Don't pull configuration data from vault.")
+	}
+	flags := defineFlags(flagset, standalone)
+	envPtr, tokenPtr, addrPtr := flags.env, flags.token, flags.addr
+	startDirPtr, endDirPtr, outputDirPtr := flags.startDir, flags.endDir, flags.outputDir
+	secretMode, servicesWanted, swPtr := flags.secretMode, flags.servicesWanted, flags.sw
+	wantCertsPtr, certDestPathPtr, keyStorePtr := flags.wantCerts, flags.certDestPath, flags.keyStore
+	logFilePtr, pingPtr, zcPtr, fileFilterPtr := flags.logFile, flags.ping, flags.zc, flags.fileFilter
+	templateInfoPtr, insecurePtr, noVaultPtr := flags.templateInfo, flags.insecure, flags.noVault
+	outputFormatPtr, outputFilePtr, namespacePtr := flags.outputFormat, flags.outputFile, flags.namespace
+	watchPtr, watchIntervalPtr := flags.watch, flags.watchInterval
+	reloadCmdPtr, reloadPidPtr, reloadSignalPtr := flags.reloadCmd, flags.reloadPid, flags.reloadSignal
+	strictPtr := flags.strict
 
 	var versionInfoPtr *bool
 	var diffPtr *bool
@@ -189,8 +302,7 @@ func CommonMain(envDefaultPtr *string,
 				return fmt.Errorf("wrong flag syntax: %s", s)
 			}
 		}
-		diffPtr = flagset.Bool("diff", false, "Diff files")
-		versionInfoPtr = flagset.Bool("versions", false, "Version information about values")
+		diffPtr, versionInfoPtr = defineDiffFlags(flagset)
 		parseErr := flagset.Parse(argLines[1:])
 		// If help flag was used, print usage and return early
 		if parseErr == flag.ErrHelp {
@@ -216,6 +328,8 @@ func CommonMain(envDefaultPtr *string,
 				return nil
 			} else if args == "-certs" {
 				driverConfig.CoreConfig.WantCerts = true
+			} else if args == "-strict" {
+				*strictPtr = true
 			} else if strings.HasPrefix(args, "-keystore") {
 				storeArgs := strings.Split(args, "=")
 				if len(storeArgs) > 1 {
@@ -250,6 +364,21 @@ func CommonMain(envDefaultPtr *string,
 					}
 					*envPtr = envArgs[1]
 				}
+			} else if strings.HasPrefix(args, "-outputFormat") {
+				outputFormatArg := strings.Split(args, "=")
+				if len(outputFormatArg) > 1 {
+					*outputFormatPtr = outputFormatArg[1]
+				}
+			} else if strings.HasPrefix(args, "-outputFile") {
+				outputFileArg := strings.Split(args, "=")
+				if len(outputFileArg) > 1 {
+					*outputFilePtr = outputFileArg[1]
+				}
+			} else if strings.HasPrefix(args, "-namespace") {
+				namespaceArg := strings.Split(args, "=")
+				if len(namespaceArg) > 1 {
+					*namespacePtr = namespaceArg[1]
+				}
 			} else if strings.HasPrefix(args, "-outputDir") {
 				outputDirArg := strings.Split(args, "=")
 				if len(outputDirArg) > 1 {
@@ -385,6 +514,24 @@ func CommonMain(envDefaultPtr *string,
 	} else if *versionInfoPtr && *templateInfoPtr {
 		fmt.Fprintln(outWriter, "Cannot use -templateInfo flag and -versionInfo flag together")
 		return errors.New("cannot use -templateInfo flag and -versionInfo flag together")
+	} else if *outputFormatPtr != "" && !slices.Contains(vcutils.OutputFormats, *outputFormatPtr) {
+		fmt.Fprintln(outWriter, "Unsupported -outputFormat, expected one of: "+strings.Join(vcutils.OutputFormats, ", "))
+		return fmt.Errorf("unsupported output format: %s", *outputFormatPtr)
+	} else if *outputFormatPtr != "" && (*diffPtr || *wantCertsPtr || *templateInfoPtr || *versionInfoPtr) {
+		fmt.Fprintln(outWriter, "Cannot use -outputFormat flag with -diff, -certs, -templateInfo or -versionInfo")
+		return errors.New("cannot use -outputFormat flag with -diff, -certs, -templateInfo or -versionInfo")
+	} else if *watchPtr && (isShell || isDrone || kernelopts.BuildOptions.IsKernel()) {
+		fmt.Fprintln(outWriter, "The -watch flag is not available from a shell or kernel")
+		return errors.New("the -watch flag is not available from a shell or kernel")
+	} else if *watchPtr && (*diffPtr || *wantCertsPtr || *templateInfoPtr || *versionInfoPtr || *noVaultPtr || *outputFormatPtr != "") {
+		fmt.Fprintln(outWriter, "Cannot use -watch flag with -diff, -certs, -templateInfo, -versionInfo, -novault or -outputFormat")
+		return errors.New("cannot use -watch flag with -diff, -certs, -templateInfo, -versionInfo, -novault or -outputFormat")
+	} else if (*reloadCmdPtr != "" || *reloadPidPtr != 0) && !*watchPtr {
+		fmt.Fprintln(outWriter, "Cannot use -reloadCmd or -reloadPid flag without including -watch flag")
+		return errors.New("cannot use -reloadCmd or -reloadPid flag without including -watch flag")
+	} else if (*outputFilePtr != "" || *namespacePtr != "") && *outputFormatPtr == "" {
+		fmt.Fprintln(outWriter, "Cannot use -outputFile or -namespace flag without including -outputFormat flag")
+		return errors.New("cannot use -outputFile or -namespace flag without including -outputFormat flag")
 	} else if *diffPtr {
 		if strings.ContainsAny(*envPtr, ",") { // Multiple environments
 			*envPtr = strings.ReplaceAll(*envPtr, "latest", "0")
@@ -549,6 +696,7 @@ func CommonMain(envDefaultPtr *string,
 
 	// channel receiver
 	go receiver(configCtx)
+	var watchConfig *config.DriverConfig
 	if *diffPtr && !driverConfigBase.CoreConfig.IsShell {
 		for _, env := range configCtx.EnvSlice {
 			envVersion := eUtils.SplitEnv(env)
@@ -604,6 +752,7 @@ func CommonMain(envDefaultPtr *string,
 				OutputFileSystemDir: driverConfig.OutputFileSystemDir,
 				CertPathOverrides:   certOverrides,
 				Diff:                *diffPtr,
+				Strict:              *strictPtr,
 				Update:              messenger,
 				FileFilter:          fileFilterSlice,
 			}
@@ -657,8 +806,10 @@ func CommonMain(envDefaultPtr *string,
 			OutputMemCache:      driverConfigBase.OutputMemCache,
 			MemFs:               driverConfigBase.MemFs,
 			OutputFileSystemDir: driverConfig.OutputFileSystemDir,
+			OutputFormat:        *outputFormatPtr,
 			CertPathOverrides:   certOverrides,
 			Diff:                *diffPtr,
+			Strict:              *strictPtr,
 			FileFilter:          fileFilterSlice,
 			VersionInfo:         eUtils.VersionHelper,
 		}
@@ -678,6 +829,7 @@ func CommonMain(envDefaultPtr *string,
 		if driverConfigBase.DeploymentConfig != nil {
 			dConfig.DeploymentConfig = driverConfigBase.DeploymentConfig
 		}
+		watchConfig = &dConfig
 		configCtx.ConfigWg.Add(1)
 		go func(dc *config.DriverConfig) {
 			defer configCtx.ConfigWg.Done()
@@ -701,6 +853,33 @@ func CommonMain(envDefaultPtr *string,
 			break
 		}
 	}
+	if templateFailures := configCtx.TemplateFailures(); *strictPtr && len(templateFailures) > 0 {
+		for _, templateFailure := range templateFailures {
+			fmt.Fprintln(outWriter, templateFailure)
+		}
+		return fmt.Errorf("strict: %d template(s) could not be configured", len(templateFailures))
+	}
+	if *outputFormatPtr != "" {
+		if err := writePackagedOutput(driverConfigBase, configCtx, *outputFormatPtr, *outputFilePtr, *namespacePtr); err != nil {
+			fmt.Fprintln(outWriter, err.Error())
+			return err
+		}
+	}
+	if *watchPtr && watchConfig != nil {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Fprintf(outWriter, "Watching for changed values every %s\n", *watchIntervalPtr)
+		err := vcutils.WatchConfigs(ctx, watchConfig, &vcutils.WatchOptions{
+			Interval:     *watchIntervalPtr,
+			ReloadCmd:    *reloadCmdPtr,
+			ReloadPid:    *reloadPidPtr,
+			ReloadSignal: *reloadSignalPtr,
+		})
+		if err != nil {
+			fmt.Fprintln(outWriter, err.Error())
+			return err
+		}
+	}
 	if *diffPtr { // Diff if needed
 		if configCtx.FileSysIndex != -1 {
 			configCtx.EnvSlice = append(configCtx.EnvSlice, "filesys")
@@ -395,6 +395,9 @@ func GenerateConfigsFromVault(ctx config.ProcessContext, configCtx *config.Confi
 					if ctErr != nil {
 						if !strings.Contains(ctErr.Error(), "Missing .certData") {
 							eUtils.LogErrorObject(driverConfig.CoreConfig, ctErr, false)
+							if driverConfig.Strict {
+								configCtx.AddTemplateFailure(endPaths[i] + ": " + ctErr.Error())
+							}
 							goto wait
 						}
 					}
@@ -425,6 +428,9 @@ func GenerateConfigsFromVault(ctx config.ProcessContext, configCtx *config.Confi
 						} else {
 							driverConfig.Update(configCtx, &configuredTemplate, driverConfig.CoreConfig.Env+"||"+endPaths[i])
 						}
+					} else if driverConfig.OutputFormat != "" {
+						renderedFile := strings.TrimPrefix(strings.TrimPrefix(endPaths[i], driverConfig.EndDir), "/")
+						configCtx.AddRenderedTemplate(service, renderedFile, substituteBuildVars(driverConfig, configuredTemplate))
 					} else {
 						writeToFile(driverConfig, configuredTemplate, endPaths[i])
 					}
@@ -459,6 +465,9 @@ func GenerateConfigsFromVault(ctx config.ProcessContext, configCtx *config.Confi
 					if ctErr != nil {
 						if !strings.Contains(ctErr.Error(), "Missing .certData") {
 							eUtils.LogErrorObject(driverConfig.CoreConfig, ctErr, false)
+							if driverConfig.Strict {
+								configCtx.AddTemplateFailure(endPaths[i] + ": " + ctErr.Error())
+							}
 							goto wait
 						}
 					}
@@ -483,6 +492,9 @@ func GenerateConfigsFromVault(ctx config.ProcessContext, configCtx *config.Confi
 						} else {
 							driverConfig.Update(configCtx, &configuredTemplate, driverConfig.CoreConfig.Env+"||"+endPaths[i])
 						}
+					} else if driverConfig.OutputFormat != "" {
+						renderedFile := strings.TrimPrefix(strings.TrimPrefix(endPaths[i], driverConfig.EndDir), "/")
+						configCtx.AddRenderedTemplate(service, renderedFile, substituteBuildVars(driverConfig, configuredTemplate))
 					} else {
 						writeToFile(driverConfig, configuredTemplate, endPaths[i])
 					}
@@ -544,7 +556,8 @@ func GenerateConfigsFromVault(ctx config.ProcessContext, configCtx *config.Confi
 	return nil, nil
 }
 
-func writeToFile(driverConfig *config.DriverConfig, data string, path string) {
+// substituteBuildVars replaces the ${TAG} and ${RELEASE} placeholders from the environment.
+func substituteBuildVars(driverConfig *config.DriverConfig, data string) string {
 	if strings.Contains(data, "${TAG}") {
 		tag := os.Getenv("TRCENV_TAG")
 		if len(tag) > 0 {
@@ -563,8 +576,11 @@ func writeToFile(driverConfig *config.DriverConfig, data string, path string) {
 		release := os.Getenv("RELEASE")
 		data = strings.Replace(data, "${RELEASE}", release, -1)
 	}
+	return data
+}
 
-	byteData := []byte(data)
+func writeToFile(driverConfig *config.DriverConfig, data string, path string) {
+	byteData := []byte(substituteBuildVars(driverConfig, data))
 	// Ensure directory has been created
 	var newFile *os.File
 
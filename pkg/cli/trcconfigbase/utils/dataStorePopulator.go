@@ -17,6 +17,21 @@ type ConfigDataStore struct {
 	Regions []string
 }
 
+// pathHasWantedService returns true if the template path belongs to one of servicesWanted.
+func pathHasWantedService(path string, servicesWanted []string) bool {
+	pathParts := strings.Split(path, "/")
+	for i := range servicesWanted {
+		if strings.HasSuffix(path, servicesWanted[i]) {
+			return true
+		}
+		splitService := strings.Split(servicesWanted[i], ".")
+		if len(pathParts) >= 3 && (pathParts[2] == servicesWanted[i] || splitService[0] == pathParts[2] || (len(pathParts) >= 4 && pathParts[3] == servicesWanted[i])) {
+			return true
+		}
+	}
+	return false
+}
+
 func (cds *ConfigDataStore) Init(config *coreconfig.CoreConfig,
 	mod *helperkv.Modifier,
 	secretMode bool,
@@ -76,20 +91,7 @@ func (cds *ConfigDataStore) Init(config *coreconfig.CoreConfig,
 	}
 	for _, path := range dataPaths {
 		// for each path, read the secrets there
-		pathParts := strings.Split(path, "/")
-		foundWantedService := false
-		for i := range servicesWanted {
-			if strings.HasSuffix(path, servicesWanted[i]) {
-				foundWantedService = true
-				break
-			}
-			splitService := strings.Split(servicesWanted[i], ".")
-			if len(pathParts) >= 2 && (pathParts[2] == servicesWanted[i] || splitService[0] == pathParts[2] || (len(pathParts) >= 4 && pathParts[3] == servicesWanted[i])) {
-				foundWantedService = true
-				break
-			}
-		}
-		if !foundWantedService {
+		if !pathHasWantedService(path, servicesWanted) {
 			continue
 		}
 
@@ -293,7 +295,7 @@ func (cds *ConfigDataStore) InitTemplateVersionData(config *coreconfig.CoreConfi
 		foundWantedService := false
 		for i := range servicesWanted {
 			splitService := strings.Split(servicesWanted[i], ".")
-			if len(pathParts) >= 2 && (pathParts[2] == servicesWanted[i] || splitService[0] == pathParts[2] || (len(pathParts) >= 4 && pathParts[3] == servicesWanted[i])) {
+			if len(pathParts) >= 3 && (pathParts[2] == servicesWanted[i] || splitService[0] == pathParts[2] || (len(pathParts) >= 4 && pathParts[3] == servicesWanted[i])) {
 				foundWantedService = true
 				break
 			}
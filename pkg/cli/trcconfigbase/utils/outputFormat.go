@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats for trcconfig -outputFormat.  Templates are written to
+// endDir as individual files unless one of these is chosen, in which case
+// the rendered templates are packaged into a single document.
+const (
+	OutputFormatFiles     = ""
+	OutputFormatSecret    = "secret"    // Kubernetes Secret per service, base64 data
+	OutputFormatConfigMap = "configmap" // Kubernetes ConfigMap per service
+	OutputFormatDotenv    = "dotenv"    // SERVICE_FILE="contents" lines
+	OutputFormatJson      = "json"      // {"service": {"file": "contents"}}
+)
+
+// OutputFormats lists the supported packaged output formats.
+var OutputFormats = []string{OutputFormatSecret, OutputFormatConfigMap, OutputFormatDotenv, OutputFormatJson}
+
+// DefaultOutputFileName returns the file written in endDir for format.
+func DefaultOutputFileName(format string) string {
+	switch format {
+	case OutputFormatSecret:
+		return "secret.yaml"
+	case OutputFormatConfigMap:
+		return "configmap.yaml"
+	case OutputFormatDotenv:
+		return ".env"
+	case OutputFormatJson:
+		return "config.json"
+	}
+	return ""
+}
+
+var (
+	kubeNameInvalid = regexp.MustCompile(`[^a-z0-9.-]+`)
+	kubeKeyInvalid  = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+	envNameInvalid  = regexp.MustCompile(`[^A-Z0-9_]+`)
+)
+
+type kubeMetadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+type kubeObject struct {
+	ApiVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   kubeMetadata      `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// kubeName converts a service name to a valid object name.
+func kubeName(service string) string {
+	name := strings.Trim(kubeNameInvalid.ReplaceAllString(strings.ToLower(service), "-"), "-.")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return name
+}
+
+// renderedKeys maps each rendered file of a service to its key, the file's
+// base name unless two files share it.  Files whose keys are the same once
+// sanitized are an error.
+func renderedKeys(files map[string]string) (map[string]string, error) {
+	baseCount := map[string]int{}
+	sortedFiles := make([]string, 0, len(files))
+	for file := range files {
+		baseCount[path.Base(file)]++
+		sortedFiles = append(sortedFiles, file)
+	}
+	sort.Strings(sortedFiles)
+	keys := map[string]string{}
+	keyFiles := map[string]string{}
+	for _, file := range sortedFiles {
+		key := path.Base(file)
+		if baseCount[key] > 1 {
+			key = strings.ReplaceAll(strings.TrimPrefix(file, "/"), "/", "_")
+		}
+		key = kubeKeyInvalid.ReplaceAllString(key, "_")
+		if other, ok := keyFiles[key]; ok {
+			return nil, fmt.Errorf("%s and %s both render to key %s", other, file, key)
+		}
+		keyFiles[key] = file
+		keys[file] = key
+	}
+	return keys, nil
+}
+
+func sortedFiles(keys map[string]string) []string {
+	files := make([]string, 0, len(keys))
+	for file := range keys {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func sortedServices(rendered map[string]map[string]string) []string {
+	services := make([]string, 0, len(rendered))
+	for service := range rendered {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// RenderOutput packages rendered templates, by service and then by file path
+// relative to endDir, into format.
+func RenderOutput(format string, namespace string, rendered map[string]map[string]string) ([]byte, error) {
+	var out bytes.Buffer
+	switch format {
+	case OutputFormatSecret, OutputFormatConfigMap:
+		objectServices := map[string]string{}
+		for i, service := range sortedServices(rendered) {
+			if other, ok := objectServices[kubeName(service)]; ok {
+				return nil, fmt.Errorf("services %s and %s both render to object %s", other, service, kubeName(service))
+			}
+			objectServices[kubeName(service)] = service
+			object := kubeObject{
+				ApiVersion: "v1",
+				Kind:       "ConfigMap",
+				Metadata: kubeMetadata{
+					Name:      kubeName(service),
+					Namespace: namespace,
+					Labels:    map[string]string{"app.kubernetes.io/managed-by": "trcconfig"},
+				},
+				Data: map[string]string{},
+			}
+			if format == OutputFormatSecret {
+				object.Kind = "Secret"
+				object.Type = "Opaque"
+			}
+			keys, err := renderedKeys(rendered[service])
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", service, err)
+			}
+			for file, data := range rendered[service] {
+				if format == OutputFormatSecret {
+					data = base64.StdEncoding.EncodeToString([]byte(data))
+				}
+				object.Data[keys[file]] = data
+			}
+			objectYaml, err := yaml.Marshal(&object)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				out.WriteString("---\n")
+			}
+			out.Write(objectYaml)
+		}
+	case OutputFormatDotenv:
+		nameSources := map[string]string{}
+		for _, service := range sortedServices(rendered) {
+			keys, err := renderedKeys(rendered[service])
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", service, err)
+			}
+			names := map[string]string{}
+			for _, file := range sortedFiles(keys) {
+				name := envNameInvalid.ReplaceAllString(strings.ToUpper(service+"_"+keys[file]), "_")
+				if other, ok := nameSources[name]; ok {
+					return nil, fmt.Errorf("%s and %s/%s both render to variable %s", other, service, file, name)
+				}
+				nameSources[name] = service + "/" + file
+				names[name] = file
+			}
+			sortedNames := make([]string, 0, len(names))
+			for name := range names {
+				sortedNames = append(sortedNames, name)
+			}
+			sort.Strings(sortedNames)
+			for _, name := range sortedNames {
+				var quoted bytes.Buffer
+				encoder := json.NewEncoder(&quoted)
+				encoder.SetEscapeHTML(false)
+				if err := encoder.Encode(rendered[service][names[name]]); err != nil {
+					return nil, err
+				}
+				fmt.Fprintf(&out, "%s=%s", name, quoted.Bytes())
+			}
+		}
+	case OutputFormatJson:
+		document := map[string]map[string]string{}
+		for service, files := range rendered {
+			keys, err := renderedKeys(files)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", service, err)
+			}
+			document[service] = map[string]string{}
+			for file, data := range files {
+				document[service][keys[file]] = data
+			}
+		}
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, expected one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+	return out.Bytes(), nil
+}
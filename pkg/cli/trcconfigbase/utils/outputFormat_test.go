@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderOutput(t *testing.T) {
+	rendered := map[string]map[string]string{
+		"Hello_Service": {
+			"config.yml":      "greeting: hello\n",
+			"a/settings.json": `{"a": 1}`,
+			"b/settings.json": `{"b": 2}`,
+		},
+	}
+
+	secret, err := RenderOutput(OutputFormatSecret, "apps", rendered)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{"kind: Secret", "name: hello-service", "namespace: apps", "config.yml: Z3JlZXRpbmc6IGhlbGxvCg==", "a_settings.json:", "b_settings.json:"} {
+		if !strings.Contains(string(secret), expected) {
+			t.Fatalf("Expected %q in:\n%s", expected, secret)
+		}
+	}
+
+	dotenv, err := RenderOutput(OutputFormatDotenv, "", rendered)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(dotenv), `HELLO_SERVICE_CONFIG_YML="greeting: hello\n"`) {
+		t.Fatalf("Unexpected dotenv output:\n%s", dotenv)
+	}
+
+	if _, err := RenderOutput("xml", "", rendered); err == nil {
+		t.Fatalf("Expected unsupported format error")
+	}
+
+	for format, colliding := range map[string]map[string]map[string]string{
+		OutputFormatSecret: {"Hello": {"a b.yml": "a", "a_b.yml": "b"}},
+		OutputFormatDotenv: {"Hello": {"a b.yml": "a", "a-b.yml": "b"}},
+		OutputFormatJson:   {"Hello": {"a b.yml": "a", "a_b.yml": "b"}},
+	} {
+		_, err := RenderOutput(format, "", colliding)
+		if err == nil {
+			t.Fatalf("Expected %s key collision error", format)
+		}
+		for file := range colliding["Hello"] {
+			if !strings.Contains(err.Error(), file) {
+				t.Fatalf("Expected %s in %s error: %v", file, format, err)
+			}
+		}
+	}
+	if _, err := RenderOutput(OutputFormatConfigMap, "", map[string]map[string]string{"Hello_Service": {"a": "a"}, "hello service": {"b": "b"}}); err == nil {
+		t.Fatalf("Expected object name collision error")
+	}
+}
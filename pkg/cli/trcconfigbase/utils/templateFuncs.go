@@ -0,0 +1,348 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/trimble-oss/tierceron/buildopts/coreopts"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+
+	"gopkg.in/yaml.v2"
+)
+
+const maxIncludeDepth = 16
+
+// TemplateFuncMap returns the functions available to every config template.
+//
+//	b64enc VALUE             base64 encode
+//	b64dec VALUE             base64 decode
+//	toJson VALUE             JSON encoding of a value, map or list
+//	toYaml VALUE             YAML encoding of a value, map or list
+//	quote VALUE              double quoted string, valid in both JSON and YAML
+//	squote VALUE             single quoted YAML string
+//	default DEFAULT VALUE    DEFAULT if VALUE is missing or empty
+//	required MESSAGE VALUE   VALUE, or fails the template with MESSAGE if it is missing or empty
+//	indent N VALUE           indents every line of VALUE by N spaces
+//	nindent N VALUE          indent preceded by a newline
+//	join SEPARATOR LIST      joins the elements of LIST
+//	env                      environment being configured, e.g. dev_1
+//	envBasis                 environment without its version or instance, e.g. dev
+//	region                   region being configured or empty
+//	regions                  all regions being configured
+//	certFingerprint CERT     SHA-256 fingerprint of a PEM or base64 DER certificate
+//	include NAME DATA        executes the fragment trc_templates/Common/NAME with DATA
+//
+// Under -strict a missing key fails the template, so optional values
+// must be looked up with index, e.g. {{ index . "port" | default 8080 }}.
+func TemplateFuncMap(driverConfig *config.DriverConfig, regions []string) template.FuncMap {
+	funcMap := template.FuncMap{
+		"b64enc": func(v any) string {
+			return base64.StdEncoding.EncodeToString([]byte(toTemplateString(v)))
+		},
+		"b64dec": func(v any) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(toTemplateString(v))
+			return string(decoded), err
+		},
+		"toJson": func(v any) (string, error) {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(jsonSafe(v)); err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(buf.String(), "\n"), nil
+		},
+		"toYaml": func(v any) (string, error) {
+			out, err := yaml.Marshal(v)
+			return strings.TrimSuffix(string(out), "\n"), err
+		},
+		"quote": func(v any) string {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.Encode(toTemplateString(v))
+			return strings.TrimSuffix(buf.String(), "\n")
+		},
+		"squote": func(v any) string {
+			return "'" + strings.ReplaceAll(toTemplateString(v), "'", "''") + "'"
+		},
+		"default": func(defaultValue any, v ...any) any {
+			if len(v) == 0 || isEmptyValue(v[0]) {
+				return defaultValue
+			}
+			return v[0]
+		},
+		"required": func(message string, v any) (any, error) {
+			if isEmptyValue(v) {
+				return nil, errors.New(message)
+			}
+			return v, nil
+		},
+		"indent":  indentLines,
+		"nindent": func(n int, v any) string { return "\n" + indentLines(n, v) },
+		"join": func(separator string, list any) string {
+			elements := []string{}
+			listValue := reflect.ValueOf(list)
+			if listValue.Kind() != reflect.Slice && listValue.Kind() != reflect.Array {
+				return toTemplateString(list)
+			}
+			for i := range listValue.Len() {
+				elements = append(elements, toTemplateString(listValue.Index(i).Interface()))
+			}
+			return strings.Join(elements, separator)
+		},
+		"env": func() string {
+			return driverConfig.CoreConfig.Env
+		},
+		"envBasis": func() string {
+			return driverConfig.CoreConfig.EnvBasis
+		},
+		"region": func() string {
+			if len(regions) > 0 {
+				return regions[0]
+			}
+			return ""
+		},
+		"regions": func() []string {
+			return regions
+		},
+		"certFingerprint": certFingerprint,
+	}
+	depth := 0
+	funcMap["include"] = func(name string, data any) (string, error) {
+		if depth >= maxIncludeDepth {
+			return "", fmt.Errorf("include %s: nested too deeply", name)
+		}
+		fragment, err := readCommonFragment(driverConfig, name)
+		if err != nil {
+			return "", fmt.Errorf("include %s: %w", name, err)
+		}
+		t := template.New(name).Funcs(funcMap)
+		if driverConfig.Strict {
+			t = t.Option("missingkey=error")
+		}
+		if t, err = t.Parse(fragment); err != nil {
+			return "", err
+		}
+		var doc bytes.Buffer
+		depth++
+		defer func() { depth-- }()
+		err = t.Execute(&doc, data)
+		return doc.String(), err
+	}
+	return funcMap
+}
+
+// TemplateParseFuncMap returns the template functions for parsing templates
+// that aren't executed, e.g. to find the keys they use when seeding.
+func TemplateParseFuncMap() template.FuncMap {
+	return TemplateFuncMap(nil, nil)
+}
+
+// readCommonFragment loads a template fragment shared by all projects.
+func readCommonFragment(driverConfig *config.DriverConfig, name string) (string, error) {
+	templatesDir := coreopts.BuildOptions.GetFolderPrefix(driverConfig.StartDir) + "_templates"
+	root := templatesDir
+	if len(driverConfig.StartDir) > 0 {
+		root = strings.ReplaceAll(driverConfig.StartDir[0], "\\", "/")
+		if i := strings.Index(root, templatesDir); i >= 0 {
+			root = root[:i+len(templatesDir)]
+		}
+	}
+	name = strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "Common/")
+	if strings.Contains(name, "..") {
+		return "", errors.New("invalid fragment name")
+	}
+	fragmentPath := strings.TrimSuffix(root, "/") + "/Common/" + name
+
+	if driverConfig.ReadMemCache && driverConfig.MemFs != nil {
+		fragmentFile, err := driverConfig.MemFs.Open(fragmentPath)
+		if err != nil {
+			return "", err
+		}
+		fragment, err := io.ReadAll(fragmentFile)
+		return string(fragment), err
+	}
+	fragment, err := os.ReadFile(fragmentPath)
+	return string(fragment), err
+}
+
+func toTemplateString(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case *string:
+		if value == nil {
+			return ""
+		}
+		return *value
+	case []byte:
+		return string(value)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil() || isEmptyValue(value.Elem().Interface())
+	case reflect.Bool:
+		return !value.Bool()
+	default:
+		return value.IsZero()
+	}
+}
+
+func indentLines(n int, v any) string {
+	padding := strings.Repeat(" ", n)
+	return padding + strings.ReplaceAll(toTemplateString(v), "\n", "\n"+padding)
+}
+
+// jsonSafe converts yaml style map[any]any values into something encoding/json accepts.
+func jsonSafe(v any) any {
+	switch value := v.(type) {
+	case map[any]any:
+		converted := make(map[string]any, len(value))
+		for k, entry := range value {
+			converted[fmt.Sprint(k)] = jsonSafe(entry)
+		}
+		return converted
+	case map[string]any:
+		converted := make(map[string]any, len(value))
+		for k, entry := range value {
+			converted[k] = jsonSafe(entry)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(value))
+		for i, entry := range value {
+			converted[i] = jsonSafe(entry)
+		}
+		return converted
+	default:
+		return v
+	}
+}
+
+// certFingerprint returns the colon separated SHA-256 fingerprint of a
+// certificate provided as PEM or as base64 encoded DER.
+func certFingerprint(v any) (string, error) {
+	certBytes := []byte(toTemplateString(v))
+	if block, _ := pem.Decode(certBytes); block != nil {
+		certBytes = block.Bytes
+	} else if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(certBytes))); err == nil {
+		if block, _ := pem.Decode(decoded); block != nil {
+			certBytes = block.Bytes
+		} else {
+			certBytes = decoded
+		}
+	}
+	if len(certBytes) == 0 {
+		return "", errors.New("certFingerprint: empty certificate")
+	}
+	sum := sha256.Sum256(certBytes)
+	fingerprint := make([]string, len(sum))
+	for i, b := range sum {
+		fingerprint[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(fingerprint, ":"), nil
+}
+
+// MissingKeysError lists the keys a template references that have no value.
+type MissingKeysError struct {
+	Template string
+	Keys     []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("%s: unresolved keys: %s", e.Template, strings.Join(e.Keys, ", "))
+}
+
+// missingTemplateKeys returns the keys referenced from the top level of t that
+// are not in data.  References inside range and with blocks are relative to
+// the block's own dot and are left to execution.
+func missingTemplateKeys(t *template.Template, data any) []string {
+	missing := map[string]bool{}
+	values, _ := data.(map[string]any)
+	check := func(key string) {
+		if _, ok := values[key]; !ok {
+			missing[key] = true
+		}
+	}
+	var walk func(node parse.Node, rootDot bool)
+	walk = func(node parse.Node, rootDot bool) {
+		if node == nil || reflect.ValueOf(node).IsNil() {
+			return
+		}
+		switch n := node.(type) {
+		case *parse.ListNode:
+			for _, child := range n.Nodes {
+				walk(child, rootDot)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, rootDot)
+		case *parse.PipeNode:
+			for _, cmd := range n.Cmds {
+				walk(cmd, rootDot)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, rootDot)
+			}
+		case *parse.FieldNode:
+			if rootDot {
+				check(n.Ident[0])
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				check(n.Ident[1])
+			}
+		case *parse.ChainNode:
+			walk(n.Node, rootDot)
+		case *parse.IfNode:
+			walk(n.Pipe, rootDot)
+			walk(n.List, rootDot)
+			walk(n.ElseList, rootDot)
+		case *parse.RangeNode:
+			walk(n.Pipe, rootDot)
+			walk(n.List, false)
+			walk(n.ElseList, rootDot)
+		case *parse.WithNode:
+			walk(n.Pipe, rootDot)
+			walk(n.List, false)
+			walk(n.ElseList, rootDot)
+		case *parse.TemplateNode:
+			walk(n.Pipe, rootDot)
+		}
+	}
+	if t != nil && t.Tree != nil {
+		walk(t.Tree.Root, true)
+	}
+	keys := make([]string, 0, len(missing))
+	for key := range missing {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"text/template"
+
+	"github.com/trimble-oss/tierceron-core/v2/core/coreconfig"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+func TestTemplateFuncMap(t *testing.T) {
+	driverConfig := &config.DriverConfig{
+		CoreConfig: &coreconfig.CoreConfig{Env: "dev_1", EnvBasis: "dev"},
+	}
+	tmpl := template.Must(template.New("template").Funcs(TemplateFuncMap(driverConfig, []string{"west"})).Parse(
+		`{{ .name | b64enc }} {{ .list | join "," }} {{ index . "missing" | default "d" }} {{ quote .quoted }} {{ envBasis }}-{{ region }} {{ toJson .list }}`))
+	var doc bytes.Buffer
+	err := tmpl.Execute(&doc, map[string]any{
+		"name":   "trc",
+		"list":   []any{"a", "b"},
+		"quoted": `say "hi"`,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `dHJj a,b d "say \"hi\"" dev-west ["a","b"]`
+	if doc.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, doc.String())
+	}
+}
+
+func TestMissingTemplateKeys(t *testing.T) {
+	tmpl := template.Must(template.New("template").Parse(
+		`{{ .a }}{{ if .b }}{{ .c }}{{ end }}{{ range .d }}{{ .item }}{{ end }}{{ with .e }}{{ $.f }}{{ end }}{{ index . "g" }}`))
+	missing := missingTemplateKeys(tmpl, map[string]any{"a": 1, "d": []int{}})
+	if !reflect.DeepEqual(missing, []string{"b", "c", "e", "f"}) {
+		t.Fatalf("Unexpected missing keys: %v", missing)
+	}
+
+	static := template.Must(template.New("template").Parse("port: 8080\n{{ if true }}tls: true{{ end }}\n"))
+	if missing := missingTemplateKeys(static, nil); len(missing) != 0 {
+		t.Fatalf("Unexpected missing keys in static template: %v", missing)
+	}
+}
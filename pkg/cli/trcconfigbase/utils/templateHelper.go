@@ -263,10 +263,20 @@ func PopulateTemplate(driverConfig *config.DriverConfig,
 
 	if ok {
 		// create new template from template string
-		t := template.New("template")
+		regions := cds.Regions
+		if len(regions) == 0 {
+			regions = driverConfig.CoreConfig.Regions
+		}
+		t := template.New("template").Funcs(TemplateFuncMap(driverConfig, regions))
+		if driverConfig.Strict {
+			t = t.Option("missingkey=error")
+		}
 		t, err := t.Parse(emptyTemplate)
 		if err != nil {
 			eUtils.LogErrorObject(driverConfig.CoreConfig, err, false)
+			if driverConfig.Strict {
+				return "", nil, fmt.Errorf("%s: %w", filename, err)
+			}
 		}
 		var doc bytes.Buffer
 		// configure the template
@@ -275,6 +285,12 @@ func PopulateTemplate(driverConfig *config.DriverConfig,
 
 		_, hasData := values[filename]
 		if !hasData && !wantCerts {
+			if driverConfig.Strict {
+				// Templates without placeholders need no values.
+				if missingKeys := missingTemplateKeys(t, nil); len(missingKeys) > 0 {
+					return "", nil, &MissingKeysError{Template: filename, Keys: missingKeys}
+				}
+			}
 			eUtils.LogInfo(driverConfig.CoreConfig, filename+" does not exist in values. Please check seed files to verify that folder structures are correct.")
 		}
 
@@ -363,10 +379,19 @@ func PopulateTemplate(driverConfig *config.DriverConfig,
 			}
 		}
 
+		if driverConfig.Strict {
+			if missingKeys := missingTemplateKeys(t, values[filename]); len(missingKeys) > 0 {
+				return "", nil, &MissingKeysError{Template: filename, Keys: missingKeys}
+			}
+		}
+
 		err = t.Execute(&doc, values[filename])
 		str = doc.String()
 		if err != nil {
 			eUtils.LogErrorObject(driverConfig.CoreConfig, err, false)
+			if driverConfig.Strict {
+				return "", nil, fmt.Errorf("%s: %w", filename, err)
+			}
 		}
 	}
 	return str, certData, nil
@@ -0,0 +1,336 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
+)
+
+// WatchOptions configure trcconfig -watch.
+type WatchOptions struct {
+	Interval     time.Duration // How often vault versions are checked.
+	ReloadCmd    string        // Optional command run after templates change.
+	ReloadPid    int           // Optional process signaled after templates change.
+	ReloadSignal string        // Signal sent to ReloadPid, e.g. HUP.
+}
+
+// watchedTemplate is a template kept up to date by WatchConfigs.
+type watchedTemplate struct {
+	templatePath string
+	endPath      string
+	project      string
+	service      string
+	fingerprint  string // vault path@version of everything the template reads
+	links        map[string]*templateLinks
+}
+
+// templateLinks are the value buckets a template path links to as of version.
+type templateLinks struct {
+	version string
+	buckets []string
+}
+
+// watchRound is one check of vault versions, sharing a Modifier and the
+// versions and paths read between templates.
+type watchRound struct {
+	mod          *helperkv.Modifier
+	versions     map[string]string
+	projectPaths map[string][]string
+}
+
+// WatchConfigs checks the vault versions of every path the configured
+// templates read each interval and re-renders only the templates whose
+// values changed, replacing their files atomically.  After a round of
+// changes the reload command is run and the reload process signaled.
+// WatchConfigs runs until ctx is done.
+func WatchConfigs(ctx context.Context, driverConfig *config.DriverConfig, options *WatchOptions) error {
+	if options.Interval <= 0 {
+		return errors.New("watch interval must be positive")
+	}
+	var reloadSignal os.Signal
+	if options.ReloadPid > 0 {
+		var err error
+		if reloadSignal, err = parseReloadSignal(options.ReloadSignal); err != nil {
+			return err
+		}
+	}
+	templates, err := watchedTemplates(driverConfig)
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		return errors.New("no templates to watch")
+	}
+
+	if round, err := newWatchRound(driverConfig); err != nil {
+		eUtils.LogInfo(driverConfig.CoreConfig, fmt.Sprintf("Unable to read versions: %v", err))
+	} else {
+		for _, template := range templates {
+			if template.fingerprint, err = templateFingerprint(driverConfig, round, template); err != nil {
+				eUtils.LogInfo(driverConfig.CoreConfig, fmt.Sprintf("Unable to read versions for %s: %v", template.endPath, err))
+			}
+		}
+		round.mod.Release()
+	}
+	eUtils.LogInfo(driverConfig.CoreConfig, fmt.Sprintf("Watching %d templates every %s", len(templates), options.Interval))
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		round, err := newWatchRound(driverConfig)
+		if err != nil {
+			eUtils.LogInfo(driverConfig.CoreConfig, fmt.Sprintf("Unable to read versions: %v", err))
+			continue
+		}
+		changed := 0
+		for _, template := range templates {
+			fingerprint, err := templateFingerprint(driverConfig, round, template)
+			if err != nil {
+				eUtils.LogInfo(driverConfig.CoreConfig, fmt.Sprintf("Unable to read versions for %s: %v", template.endPath, err))
+				continue
+			}
+			if fingerprint == template.fingerprint {
+				continue
+			}
+			if err := renderWatchedTemplate(driverConfig, round.mod, template); err != nil {
+				// Keep the old fingerprint so the render is retried next round.
+				eUtils.LogErrorObject(driverConfig.CoreConfig, err, false)
+				continue
+			}
+			eUtils.LogInfo(driverConfig.CoreConfig, "Values changed, reconfigured "+template.endPath)
+			template.fingerprint = fingerprint
+			changed++
+		}
+		round.mod.Release()
+		if changed > 0 {
+			runReloadHook(driverConfig, options, reloadSignal)
+		}
+	}
+}
+
+// watchedTemplates lists the templates GenerateConfigsFromVault configures.
+func watchedTemplates(driverConfig *config.DriverConfig) ([]*watchedTemplate, error) {
+	templatePaths, endPaths, err := generatePaths(driverConfig)
+	if err != nil {
+		return nil, err
+	}
+	templatePaths, endPaths = FilterPaths(templatePaths, endPaths, driverConfig.FileFilter, false)
+	templatePaths, endPaths = FilterPaths(templatePaths, endPaths, driverConfig.ServicesWanted, false)
+
+	templates := []*watchedTemplate{}
+	for i, templatePath := range templatePaths {
+		if strings.HasSuffix(templatePath, ".DS_Store") || strings.Contains(templatePath, "Common") || strings.Contains(templatePath, ".mf.") {
+			continue
+		}
+		project, service, _, templatePath := eUtils.GetProjectService(driverConfig, templatePath)
+		if service == "" {
+			continue
+		}
+		templates = append(templates, &watchedTemplate{
+			templatePath: templatePath,
+			endPath:      endPaths[i],
+			project:      project,
+			service:      service,
+		})
+	}
+	return templates, nil
+}
+
+func newWatchModifier(driverConfig *config.DriverConfig) (*helperkv.Modifier, error) {
+	tokenNamePtr := driverConfig.CoreConfig.GetCurrentToken("config_token_%s")
+	mod, err := helperkv.NewModifierFromCoreConfig(driverConfig.CoreConfig, *tokenNamePtr, driverConfig.CoreConfig.EnvBasis, true)
+	if err != nil {
+		return nil, err
+	}
+	mod.Env = driverConfig.CoreConfig.Env
+	return mod, nil
+}
+
+func newWatchRound(driverConfig *config.DriverConfig) (*watchRound, error) {
+	mod, err := newWatchModifier(driverConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &watchRound{mod: mod, versions: map[string]string{}, projectPaths: map[string][]string{}}, nil
+}
+
+// version returns the latest version of a vault path from its version
+// metadata, without reading the secret.
+func (round *watchRound) version(driverConfig *config.DriverConfig, path string) (string, error) {
+	if version, ok := round.versions[path]; ok {
+		return version, nil
+	}
+	versionMetadata, err := round.mod.ReadVersionMetadata(path, driverConfig.CoreConfig.Log)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	version := latestVersion(versionMetadata)
+	round.versions[path] = version
+	return version, nil
+}
+
+// latestVersion returns the highest version in a path's version metadata.
+func latestVersion(versionMetadata map[string]any) string {
+	latest := 0
+	for version := range versionMetadata {
+		if number, err := strconv.Atoi(version); err == nil && number > latest {
+			latest = number
+		}
+	}
+	return strconv.Itoa(latest)
+}
+
+// templateFingerprint returns the versions of the template paths for the
+// template's service and of the value buckets they link to.  A template path
+// is only read to find its links when its version changes.
+func templateFingerprint(driverConfig *config.DriverConfig, round *watchRound, template *watchedTemplate) (string, error) {
+	serviceLookup, _, _ := strings.Cut(template.service, ".")
+	projectService := template.project + "/" + serviceLookup
+	templateDataPaths, ok := round.projectPaths[projectService]
+	if !ok {
+		var err error
+		templateDataPaths, err = GetPathsFromProject(driverConfig.CoreConfig, round.mod, []string{template.project}, false, []string{serviceLookup})
+		if err != nil {
+			return "", err
+		}
+		round.projectPaths[projectService] = templateDataPaths
+	}
+	if template.links == nil {
+		template.links = map[string]*templateLinks{}
+	}
+
+	dependencies := map[string]bool{}
+	for _, path := range templateDataPaths {
+		if strings.HasSuffix(path, "/") || strings.Contains(path, "!=!") || !pathHasWantedService(path, []string{serviceLookup}) {
+			continue
+		}
+		dependencies[path] = true
+		version, err := round.version(driverConfig, path)
+		if err != nil {
+			return "", err
+		}
+		links, ok := template.links[path]
+		if !ok || links.version != version {
+			data, err := round.mod.ReadData(path)
+			if err != nil {
+				return "", err
+			}
+			links = &templateLinks{version: version}
+			for _, value := range data {
+				if link, ok := value.([]any); ok && len(link) > 0 {
+					if bucket, ok := link[0].(string); ok {
+						links.buckets = append(links.buckets, bucket)
+					}
+				}
+			}
+			template.links[path] = links
+		}
+		for _, bucket := range links.buckets {
+			dependencies[bucket] = true
+		}
+	}
+
+	fingerprint := make([]string, 0, len(dependencies))
+	for path := range dependencies {
+		version, err := round.version(driverConfig, path)
+		if err != nil {
+			return "", err
+		}
+		fingerprint = append(fingerprint, path+"@"+version)
+	}
+	sort.Strings(fingerprint)
+	return strings.Join(fingerprint, ";"), nil
+}
+
+// renderWatchedTemplate configures template and replaces its file atomically.
+func renderWatchedTemplate(driverConfig *config.DriverConfig, mod *helperkv.Modifier, template *watchedTemplate) error {
+	configuredTemplate, _, _, err := ConfigTemplate(driverConfig, mod, template.templatePath, driverConfig.SecretMode, template.project, template.service, false, driverConfig.ZeroConfig)
+	if err != nil {
+		return err
+	}
+	outputPath := template.endPath
+	if driverConfig.OutputFileSystemDir != "" {
+		outputPath = filepath.Join(driverConfig.OutputFileSystemDir, outputPath)
+	}
+	return writeFileAtomic(outputPath, []byte(substituteBuildVars(driverConfig, configuredTemplate)))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath)
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// runReloadHook tells the configured service that its configuration changed.
+func runReloadHook(driverConfig *config.DriverConfig, options *WatchOptions, reloadSignal os.Signal) {
+	if options.ReloadCmd != "" {
+		var cmd *exec.Cmd
+		if eUtils.IsWindows() {
+			cmd = exec.Command("cmd", "/C", options.ReloadCmd)
+		} else {
+			cmd = exec.Command("sh", "-c", options.ReloadCmd)
+		}
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			eUtils.LogErrorObject(driverConfig.CoreConfig, fmt.Errorf("reload command failed: %w: %s", err, output), false)
+		} else {
+			eUtils.LogInfo(driverConfig.CoreConfig, "Reload command completed")
+		}
+	}
+	if options.ReloadPid > 0 && reloadSignal != nil {
+		process, err := os.FindProcess(options.ReloadPid)
+		if err == nil {
+			err = process.Signal(reloadSignal)
+		}
+		if err != nil {
+			eUtils.LogErrorObject(driverConfig.CoreConfig, fmt.Errorf("unable to signal process %d: %w", options.ReloadPid, err), false)
+		} else {
+			eUtils.LogInfo(driverConfig.CoreConfig, fmt.Sprintf("Sent %s to process %d", options.ReloadSignal, options.ReloadPid))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config", "app.yml")
+	if err := writeFileAtomic(path, []byte("version: 1\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("version: 2\n")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "version: 2\n" {
+		t.Fatalf("Unexpected content %q, %v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected mode to be kept, got %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("Expected temporary files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	versionMetadata := map[string]any{"1": map[string]any{}, "9": map[string]any{}, "10": map[string]any{}, "2": map[string]any{}}
+	if version := latestVersion(versionMetadata); version != "10" {
+		t.Fatalf("Expected version 10, got %s", version)
+	}
+	if version := latestVersion(nil); version != "0" {
+		t.Fatalf("Expected version 0, got %s", version)
+	}
+}
+
+func TestPathHasWantedService(t *testing.T) {
+	tests := []struct {
+		path   string
+		wanted bool
+	}{
+		{"templates/Project/Service/config.yml.tmpl", true},
+		{"templates/Other/Other/config.yml.tmpl", false},
+		{"templates/Project", false},
+		{"templates", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if wanted := pathHasWantedService(test.path, []string{"Service"}); wanted != test.wanted {
+			t.Errorf("pathHasWantedService(%q): expected %v, got %v", test.path, test.wanted, wanted)
+		}
+	}
+}
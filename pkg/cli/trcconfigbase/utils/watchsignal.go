@@ -0,0 +1,30 @@
+//go:build !windows
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+var reloadSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// parseReloadSignal converts a signal name such as HUP or SIGUSR1.
+func parseReloadSignal(name string) (os.Signal, error) {
+	if name == "" {
+		return syscall.SIGHUP, nil
+	}
+	if signal, ok := reloadSignals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return signal, nil
+	}
+	return nil, fmt.Errorf("unsupported reload signal: %s", name)
+}
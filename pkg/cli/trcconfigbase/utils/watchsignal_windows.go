@@ -0,0 +1,13 @@
+//go:build windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+// parseReloadSignal fails as windows processes can't be signaled to reload.
+func parseReloadSignal(name string) (os.Signal, error) {
+	return nil, errors.New("reload signals are not supported on windows, use -reloadCmd")
+}
@@ -20,6 +20,7 @@ import (
 	il "github.com/trimble-oss/tierceron/pkg/trcinit/initlib"
 	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
 	"github.com/trimble-oss/tierceron/pkg/utils/config"
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
 	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
 	sys "github.com/trimble-oss/tierceron/pkg/vaulthelper/system"
 	"github.com/trimble-oss/tierceron/trcweb/rpc/apinator"
@@ -35,6 +36,112 @@ func defaultEmpty() *string {
 	return &emptyVar
 }
 
+// initFlags are the flags CommonMain defines.
+type initFlags struct {
+	new             *bool
+	seeds           *string
+	shard           *string
+	namespace       *string
+	logFile         *string
+	service         *string
+	prod            *bool
+	rotateTokens    *bool
+	tokenExpiration *bool
+	ping            *bool
+	updateRole      *bool
+	updateAppRole   *bool
+	updatePolicy    *bool
+	initNamespace   *bool
+	doTidy          *bool
+	insecure        *bool
+	keyShard        *string
+	unsealShard     *string
+	tokenFileFilter *string
+	roleFileFilter  *string
+	dynamicPath     *string
+	nest            *bool
+	dev             *bool
+	token           *string
+	addr            *string
+	plan            *bool
+}
+
+// defineFlags defines CommonMain's flags on flagset.  standalone is true if
+// CommonMain created flagset, which restricts trcinit to -env and leaves
+// everything else at its default.
+func defineFlags(flagset *flag.FlagSet, standalone bool) *initFlags {
+	if standalone {
+		flagset.String("env", "dev", "Environment to configure")
+		seeds := coreopts.BuildOptions.GetFolderPrefix(nil) + "_seeds"
+		return &initFlags{
+			new:             defaultFalse(),
+			seeds:           &seeds,
+			shard:           defaultEmpty(),
+			namespace:       defaultEmpty(),
+			logFile:         defaultEmpty(),
+			service:         defaultEmpty(),
+			prod:            defaultFalse(),
+			rotateTokens:    defaultFalse(),
+			tokenExpiration: defaultFalse(),
+			ping:            defaultFalse(),
+			updateRole:      defaultFalse(),
+			updateAppRole:   defaultFalse(),
+			updatePolicy:    defaultFalse(),
+			initNamespace:   defaultFalse(),
+			doTidy:          defaultFalse(),
+			insecure:        defaultFalse(),
+			keyShard:        defaultEmpty(),
+			unsealShard:     defaultEmpty(),
+			tokenFileFilter: defaultEmpty(),
+			roleFileFilter:  defaultEmpty(),
+			dynamicPath:     defaultEmpty(),
+			nest:            defaultFalse(),
+			dev:             defaultFalse(),
+			token:           defaultEmpty(),
+			addr:            defaultEmpty(),
+			plan:            defaultFalse(),
+		}
+	}
+	flags := &initFlags{}
+	flags.new = flagset.Bool("new", false, "New vault being initialized. Creates engines and requests first-time initialization")
+	flags.seeds = flagset.String("seeds", coreopts.BuildOptions.GetFolderPrefix(nil)+"_seeds", "Directory that contains vault seeds")
+	flags.shard = flagset.String("shard", "", "Key shard used to unseal a vault that has been initialized but restarted")
+
+	flags.namespace = flagset.String("namespace", "vault", "name of the namespace")
+
+	flags.logFile = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"init.log", "Output path for log files")
+	flags.service = flagset.String("service", "", "Seeding vault with a single service")
+	flags.prod = flagset.Bool("prod", false, "Prod only seeds vault with staging environment")
+	flags.rotateTokens = flagset.Bool("rotateTokens", false, "rotate tokens")
+	flags.tokenExpiration = flagset.Bool("tokenExpiration", false, "Look up Token expiration dates")
+	flags.ping = flagset.Bool("ping", false, "Ping vault.")
+	flags.updateRole = flagset.Bool("updateRole", false, "Update security role")
+	flags.updateAppRole = flagset.Bool("updateAppRole", false, "Update AppRole without rotating tokens")
+	flags.updatePolicy = flagset.Bool("updatePolicy", false, "Update security policy")
+	flags.initNamespace = flagset.Bool("initns", false, "Init namespace (tokens, policy, and role)")
+	flags.doTidy = flagset.Bool("tidy", false, "Clean up (tidy) expired tokens")
+	flags.insecure = flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
+	flags.keyShard = flagset.String("totalKeys", "5", "Total number of key shards to make")
+	flags.unsealShard = flagset.String("unsealKeys", "3", "Number of key shards needed to unseal")
+	flags.tokenFileFilter = flagset.String("filter", "", "Filter files for token rotation.  Comma delimited.")
+	flags.roleFileFilter = flagset.String("approle", "", "Filter files for approle rotation.")
+	flags.dynamicPath = flagset.String("dynamicPath", "", "Seed a specific directory in vault.")
+	flags.nest = flagset.Bool("nest", false, "Seed a specific directory in vault.")
+	flags.dev = flagset.Bool("dev", false, "Vault server running in dev mode (does not need to be unsealed)")
+	flags.addr = flagset.String("addr", "", "API endpoint for the vault")
+	flags.token = flagset.String("token", "", "Vault access token, only use if in dev mode or reseeding")
+	flags.plan = flagset.Bool("plan", false, "Compare seed files against vault and print the planned changes without writing.  Exits with code 2 if drift exists and 1 if some paths could not be compared.")
+	return flags
+}
+
+// Flags returns the flags CommonMain defines, standalone or on a caller's
+// flagset, e.g. to check trcsh scripts.
+func Flags(standalone bool) *flag.FlagSet {
+	flagset := flag.NewFlagSet("trcinit", flag.ContinueOnError)
+	defineFlags(flagset, standalone)
+	return flagset
+}
+
 func PrintVersion() {
 	fmt.Fprintln(os.Stderr, "trcshinit Version: "+"1.39")
 }
@@ -50,6 +157,8 @@ func CommonMain(envPtr *string,
 	argLines []string,
 	driverConfig *config.DriverConfig,
 ) {
+	audit.SetTool("trcinit")
+
 	if driverConfig == nil || driverConfig.CoreConfig == nil || driverConfig.CoreConfig.TokenCache == nil {
 		driverConfig = &config.DriverConfig{
 			CoreConfig: &coreconfig.CoreConfig{
@@ -60,34 +169,9 @@ func CommonMain(envPtr *string,
 		}
 	}
 
-	var newPtr *bool = defaultFalse()
-	var seedPtr *string = defaultEmpty()
-	var shardPtr *string = defaultEmpty()
-	var namespaceVariable *string = defaultEmpty()
-
-	var logFilePtr *string = defaultEmpty()
-	var servicePtr *string = defaultEmpty()
-	var prodPtr *bool = defaultFalse()
-	var rotateTokens *bool = defaultFalse()
-	var tokenExpiration *bool = defaultFalse()
-	var pingPtr *bool = defaultFalse()
-	var updateRole *bool = defaultFalse()
-	var updateAppRole *bool = defaultFalse()
-	var updatePolicy *bool = defaultFalse()
-	var initNamespace *bool = defaultFalse()
-	var doTidyPtr *bool = defaultFalse()
-	var insecurePtr *bool = defaultFalse()
-	var keyShardPtr *string = defaultEmpty()
-	var unsealShardPtr *string = defaultEmpty()
-	var tokenFileFilterSlicePtr *string = defaultEmpty()
-	var roleFileFilterPtr *string = defaultEmpty()
-	var dynamicPathPtr *string = defaultEmpty()
-	var nestPtr *bool = defaultFalse()
 	var roleEntityPtr *string = defaultEmpty()
-	var devPtr *bool = defaultFalse()
-	var tokenPtr *string = defaultEmpty()
-	var addrPtr *string = defaultEmpty()
 
+	standalone := flagset == nil
 	if flagset == nil {
 		if driverConfig == nil || driverConfig.CoreConfig == nil || !driverConfig.CoreConfig.IsEditor {
 			PrintVersion()
@@ -105,37 +189,17 @@ func CommonMain(envPtr *string,
 			fmt.Fprintf(flagset.Output(), "Usage of %s:\n", argLines[0])
 			flagset.PrintDefaults()
 		}
-		flagset.String("env", "dev", "Environment to configure")
-		*seedPtr = coreopts.BuildOptions.GetFolderPrefix(nil) + "_seeds"
-	} else {
-		newPtr = flagset.Bool("new", false, "New vault being initialized. Creates engines and requests first-time initialization")
-		seedPtr = flagset.String("seeds", coreopts.BuildOptions.GetFolderPrefix(nil)+"_seeds", "Directory that contains vault seeds")
-		shardPtr = flagset.String("shard", "", "Key shard used to unseal a vault that has been initialized but restarted")
-
-		namespaceVariable = flagset.String("namespace", "vault", "name of the namespace")
-
-		logFilePtr = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"init.log", "Output path for log files")
-		servicePtr = flagset.String("service", "", "Seeding vault with a single service")
-		prodPtr = flagset.Bool("prod", false, "Prod only seeds vault with staging environment")
-		rotateTokens = flagset.Bool("rotateTokens", false, "rotate tokens")
-		tokenExpiration = flagset.Bool("tokenExpiration", false, "Look up Token expiration dates")
-		pingPtr = flagset.Bool("ping", false, "Ping vault.")
-		updateRole = flagset.Bool("updateRole", false, "Update security role")
-		updateAppRole = flagset.Bool("updateAppRole", false, "Update AppRole without rotating tokens")
-		updatePolicy = flagset.Bool("updatePolicy", false, "Update security policy")
-		initNamespace = flagset.Bool("initns", false, "Init namespace (tokens, policy, and role)")
-		doTidyPtr = flagset.Bool("tidy", false, "Clean up (tidy) expired tokens")
-		insecurePtr = flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
-		keyShardPtr = flagset.String("totalKeys", "5", "Total number of key shards to make")
-		unsealShardPtr = flagset.String("unsealKeys", "3", "Number of key shards needed to unseal")
-		tokenFileFilterSlicePtr = flagset.String("filter", "", "Filter files for token rotation.  Comma delimited.")
-		roleFileFilterPtr = flagset.String("approle", "", "Filter files for approle rotation.")
-		dynamicPathPtr = flagset.String("dynamicPath", "", "Seed a specific directory in vault.")
-		nestPtr = flagset.Bool("nest", false, "Seed a specific directory in vault.")
-		devPtr = flagset.Bool("dev", false, "Vault server running in dev mode (does not need to be unsealed)")
-		addrPtr = flagset.String("addr", "", "API endpoint for the vault")
-		tokenPtr = flagset.String("token", "", "Vault access token, only use if in dev mode or reseeding")
 	}
+	flags := defineFlags(flagset, standalone)
+	newPtr, seedPtr, shardPtr, namespaceVariable := flags.new, flags.seeds, flags.shard, flags.namespace
+	logFilePtr, servicePtr, prodPtr := flags.logFile, flags.service, flags.prod
+	rotateTokens, tokenExpiration, pingPtr := flags.rotateTokens, flags.tokenExpiration, flags.ping
+	updateRole, updateAppRole, updatePolicy := flags.updateRole, flags.updateAppRole, flags.updatePolicy
+	initNamespace, doTidyPtr, insecurePtr := flags.initNamespace, flags.doTidy, flags.insecure
+	keyShardPtr, unsealShardPtr := flags.keyShard, flags.unsealShard
+	tokenFileFilterSlicePtr, roleFileFilterPtr := flags.tokenFileFilter, flags.roleFileFilter
+	dynamicPathPtr, nestPtr, devPtr := flags.dynamicPath, flags.nest, flags.dev
+	tokenPtr, addrPtr, planPtr := flags.token, flags.addr, flags.plan
 
 	if driverConfig == nil || (!driverConfig.IsShellSubProcess && (driverConfig.CoreConfig == nil || !driverConfig.CoreConfig.IsEditor)) {
 		args := argLines[1:]
@@ -189,6 +253,11 @@ func CommonMain(envPtr *string,
 		}
 	}
 
+	if *planPtr && (*newPtr || *initNamespace || *rotateTokens || *tokenExpiration || *updateRole || *updatePolicy || *updateAppRole || *doTidyPtr || len(*shardPtr) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -plan only applies to seeding and cannot be combined with -new, -initns, -rotateTokens, -tokenExpiration, -updateRole, -updatePolicy, -updateAppRole, -tidy or -shard.")
+		return
+	}
+
 	var driverConfigBase *config.DriverConfig
 	if driverConfig.CoreConfig.IsShell {
 		driverConfigBase = driverConfig
@@ -398,24 +467,36 @@ func CommonMain(envPtr *string,
 		eUtils.CheckWarning(driverConfigBase.CoreConfig, "Missing auth tokens", true)
 	}
 
-	// Create a new vault system connection
-	v, err := sys.NewVaultWithNonlocal(*insecurePtr, driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr, *envPtr, *newPtr, *pingPtr, false, allowNonLocal, driverConfigBase.CoreConfig.Log)
-	if err != nil {
-		if strings.Contains(err.Error(), "x509: certificate signed by unknown authority") {
-			fmt.Fprintf(os.Stderr, "Attempting to connect to insecure vault or vault with self signed certificate.  If you really wish to continue, you may add -insecure as on option.\n")
-		} else if strings.Contains(err.Error(), "no such host") {
-			fmt.Fprintf(os.Stderr, "failed to connect to vault - missing host")
-		} else {
-			fmt.Fprintln(os.Stderr, err.Error())
-		}
-
+	// A local file backend has no vault server to administer, only seeds.
+	fileBackend := eUtils.RefLength(driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr) > 0 &&
+		helperkv.IsFileBackendAddress(*driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr)
+	if fileBackend && (*newPtr || *initNamespace || *rotateTokens || *tokenExpiration || *updateRole || *updatePolicy || *updateAppRole || *doTidyPtr || len(*shardPtr) > 0 || *pingPtr) {
+		fmt.Fprintln(os.Stderr, "Error: a file backend only supports seeding and cannot be used with -new, -initns, -rotateTokens, -tokenExpiration, -updateRole, -updatePolicy, -updateAppRole, -tidy, -shard or -ping.")
 		return
 	}
-	if *pingPtr {
+
+	// Create a new vault system connection
+	var v *sys.Vault
+	var err error
+	if !fileBackend {
+		v, err = sys.NewVaultWithNonlocal(*insecurePtr, driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr, *envPtr, *newPtr, *pingPtr, false, allowNonLocal, driverConfigBase.CoreConfig.Log)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Ping failure: %v\n", err)
+			if strings.Contains(err.Error(), "x509: certificate signed by unknown authority") {
+				fmt.Fprintf(os.Stderr, "Attempting to connect to insecure vault or vault with self signed certificate.  If you really wish to continue, you may add -insecure as on option.\n")
+			} else if strings.Contains(err.Error(), "no such host") {
+				fmt.Fprintf(os.Stderr, "failed to connect to vault - missing host")
+			} else {
+				fmt.Fprintln(os.Stderr, err.Error())
+			}
+
+			return
+		}
+		if *pingPtr {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Ping failure: %v\n", err)
+			}
+			return
 		}
-		return
 	}
 	// Set up token file filters if there are any.
 	var tokenFileFiltersSet map[string]bool = make(map[string]bool)
@@ -436,7 +517,9 @@ func CommonMain(envPtr *string,
 		driverConfigBase.CoreConfig.Log.Printf("Login successful, using local envronment: %s\n", *envPtr)
 	}
 
-	if *devPtr || !*newPtr { // Dev server, initialization taken care of, get root token
+	if fileBackend {
+		driverConfigBase.CoreConfig.Log.Printf("Using file backend at %s\n", *driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr)
+	} else if *devPtr || !*newPtr { // Dev server, initialization taken care of, get root token
 		v.SetToken(driverConfigBase.CoreConfig.TokenCache.GetToken(*tokenNamePtr))
 	} else { // Unseal and grab keys/root token
 		totalKeyShard, err := strconv.ParseUint(*keyShardPtr, 10, 32)
@@ -457,7 +540,9 @@ func CommonMain(envPtr *string,
 		_, _, _, err = v.Unseal()
 		eUtils.LogErrorObject(driverConfigBase.CoreConfig, err, true)
 	}
-	driverConfigBase.CoreConfig.Log.Printf("Successfully connected to vault at %s\n", *driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr)
+	if !fileBackend {
+		driverConfigBase.CoreConfig.Log.Printf("Successfully connected to vault at %s\n", *driverConfigBase.CoreConfig.TokenCache.VaultAddressPtr)
+	}
 
 	if !*newPtr && *namespaceVariable != "" && *namespaceVariable != "vault" && !(*rotateTokens || *updatePolicy || *updateRole || *updateAppRole || *tokenExpiration) {
 		if *initNamespace {
@@ -1221,7 +1306,25 @@ func CommonMain(envPtr *string,
 			dConfig.SubSectionName = *eUtils.ServiceNameFilterPtr
 		}
 
+		var seedPlan *il.SeedPlan
+		if *planPtr {
+			seedPlan = il.NewSeedPlan(*envPtr)
+			dConfig.Context = seedPlan
+		}
+
 		il.SeedVault(dConfig)
+
+		if seedPlan != nil {
+			seedPlan.Print(os.Stdout)
+			if seedPlan.Failed() {
+				eUtils.LogAndSafeExit(driverConfigBase.CoreConfig, "Seed plan incomplete for "+*envPtr+".", 1)
+				return
+			}
+			if seedPlan.HasDrift() {
+				eUtils.LogAndSafeExit(driverConfigBase.CoreConfig, "Seed plan found drift for "+*envPtr+".", 2)
+				return
+			}
+		}
 	}
 
 	driverConfigBase.CoreConfig.Log.SetPrefix("[INIT]")
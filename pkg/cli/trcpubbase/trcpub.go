@@ -17,6 +17,7 @@ import (
 	il "github.com/trimble-oss/tierceron/pkg/trcinit/initlib"
 	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
 	"github.com/trimble-oss/tierceron/pkg/utils/config"
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
 	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
 )
 
@@ -24,6 +25,53 @@ func PrintVersion() {
 	fmt.Fprintln(os.Stderr, "Version: "+"1.29")
 }
 
+// pubFlags are the flags CommonMain defines.
+type pubFlags struct {
+	token          *string
+	addr           *string
+	help           *bool
+	dir            *string
+	ping           *bool
+	insecure       *bool
+	logFile        *string
+	roleEntity     *string
+	filterTemplate *string
+}
+
+// defineFlags defines CommonMain's flags on flagset.  standalone is true if
+// CommonMain created flagset, a caller's flagset already has the rest of the
+// connection flags.
+func defineFlags(flagset *flag.FlagSet, standalone bool) *pubFlags {
+	flags := &pubFlags{}
+	if standalone {
+		flags.help = flagset.Bool("h", false, "Display help")
+		flagset.String("env", "dev", "Environment to configure")
+		flagset.String("addr", "", "API endpoint for the vault")
+		flagset.String("token", "", "Vault access token")
+		flagset.String("secretID", "", "Secret for app role ID")
+		flagset.String("appRoleID", "", "Public app role ID")
+		flagset.String("tokenName", "", "Token name used by this "+coreopts.BuildOptions.GetFolderPrefix(nil)+"pub to access the vault")
+	} else {
+		flags.token = flagset.String("token", "", "Vault access token")
+		flags.addr = flagset.String("addr", "", "API endpoint for the vault")
+	}
+	flags.dir = flagset.String("dir", coreopts.BuildOptions.GetFolderPrefix(nil)+"_templates", "Directory containing template files for vault")
+	flags.ping = flagset.Bool("ping", false, "Ping vault.")
+	flags.insecure = flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
+	flags.logFile = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"pub.log", "Output path for log files")
+	flags.roleEntity = flagset.String("approle", "configpub.yml", "Name of auth config file - example.yml (optional)")
+	flags.filterTemplate = flagset.String("templateFilter", "", "Specifies which templates to filter")
+	return flags
+}
+
+// Flags returns the flags CommonMain defines, standalone or on a caller's
+// flagset, e.g. to check trcsh scripts.
+func Flags(standalone bool) *flag.FlagSet {
+	flagset := flag.NewFlagSet("trcpub", flag.ContinueOnError)
+	defineFlags(flagset, standalone)
+	return flagset
+}
+
 // Reads in template files in specified directory
 // Template directory should contain directories for each service
 // Templates are uploaded to templates/<service>/<file name>/template-file
@@ -37,6 +85,8 @@ func CommonMain(envPtr *string,
 	argLines []string,
 	driverConfig *config.DriverConfig,
 ) {
+	audit.SetTool("trcpub")
+
 	if driverConfig == nil || driverConfig.CoreConfig == nil || driverConfig.CoreConfig.TokenCache == nil {
 		driverConfig = &config.DriverConfig{
 			CoreConfig: &coreconfig.CoreConfig{
@@ -50,9 +100,7 @@ func CommonMain(envPtr *string,
 	if memonly.IsMemonly() {
 		memprotectopts.MemProtectInit(nil)
 	}
-	var tokenPtr *string = nil
-	var addrPtr *string = nil
-	var helpPtr *bool = nil
+	standalone := flagset == nil
 	if flagset == nil {
 		PrintVersion()
 		progName := "trcpub"
@@ -69,23 +117,11 @@ func CommonMain(envPtr *string,
 			fmt.Fprintf(flagset.Output(), "Usage:\n")
 			flagset.PrintDefaults()
 		}
-		helpPtr = flagset.Bool("h", false, "Display help")
-		flagset.String("env", "dev", "Environment to configure")
-		flagset.String("addr", "", "API endpoint for the vault")
-		flagset.String("token", "", "Vault access token")
-		flagset.String("secretID", "", "Secret for app role ID")
-		flagset.String("appRoleID", "", "Public app role ID")
-		flagset.String("tokenName", "", "Token name used by this "+coreopts.BuildOptions.GetFolderPrefix(nil)+"pub to access the vault")
-	} else {
-		tokenPtr = flagset.String("token", "", "Vault access token")
-		addrPtr = flagset.String("addr", "", "API endpoint for the vault")
 	}
-	dirPtr := flagset.String("dir", coreopts.BuildOptions.GetFolderPrefix(nil)+"_templates", "Directory containing template files for vault")
-	pingPtr := flagset.Bool("ping", false, "Ping vault.")
-	insecurePtr := flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
-	logFilePtr := flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"pub.log", "Output path for log files")
-	roleEntityPtr := flagset.String("approle", "configpub.yml", "Name of auth config file - example.yml (optional)")
-	filterTemplatePtr := flagset.String("templateFilter", "", "Specifies which templates to filter")
+	flags := defineFlags(flagset, standalone)
+	tokenPtr, addrPtr, helpPtr := flags.token, flags.addr, flags.help
+	dirPtr, pingPtr, insecurePtr, logFilePtr := flags.dir, flags.ping, flags.insecure, flags.logFile
+	roleEntityPtr, filterTemplatePtr := flags.roleEntity, flags.filterTemplate
 
 	// If running from trcshcmd (IsShellCommand), redirect output to io/STDIO in memfs
 	var outWriter io.Writer = os.Stderr
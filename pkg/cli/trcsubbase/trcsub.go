@@ -25,6 +25,60 @@ func PrintVersion() {
 	fmt.Fprintln(os.Stderr, "Version: "+"1.29")
 }
 
+// subFlags are the flags CommonMain defines.
+type subFlags struct {
+	env            *string
+	token          *string
+	addr           *string
+	endDir         *string
+	ping           *bool
+	insecure       *bool
+	logFile        *string
+	sa             *bool
+	pluginInfo     *bool
+	pluginName     *string
+	filterTemplate *string
+	sw             *string
+	templatePaths  *string
+}
+
+// defineFlags defines CommonMain's flags on flagset.  standalone is true if
+// CommonMain created flagset, a caller's flagset already has the rest of the
+// connection flags.
+func defineFlags(flagset *flag.FlagSet, standalone bool, envDefault string) *subFlags {
+	flags := &subFlags{}
+	if standalone {
+		flags.env = flagset.String("env", envDefault, "Environment to configure")
+		flagset.String("addr", "", "API endpoint for the vault")
+		flagset.String("secretID", "", "Secret for app role ID")
+		flagset.String("appRoleID", "", "Public app role ID")
+		flagset.String("tokenName", "", "Token name used by this "+coreopts.BuildOptions.GetFolderPrefix(nil)+"pub to access the vault")
+	} else {
+		flags.token = flagset.String("token", "", "Vault access token")
+		flags.addr = flagset.String("addr", "", "API endpoint for the vault")
+	}
+	flags.endDir = flagset.String("endDir", coreopts.BuildOptions.GetFolderPrefix(nil)+"_templates", "Directory to put configured templates into")
+	flags.ping = flagset.Bool("ping", false, "Ping vault.")
+	flags.insecure = flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
+	flags.logFile = flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"sub.log", "Output path for log files")
+	flags.sa = flagset.Bool("sa", false, "Lists all projects and services available")
+	flags.pluginInfo = flagset.Bool("pluginInfo", false, "Lists all plugins")
+	flags.pluginName = flagset.String("pluginName", "", "Specifies which templates to filter")
+
+	flags.filterTemplate = flagset.String("templateFilter", "", "Specifies which templates to filter")
+	flags.sw = flagset.String("sw", "", "Alias for -templateFilter")
+	flags.templatePaths = flagset.String("templatePaths", "", "Specifies which specific templates to download.")
+	return flags
+}
+
+// Flags returns the flags CommonMain defines, standalone or on a caller's
+// flagset, e.g. to check trcsh scripts.
+func Flags(standalone bool) *flag.FlagSet {
+	flagset := flag.NewFlagSet("trcsub", flag.ContinueOnError)
+	defineFlags(flagset, standalone, "dev")
+	return flagset
+}
+
 // Reads in template files in specified directory
 // Template directory should contain directories for each service
 // Templates are uploaded to templates/<service>/<file name>/template-file
@@ -51,10 +105,7 @@ func CommonMain(envDefaultPtr *string,
 	if memonly.IsMemonly() {
 		memprotectopts.MemProtectInit(nil)
 	}
-	var envPtr *string = nil
-	var tokenPtr *string = nil
-	var addrPtr *string = nil
-
+	standalone := flagset == nil
 	if flagset == nil {
 		if driverConfig == nil || driverConfig.CoreConfig == nil || !driverConfig.CoreConfig.IsEditor {
 			fmt.Fprintln(os.Stderr, "Version: "+"1.7")
@@ -73,31 +124,16 @@ func CommonMain(envDefaultPtr *string,
 			fmt.Fprintf(flagset.Output(), "Usage of %s:\n", progName)
 			flagset.PrintDefaults()
 		}
-		if envDefaultPtr != nil {
-			envPtr = flagset.String("env", *envDefaultPtr, "Environment to configure")
-		} else {
-			envPtr = flagset.String("env", "dev", "Environment to configure")
-		}
-
-		flagset.String("addr", "", "API endpoint for the vault")
-		flagset.String("secretID", "", "Secret for app role ID")
-		flagset.String("appRoleID", "", "Public app role ID")
-		flagset.String("tokenName", "", "Token name used by this "+coreopts.BuildOptions.GetFolderPrefix(nil)+"pub to access the vault")
-	} else {
-		tokenPtr = flagset.String("token", "", "Vault access token")
-		addrPtr = flagset.String("addr", "", "API endpoint for the vault")
 	}
-	endDirPtr := flagset.String("endDir", coreopts.BuildOptions.GetFolderPrefix(nil)+"_templates", "Directory to put configured templates into")
-	pingPtr := flagset.Bool("ping", false, "Ping vault.")
-	insecurePtr := flagset.Bool("insecure", false, "By default, every ssl connection this tool makes is verified secure.  This option allows to tool to continue with server connections considered insecure.")
-	logFilePtr := flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"sub.log", "Output path for log files")
-	saPtr := flagset.Bool("sa", false, "Lists all projects and services available")
-	pluginInfoPtr := flagset.Bool("pluginInfo", false, "Lists all plugins")
-	pluginNamePtr := flagset.String("pluginName", "", "Specifies which templates to filter")
-
-	filterTemplatePtr := flagset.String("templateFilter", "", "Specifies which templates to filter")
-	swPtr := flagset.String("sw", "", "Alias for -templateFilter")
-	templatePathsPtr := flagset.String("templatePaths", "", "Specifies which specific templates to download.")
+	envDefault := "dev"
+	if envDefaultPtr != nil {
+		envDefault = *envDefaultPtr
+	}
+	flags := defineFlags(flagset, standalone, envDefault)
+	envPtr, tokenPtr, addrPtr := flags.env, flags.token, flags.addr
+	endDirPtr, pingPtr, insecurePtr, logFilePtr := flags.endDir, flags.ping, flags.insecure, flags.logFile
+	saPtr, pluginInfoPtr, pluginNamePtr := flags.sa, flags.pluginInfo, flags.pluginName
+	filterTemplatePtr, swPtr, templatePathsPtr := flags.filterTemplate, flags.sw, flags.templatePaths
 
 	// If running from trcshcmd (IsShellCommand), redirect output to io/STDIO in memfs
 	var outWriter io.Writer = os.Stderr
@@ -17,6 +17,7 @@ import (
 	"github.com/trimble-oss/tierceron/buildopts/coreopts"
 	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
 	"github.com/trimble-oss/tierceron/pkg/utils/config"
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
 	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
 )
 
@@ -29,10 +30,10 @@ func PrintVersion() {
 // parseTvPath parses a vault path like "super-secrets/dev/DataFlowStatistics"
 // into (modifierPath, env) where modifierPath is "super-secrets/DataFlowStatistics"
 // and env is "dev". Paths with two segments like "super-secrets/dev" are treated
-// as a mount plus env with an empty remainder.
+// as a mount plus env with an empty remainder. Template paths carry no env.
 func parseTvPath(userPath string) (string, string) {
 	parts := strings.Split(userPath, "/")
-	if len(parts) < 2 {
+	if len(parts) < 2 || parts[0] == "templates" {
 		return userPath, ""
 	}
 	remainder := ""
@@ -86,6 +87,8 @@ func CommonMain(
 	argLines []string,
 	driverConfig *config.DriverConfig,
 ) error {
+	audit.SetTool("trctv")
+
 	if memonly.IsMemonly() {
 		memprotectopts.MemProtectInit(nil)
 	}
@@ -115,6 +118,18 @@ func CommonMain(
 			fmt.Fprintf(flagset.Output(), "      Path: <mount>/<env>/<secret>  e.g. super-secrets/dev/DataFlowStatistics\n")
 			fmt.Fprintf(flagset.Output(), "  %s patch <path> <key>=<value> ...\n", argLines[0])
 			fmt.Fprintf(flagset.Output(), "      Update one or more keys at a secret store path (requires elevated access)\n")
+			fmt.Fprintf(flagset.Output(), "  %s diff <path> -from=<number> -to=<number> [-showValues]\n", argLines[0])
+			fmt.Fprintf(flagset.Output(), "      Show keys added, changed or removed between two versions (values masked by default)\n")
+			fmt.Fprintf(flagset.Output(), "  %s history <path>\n", argLines[0])
+			fmt.Fprintf(flagset.Output(), "      List all versions of a secret store path with created and deleted times\n")
+			fmt.Fprintf(flagset.Output(), "  %s rollback <path> -version=<number>\n", argLines[0])
+			fmt.Fprintf(flagset.Output(), "      Write an earlier version back as the newest version (requires elevated access)\n")
+			fmt.Fprintf(flagset.Output(), "  %s delete <path> -confirm [-hard]\n", argLines[0])
+			fmt.Fprintf(flagset.Output(), "      Soft delete the latest version, or destroy all versions with -hard (requires elevated access)\n")
+			fmt.Fprintf(flagset.Output(), "  %s export <path> [<path> ...] -out=<bundle> [-publicKey=<pem>]\n", argLines[0])
+			fmt.Fprintf(flagset.Output(), "      Write every secret below the paths to an encrypted bundle (passphrase from %s or prompt)\n", bundlePassphraseEnv)
+			fmt.Fprintf(flagset.Output(), "  %s import <bundle> [-privateKey=<pem>] [-overwrite]\n", argLines[0])
+			fmt.Fprintf(flagset.Output(), "      Write a bundle into -env, refusing to replace differing data unless -overwrite is set\n")
 			flagset.PrintDefaults()
 		}
 		localEnvPtr = flagset.String("env", "", "Environment override (default: embedded in path)")
@@ -125,7 +140,16 @@ func CommonMain(
 	}
 
 	insecurePtr := flagset.Bool("insecure", false, "Allow insecure SSL connections")
-	versionPtr := flagset.String("version", "", "Specific secret version for tv get")
+	versionPtr := flagset.String("version", "", "Specific secret version for tv get and tv rollback")
+	fromPtr := flagset.String("from", "", "Starting version for tv diff")
+	toPtr := flagset.String("to", "", "Ending version for tv diff")
+	showValuesPtr := flagset.Bool("showValues", false, "Show unmasked values in tv diff output")
+	confirmPtr := flagset.Bool("confirm", false, "Confirm a destructive tv delete")
+	hardPtr := flagset.Bool("hard", false, "Destroy all versions and metadata in tv delete")
+	outPtr := flagset.String("out", "", "Bundle file written by tv export")
+	publicKeyPtr := flagset.String("publicKey", "", "PEM X25519 public key used to encrypt a tv export bundle instead of a passphrase")
+	privateKeyPtr := flagset.String("privateKey", "", "PEM X25519 private key used to decrypt a tv import bundle")
+	overwritePtr := flagset.Bool("overwrite", false, "Replace existing data that conflicts with a tv import bundle")
 	logFilePtr := flagset.String("log", "./"+coreopts.BuildOptions.GetFolderPrefix(nil)+"tv.log", "Output path for log file")
 	pingPtr := flagset.Bool("ping", false, "Ping vault.")
 
@@ -154,6 +178,22 @@ func CommonMain(
 			case (arg == "-version" || arg == "--version") && i+1 < len(argLines):
 				i++
 				*versionPtr = argLines[i]
+			case strings.HasPrefix(arg, "-from="):
+				*fromPtr = arg[6:]
+			case arg == "-from" && i+1 < len(argLines):
+				i++
+				*fromPtr = argLines[i]
+			case strings.HasPrefix(arg, "-to="):
+				*toPtr = arg[4:]
+			case arg == "-to" && i+1 < len(argLines):
+				i++
+				*toPtr = argLines[i]
+			case arg == "-showValues" || arg == "--showValues":
+				*showValuesPtr = true
+			case arg == "-confirm" || arg == "--confirm":
+				*confirmPtr = true
+			case arg == "-hard" || arg == "--hard":
+				*hardPtr = true
 			case arg == "-insecure" || arg == "--insecure":
 				*insecurePtr = true
 			case arg == "-ping" || arg == "--ping":
@@ -178,23 +218,34 @@ func CommonMain(
 		return nil
 	}
 
-	if *versionPtr != "" {
-		versionNum, convErr := strconv.Atoi(*versionPtr)
-		if convErr != nil || versionNum <= 0 {
-			return fmt.Errorf("invalid --version value %q: must be a positive number", *versionPtr)
+	versionFlags := []string{"--version", "-from", "-to"}
+	for i, versionValue := range []string{*versionPtr, *fromPtr, *toPtr} {
+		if versionValue != "" {
+			versionNum, convErr := strconv.Atoi(versionValue)
+			if convErr != nil || versionNum <= 0 {
+				return fmt.Errorf("invalid %s value %q: must be a positive number", versionFlags[i], versionValue)
+			}
 		}
 	}
 
 	// sub-command, path
 	if len(positionalArgs) < 2 {
 		flagset.Usage()
-		return errors.New("usage: tv <list|get|patch> <path> [key=value ...]")
+		return errors.New("usage: tv <list|get|patch|diff|history|rollback|delete|export|import> <path> [key=value ...]")
 	}
 	subCmd := positionalArgs[0]
 	userPath := positionalArgs[1]
 
+	if isShellCmd && (subCmd == "export" || subCmd == "import") {
+		return fmt.Errorf("tv %s reads and writes bundle files on disk and is not available in the shell", subCmd)
+	}
+
 	// --- Environment resolution ---
 	vaultPath, pathEnv := parseTvPath(userPath)
+	if subCmd == "import" {
+		// The positional argument is the bundle file; env comes from -env.
+		vaultPath, pathEnv = "", ""
+	}
 	env := pathEnv
 	if localEnvPtr != nil && *localEnvPtr != "" {
 		// Explicit -env flag overrides embedded env.
@@ -283,9 +334,29 @@ func CommonMain(
 			return errors.New("patch requires at least one key=value argument")
 		}
 		return executePatch(vaultPath, positionalArgs[2:], mod, logger)
+	case "diff":
+		return executeDiff(vaultPath, *fromPtr, *toPtr, *showValuesPtr, mod)
+	case "history":
+		return executeHistory(vaultPath, mod, logger)
+	case "rollback":
+		return executeRollback(vaultPath, *versionPtr, mod, logger)
+	case "delete":
+		return executeDelete(vaultPath, *hardPtr, *confirmPtr, mod, logger)
+	case "export":
+		exportPaths := []string{vaultPath}
+		for _, extraPath := range positionalArgs[2:] {
+			extraVaultPath, extraEnv := parseTvPath(extraPath)
+			if extraEnv != "" && extraEnv != pathEnv {
+				return fmt.Errorf("all export paths must share one env: %s", extraPath)
+			}
+			exportPaths = append(exportPaths, extraVaultPath)
+		}
+		return executeExport(exportPaths, env, &bundleOptions{bundleFile: *outPtr, publicKeyFile: *publicKeyPtr}, mod, logger)
+	case "import":
+		return executeImport(env, &bundleOptions{bundleFile: userPath, privateKeyFile: *privateKeyPtr, overwrite: *overwritePtr}, mod, logger)
 	default:
 		flagset.Usage()
-		return fmt.Errorf("unknown sub-command %q (expected 'list', 'get' or 'patch')", subCmd)
+		return fmt.Errorf("unknown sub-command %q (expected 'list', 'get', 'patch', 'diff', 'history', 'rollback', 'delete', 'export' or 'import')", subCmd)
 	}
 }
 
@@ -0,0 +1,141 @@
+package trctvbase
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A bundle is a gzipped tar archive sealed with trcseal under bundleMagic.
+// The tar archive holds a manifest.json plus one json file per exported path,
+// named after its mount relative path (super-secrets/..., values/..., templates/...)
+// so that the vault layout is preserved when the bundle is imported.
+const (
+	bundleMagic        = "trctv-bundle-v1\n"
+	bundleManifestName = "manifest.json"
+)
+
+type bundleManifest struct {
+	Env         string   `json:"env"`
+	Roots       []string `json:"roots"`
+	CreatedTime string   `json:"createdTime"`
+	Paths       []string `json:"paths"`
+}
+
+// bundleEntry is a single secret store path and the data read from it.
+type bundleEntry struct {
+	Path string
+	Data map[string]any
+}
+
+// packBundle archives the entries into a gzipped tar.
+func packBundle(env string, roots []string, entries []bundleEntry) ([]byte, error) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	manifest := bundleManifest{
+		Env:         env,
+		Roots:       roots,
+		CreatedTime: time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, entry := range entries {
+		manifest.Paths = append(manifest.Paths, entry.Path)
+	}
+
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	writeFile := func(name string, content []byte) error {
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o600,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		_, err := tarWriter.Write(content)
+		return err
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(bundleManifestName, manifestBytes); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		dataBytes, err := json.Marshal(entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode %s: %w", entry.Path, err)
+		}
+		if err := writeFile(entry.Path+".json", dataBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
+
+// unpackBundle reads the manifest and entries out of a gzipped tar produced by packBundle.
+func unpackBundle(archive []byte) (*bundleManifest, []bundleEntry, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+
+	var manifest *bundleManifest
+	entries := []bundleEntry{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if header.Name == bundleManifestName {
+			manifest = &bundleManifest{}
+			if err := json.Unmarshal(content, manifest); err != nil {
+				return nil, nil, fmt.Errorf("malformed bundle manifest: %w", err)
+			}
+			continue
+		}
+		entryPath := strings.TrimSuffix(header.Name, ".json")
+		if path.Clean(entryPath) != entryPath || strings.HasPrefix(entryPath, "/") || strings.HasPrefix(entryPath, "..") {
+			return nil, nil, fmt.Errorf("invalid path in bundle: %s", header.Name)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		data := map[string]any{}
+		if err := decoder.Decode(&data); err != nil {
+			return nil, nil, fmt.Errorf("malformed bundle entry %s: %w", header.Name, err)
+		}
+		entries = append(entries, bundleEntry{Path: entryPath, Data: data})
+	}
+	if manifest == nil {
+		return nil, nil, errors.New("bundle has no manifest")
+	}
+	return manifest, entries, nil
+}
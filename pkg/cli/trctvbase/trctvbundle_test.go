@@ -0,0 +1,77 @@
+package trctvbase
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/trimble-oss/tierceron/pkg/trcseal"
+)
+
+func testBundleEntries() []bundleEntry {
+	return []bundleEntry{
+		{Path: "values/Restricted/Foo", Data: map[string]any{"endpoint": "https://example"}},
+		{Path: "super-secrets/Restricted/Foo", Data: map[string]any{"password": "secret", "port": json.Number("8080")}},
+	}
+}
+
+func checkBundleRoundTrip(t *testing.T, sealed []byte, bundleKey *trcseal.Key) {
+	archive, err := trcseal.Open(bundleMagic, sealed, bundleKey)
+	if err != nil {
+		t.Fatalf("Expected bundle to open, got %v", err)
+	}
+	manifest, entries, err := unpackBundle(archive)
+	if err != nil {
+		t.Fatalf("Expected bundle to unpack, got %v", err)
+	}
+	if manifest.Env != "dev" || len(manifest.Paths) != 2 {
+		t.Fatalf("Unexpected manifest: %+v", manifest)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Path == "super-secrets/Restricted/Foo" {
+			if entry.Data["password"] != "secret" || entry.Data["port"] != json.Number("8080") {
+				t.Fatalf("Unexpected data for %s: %v", entry.Path, entry.Data)
+			}
+		}
+	}
+}
+
+func TestBundlePassphraseRoundTrip(t *testing.T) {
+	archive, err := packBundle("dev", []string{"super-secrets/Restricted", "values/Restricted"}, testBundleEntries())
+	if err != nil {
+		t.Fatalf("Expected bundle to pack, got %v", err)
+	}
+	sealed, err := trcseal.Seal(bundleMagic, archive, &trcseal.Key{Passphrase: []byte("correct horse")})
+	if err != nil {
+		t.Fatalf("Expected bundle to seal, got %v", err)
+	}
+	checkBundleRoundTrip(t, sealed, &trcseal.Key{Passphrase: []byte("correct horse")})
+
+	if _, err := trcseal.Open(bundleMagic, sealed, &trcseal.Key{Passphrase: []byte("wrong horse")}); err == nil {
+		t.Fatalf("Expected wrong passphrase to fail")
+	}
+}
+
+func TestBundleX25519RoundTrip(t *testing.T) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %v", err)
+	}
+	archive, err := packBundle("dev", []string{"super-secrets/Restricted", "values/Restricted"}, testBundleEntries())
+	if err != nil {
+		t.Fatalf("Expected bundle to pack, got %v", err)
+	}
+	sealed, err := trcseal.Seal(bundleMagic, archive, &trcseal.Key{PublicKey: privateKey.PublicKey()})
+	if err != nil {
+		t.Fatalf("Expected bundle to seal, got %v", err)
+	}
+	checkBundleRoundTrip(t, sealed, &trcseal.Key{PrivateKey: privateKey})
+
+	if _, err := trcseal.Open(bundleMagic, sealed, &trcseal.Key{Passphrase: []byte("not a key")}); err == nil {
+		t.Fatalf("Expected passphrase to fail on a public key bundle")
+	}
+}
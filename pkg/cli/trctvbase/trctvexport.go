@@ -0,0 +1,268 @@
+package trctvbase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/trimble-oss/tierceron/pkg/trcseal"
+	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
+
+	tm "golang.org/x/term"
+)
+
+// bundlePassphraseEnv may hold the bundle passphrase so that export/import can run unattended.
+const bundlePassphraseEnv = "TRCTV_BUNDLE_PASSPHRASE"
+
+// bundleOptions carries the export/import specific flags.
+type bundleOptions struct {
+	bundleFile     string
+	publicKeyFile  string
+	privateKeyFile string
+	overwrite      bool
+}
+
+// readBundlePassphrase returns the passphrase from the environment or prompts for it.
+func readBundlePassphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(bundlePassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	fmt.Fprint(os.Stderr, "Bundle passphrase: ")
+	passphrase, err := tm.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Re-enter bundle passphrase: ")
+		confirmPassphrase, err := tm.ReadPassword(int(syscall.Stdin))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, confirmPassphrase) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// collectBundleEntries walks vaultPath recursively and reads the data at every leaf.
+func collectBundleEntries(vaultPath string, mod *helperkv.Modifier, logger *log.Logger) ([]bundleEntry, error) {
+	entries := []bundleEntry{}
+
+	secret, err := mod.List(vaultPath, logger)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list %s: %w", vaultPath, err)
+	}
+
+	keys := []string{}
+	if secret != nil && secret.Data != nil {
+		if rawKeys, ok := secret.Data["keys"].([]any); ok {
+			for _, key := range rawKeys {
+				keys = append(keys, fmt.Sprint(key))
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		// Not a folder, so treat the path itself as a secret.
+		data, readErr := mod.ReadData(vaultPath)
+		if readErr != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", vaultPath, readErr)
+		}
+		if data != nil {
+			entries = append(entries, bundleEntry{Path: vaultPath, Data: data})
+		}
+		return entries, nil
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := strings.TrimSuffix(vaultPath, "/") + "/" + strings.TrimSuffix(key, "/")
+		if strings.HasSuffix(key, "/") {
+			childEntries, walkErr := collectBundleEntries(childPath, mod, logger)
+			if walkErr != nil {
+				return nil, walkErr
+			}
+			entries = append(entries, childEntries...)
+			continue
+		}
+		data, readErr := mod.ReadData(childPath)
+		if readErr != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", childPath, readErr)
+		}
+		if data != nil { // Latest version deleted.
+			entries = append(entries, bundleEntry{Path: childPath, Data: data})
+		}
+	}
+	return entries, nil
+}
+
+// executeExport walks each of the vault paths and writes all secrets below them to an encrypted bundle.
+func executeExport(vaultPaths []string, env string, opts *bundleOptions, mod *helperkv.Modifier, logger *log.Logger) error {
+	if opts.bundleFile == "" {
+		fmt.Fprintln(outWriter, "Usage: tv export <path> [<path> ...] -out=<bundle> [-publicKey=<pem>]")
+		return errors.New("export requires -out")
+	}
+
+	bundleKey := &trcseal.Key{}
+	if opts.publicKeyFile != "" {
+		pemBytes, err := os.ReadFile(opts.publicKeyFile)
+		if err != nil {
+			return fmt.Errorf("unable to read public key: %w", err)
+		}
+		bundleKey.PublicKey, err = trcseal.ParsePublicKey(pemBytes)
+		if err != nil {
+			return fmt.Errorf("unable to parse public key: %w", err)
+		}
+	} else {
+		passphrase, err := readBundlePassphrase(true)
+		if err != nil {
+			return fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		bundleKey.Passphrase = passphrase
+	}
+
+	entries := []bundleEntry{}
+	for _, vaultPath := range vaultPaths {
+		pathEntries, err := collectBundleEntries(vaultPath, mod, logger)
+		if err != nil {
+			fmt.Fprintf(outWriter, "Error exporting %s: %v\n", vaultPath, err)
+			return err
+		}
+		entries = append(entries, pathEntries...)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(outWriter, "No secrets found to export.")
+		return errors.New("nothing to export")
+	}
+
+	archive, err := packBundle(env, vaultPaths, entries)
+	if err != nil {
+		return fmt.Errorf("unable to build bundle: %w", err)
+	}
+	sealed, err := trcseal.Seal(bundleMagic, archive, bundleKey)
+	if err != nil {
+		return fmt.Errorf("unable to encrypt bundle: %w", err)
+	}
+	if err := os.WriteFile(opts.bundleFile, sealed, 0o600); err != nil {
+		return fmt.Errorf("unable to write bundle: %w", err)
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintf(outWriter, "  %s\n", entry.Path)
+	}
+	fmt.Fprintf(outWriter, "Exported %d paths from env %s to %s.\n", len(entries), env, opts.bundleFile)
+	return nil
+}
+
+// differingKeys returns the keys whose values are not the same in both maps.
+func differingKeys(existing map[string]any, incoming map[string]any) []string {
+	diffKeys := []string{}
+	for k, incomingValue := range incoming {
+		if existingValue, ok := existing[k]; !ok || fmt.Sprint(existingValue) != fmt.Sprint(incomingValue) {
+			diffKeys = append(diffKeys, k)
+		}
+	}
+	for k := range existing {
+		if _, ok := incoming[k]; !ok {
+			diffKeys = append(diffKeys, k)
+		}
+	}
+	sort.Strings(diffKeys)
+	return diffKeys
+}
+
+// executeImport decrypts a bundle and writes its paths into the current env.
+// Paths that already hold different data are reported as conflicts, and nothing
+// is written unless overwrite is set.
+func executeImport(env string, opts *bundleOptions, mod *helperkv.Modifier, logger *log.Logger) error {
+	sealed, err := os.ReadFile(opts.bundleFile)
+	if err != nil {
+		return fmt.Errorf("unable to read bundle: %w", err)
+	}
+
+	bundleKey := &trcseal.Key{}
+	if opts.privateKeyFile != "" {
+		pemBytes, err := os.ReadFile(opts.privateKeyFile)
+		if err != nil {
+			return fmt.Errorf("unable to read private key: %w", err)
+		}
+		bundleKey.PrivateKey, err = trcseal.ParsePrivateKey(pemBytes)
+		if err != nil {
+			return fmt.Errorf("unable to parse private key: %w", err)
+		}
+	} else {
+		passphrase, err := readBundlePassphrase(false)
+		if err != nil {
+			return fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		bundleKey.Passphrase = passphrase
+	}
+
+	archive, err := trcseal.Open(bundleMagic, sealed, bundleKey)
+	if err != nil {
+		return err
+	}
+	manifest, entries, err := unpackBundle(archive)
+	if err != nil {
+		return fmt.Errorf("unable to unpack bundle: %w", err)
+	}
+	fmt.Fprintf(outWriter, "Bundle exported from env %s at %s; importing %d paths into env %s.\n", manifest.Env, manifest.CreatedTime, len(entries), env)
+
+	toWrite := []bundleEntry{}
+	conflicts := 0
+	unchanged := 0
+	for _, entry := range entries {
+		existing, readErr := mod.ReadData(entry.Path)
+		if readErr != nil {
+			fmt.Fprintf(outWriter, "Error reading %s: %v\n", entry.Path, readErr)
+			return readErr
+		}
+		if existing == nil {
+			fmt.Fprintf(outWriter, "+ %s\n", entry.Path)
+			toWrite = append(toWrite, entry)
+			continue
+		}
+		diffKeys := differingKeys(existing, entry.Data)
+		if len(diffKeys) == 0 {
+			unchanged++
+			continue
+		}
+		if opts.overwrite {
+			fmt.Fprintf(outWriter, "~ %s (overwriting keys: %s)\n", entry.Path, strings.Join(diffKeys, ", "))
+			toWrite = append(toWrite, entry)
+		} else {
+			conflicts++
+			fmt.Fprintf(outWriter, "! %s conflicts on keys: %s\n", entry.Path, strings.Join(diffKeys, ", "))
+		}
+	}
+
+	if conflicts > 0 {
+		fmt.Fprintf(outWriter, "%d conflicting paths; nothing written. Re-run with -overwrite to replace existing data.\n", conflicts)
+		return fmt.Errorf("%d conflicting paths", conflicts)
+	}
+
+	for _, entry := range toWrite {
+		warnings, writeErr := mod.Write(entry.Path, entry.Data, logger)
+		if writeErr != nil {
+			fmt.Fprintf(outWriter, "Error writing to %s: %v\n", entry.Path, writeErr)
+			return writeErr
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(outWriter, "Warning: %s\n", w)
+		}
+	}
+
+	fmt.Fprintf(outWriter, "Imported %d paths, %d unchanged.\n", len(toWrite), unchanged)
+	return nil
+}
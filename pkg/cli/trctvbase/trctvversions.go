@@ -0,0 +1,267 @@
+package trctvbase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
+)
+
+const maskedValue = "********"
+
+// readVersionData reads the data stored at a specific version of vaultPath.
+// The modifier version is always restored so later reads see the latest data.
+func readVersionData(vaultPath string, version string, mod *helperkv.Modifier) (map[string]any, error) {
+	priorVersion := mod.Version
+	mod.Version = version
+	defer func() { mod.Version = priorVersion }()
+
+	return mod.ReadData(vaultPath)
+}
+
+// displayValue returns the value as printable text, masking it unless showValues is set.
+func displayValue(value any, showValues bool) string {
+	if !showValues {
+		return maskedValue
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// executeDiff prints a key level diff between two versions of vaultPath.
+// Values are masked unless showValues is set.
+func executeDiff(vaultPath string, fromVersion string, toVersion string, showValues bool, mod *helperkv.Modifier) error {
+	if fromVersion == "" || toVersion == "" {
+		fmt.Fprintln(outWriter, "Usage: tv diff <path> -from=<number> -to=<number> [-showValues]")
+		return errors.New("diff requires both -from and -to versions")
+	}
+
+	fromData, err := readVersionData(vaultPath, fromVersion, mod)
+	if err != nil {
+		fmt.Fprintf(outWriter, "Error reading %s version %s: %v\n", vaultPath, fromVersion, err)
+		return err
+	}
+	toData, err := readVersionData(vaultPath, toVersion, mod)
+	if err != nil {
+		fmt.Fprintf(outWriter, "Error reading %s version %s: %v\n", vaultPath, toVersion, err)
+		return err
+	}
+
+	fmt.Fprintf(outWriter, "Path: %s (version %s -> %s)\n", vaultPath, fromVersion, toVersion)
+	added, removed, changed := 0, 0, 0
+	for _, change := range diffData(fromData, toData) {
+		switch change.op {
+		case diffAdded:
+			added++
+			fmt.Fprintf(outWriter, "+ %s = %s\n", change.key, displayValue(change.to, showValues))
+		case diffRemoved:
+			removed++
+			fmt.Fprintf(outWriter, "- %s = %s\n", change.key, displayValue(change.from, showValues))
+		case diffChanged:
+			changed++
+			if showValues {
+				fmt.Fprintf(outWriter, "~ %s = %v -> %v\n", change.key, change.from, change.to)
+			} else {
+				fmt.Fprintf(outWriter, "~ %s\n", change.key)
+			}
+		}
+	}
+	if added+removed+changed == 0 {
+		fmt.Fprintln(outWriter, "No differences.")
+		return nil
+	}
+	fmt.Fprintf(outWriter, "%d added, %d changed, %d removed.\n", added, changed, removed)
+	return nil
+}
+
+const (
+	diffAdded   = '+'
+	diffRemoved = '-'
+	diffChanged = '~'
+)
+
+// keyDiff is a key that differs between two versions of a path.
+type keyDiff struct {
+	op   byte
+	key  string
+	from any
+	to   any
+}
+
+// diffData returns the keys added, removed or changed from fromData to
+// toData, sorted by key.  Either may be nil.
+func diffData(fromData map[string]any, toData map[string]any) []keyDiff {
+	keySet := map[string]bool{}
+	for k := range fromData {
+		keySet[k] = true
+	}
+	for k := range toData {
+		keySet[k] = true
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	diffs := []keyDiff{}
+	for _, k := range keys {
+		fromValue, inFrom := fromData[k]
+		toValue, inTo := toData[k]
+		switch {
+		case !inFrom:
+			diffs = append(diffs, keyDiff{op: diffAdded, key: k, to: toValue})
+		case !inTo:
+			diffs = append(diffs, keyDiff{op: diffRemoved, key: k, from: fromValue})
+		case fmt.Sprintf("%v", fromValue) != fmt.Sprintf("%v", toValue):
+			diffs = append(diffs, keyDiff{op: diffChanged, key: k, from: fromValue, to: toValue})
+		}
+	}
+	return diffs
+}
+
+// executeHistory lists every version of vaultPath with its created and deleted times.
+func executeHistory(vaultPath string, mod *helperkv.Modifier, logger *log.Logger) error {
+	versions, err := mod.ReadVersionMetadata(vaultPath, logger)
+	if err != nil {
+		fmt.Fprintf(outWriter, "Error reading version history for %s: %v\n", vaultPath, err)
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Fprintf(outWriter, "No versions found at: %s\n", vaultPath)
+		return nil
+	}
+
+	versionNums := make([]int, 0, len(versions))
+	for v := range versions {
+		versionNum, convErr := strconv.Atoi(v)
+		if convErr != nil {
+			continue
+		}
+		versionNums = append(versionNums, versionNum)
+	}
+	sort.Ints(versionNums)
+
+	fmt.Fprintf(outWriter, "Path: %s\n", vaultPath)
+	fmt.Fprintf(outWriter, "%-8s %-32s %-32s %s\n", "Version", "Created", "Deleted", "Destroyed")
+	for _, versionNum := range versionNums {
+		created, deleted, destroyed := "", "", false
+		if versionMeta, ok := versions[strconv.Itoa(versionNum)].(map[string]any); ok {
+			if createdTime, ok := versionMeta["created_time"].(string); ok {
+				created = createdTime
+			}
+			if deletionTime, ok := versionMeta["deletion_time"].(string); ok {
+				deleted = deletionTime
+			}
+			if destroyedFlag, ok := versionMeta["destroyed"].(bool); ok {
+				destroyed = destroyedFlag
+			}
+		}
+		if deleted == "" {
+			deleted = "-"
+		}
+		fmt.Fprintf(outWriter, "%-8d %-32s %-32s %t\n", versionNum, created, deleted, destroyed)
+	}
+	return nil
+}
+
+// executeRollback reads an earlier version of vaultPath and writes it back as the newest version.
+func executeRollback(vaultPath string, version string, mod *helperkv.Modifier, logger *log.Logger) error {
+	if version == "" {
+		fmt.Fprintln(outWriter, "Usage: tv rollback <path> -version=<number>")
+		return errors.New("rollback requires -version")
+	}
+	versions, err := mod.ReadVersionMetadata(vaultPath, logger)
+	if err != nil {
+		fmt.Fprintf(outWriter, "Error reading version history for %s: %v\n", vaultPath, err)
+		return err
+	}
+	if err := checkRollbackVersion(versions, version); err != nil {
+		fmt.Fprintf(outWriter, "Unable to roll back %s: %v\n", vaultPath, err)
+		return err
+	}
+
+	data, err := readVersionData(vaultPath, version, mod)
+	if err != nil {
+		fmt.Fprintf(outWriter, "Error reading %s version %s: %v\n", vaultPath, version, err)
+		return err
+	}
+	if data == nil {
+		fmt.Fprintf(outWriter, "Version %s of %s is deleted or destroyed; nothing to roll back to.\n", version, vaultPath)
+		return fmt.Errorf("no data at version %s", version)
+	}
+
+	warnings, writeErr := mod.Write(vaultPath, data, logger)
+	if writeErr != nil {
+		fmt.Fprintf(outWriter, "Error writing to %s: %v\n", vaultPath, writeErr)
+		return writeErr
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(outWriter, "Warning: %s\n", w)
+	}
+
+	fmt.Fprintf(outWriter, "Rolled back %s to version %s (%d keys).\n", vaultPath, version, len(data))
+	return nil
+}
+
+// checkRollbackVersion ensures version is an earlier version of a path with
+// the version metadata versions that still has data to roll back to.
+func checkRollbackVersion(versions map[string]any, version string) error {
+	versionNum, err := strconv.Atoi(version)
+	if err != nil || versionNum < 1 {
+		return fmt.Errorf("invalid version %s", version)
+	}
+	latest := 0
+	for v := range versions {
+		if n, convErr := strconv.Atoi(v); convErr == nil && n > latest {
+			latest = n
+		}
+	}
+	versionMeta, ok := versions[strconv.Itoa(versionNum)].(map[string]any)
+	switch {
+	case !ok:
+		return fmt.Errorf("version %s not found", version)
+	case versionNum == latest:
+		return fmt.Errorf("version %s is already the latest version", version)
+	case versionMeta["destroyed"] == true:
+		return fmt.Errorf("version %s is destroyed", version)
+	}
+	if deletionTime, _ := versionMeta["deletion_time"].(string); deletionTime != "" {
+		return fmt.Errorf("version %s is deleted", version)
+	}
+	return nil
+}
+
+// executeDelete soft deletes the latest version of vaultPath, or permanently removes
+// all versions and metadata when hard is set. Nothing is deleted without confirm.
+func executeDelete(vaultPath string, hard bool, confirm bool, mod *helperkv.Modifier, logger *log.Logger) error {
+	deleteKind := "soft delete the latest version of"
+	if hard {
+		deleteKind = "permanently destroy all versions and metadata of"
+	}
+	if !confirm {
+		fmt.Fprintf(outWriter, "This would %s %s.\n", deleteKind, vaultPath)
+		fmt.Fprintln(outWriter, "Re-run with -confirm to proceed.")
+		return errors.New("delete requires -confirm")
+	}
+
+	var err error
+	if hard {
+		_, err = mod.HardDelete(vaultPath, logger)
+	} else {
+		_, err = mod.SoftDelete(vaultPath, logger)
+	}
+	if err != nil {
+		fmt.Fprintf(outWriter, "Error deleting %s: %v\n", vaultPath, err)
+		return err
+	}
+
+	if hard {
+		fmt.Fprintf(outWriter, "Destroyed all versions of %s.\n", vaultPath)
+	} else {
+		fmt.Fprintf(outWriter, "Deleted latest version of %s.\n", vaultPath)
+	}
+	return nil
+}
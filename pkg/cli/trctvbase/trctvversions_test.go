@@ -0,0 +1,65 @@
+package trctvbase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffData(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     map[string]any
+		to       map[string]any
+		expected []keyDiff
+	}{
+		{"identical", map[string]any{"a": "1"}, map[string]any{"a": "1"}, []keyDiff{}},
+		{"both empty", nil, nil, []keyDiff{}},
+		{"from deleted version", nil, map[string]any{"a": "1"}, []keyDiff{{op: diffAdded, key: "a", to: "1"}}},
+		{"to deleted version", map[string]any{"a": "1"}, nil, []keyDiff{{op: diffRemoved, key: "a", from: "1"}}},
+		{
+			"sorted changes",
+			map[string]any{"c": "3", "b": "2", "a": "1", "port": 8080},
+			map[string]any{"d": "4", "b": "two", "a": "1", "port": "8080"},
+			[]keyDiff{
+				{op: diffChanged, key: "b", from: "2", to: "two"},
+				{op: diffRemoved, key: "c", from: "3"},
+				{op: diffAdded, key: "d", to: "4"},
+			},
+		},
+	}
+	for _, test := range tests {
+		if diffs := diffData(test.from, test.to); !reflect.DeepEqual(diffs, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, diffs)
+		}
+	}
+}
+
+func TestCheckRollbackVersion(t *testing.T) {
+	versions := map[string]any{
+		"1":  map[string]any{"created_time": "2026-01-01T00:00:00Z", "deletion_time": "", "destroyed": false},
+		"2":  map[string]any{"created_time": "2026-01-02T00:00:00Z", "deletion_time": "2026-01-03T00:00:00Z", "destroyed": false},
+		"3":  map[string]any{"created_time": "2026-01-04T00:00:00Z", "deletion_time": "", "destroyed": true},
+		"4":  map[string]any{"created_time": "2026-01-05T00:00:00Z", "deletion_time": "", "destroyed": false},
+		"10": map[string]any{"created_time": "2026-01-06T00:00:00Z", "deletion_time": "", "destroyed": false},
+	}
+	tests := []struct {
+		version string
+		ok      bool
+	}{
+		{"1", true},
+		{"4", true},
+		{"2", false},  // deleted
+		{"3", false},  // destroyed
+		{"10", false}, // latest
+		{"5", false},  // unknown
+		{"0", false},
+		{"-1", false},
+		{"latest", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if err := checkRollbackVersion(versions, test.version); (err == nil) != test.ok {
+			t.Errorf("checkRollbackVersion(%q): expected ok %v, got %v", test.version, test.ok, err)
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +18,7 @@ import (
 	"github.com/trimble-oss/tierceron-core/v2/core/coreconfig"
 	"github.com/trimble-oss/tierceron-core/v2/core/coreconfig/cache"
 	"github.com/trimble-oss/tierceron/buildopts/coreopts"
+	"github.com/trimble-oss/tierceron/pkg/trcx/extract"
 	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
 	"github.com/trimble-oss/tierceron/pkg/utils/config"
 	"github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
@@ -37,6 +41,113 @@ func receiver(configCtx *config.ConfigContext) {
 	}
 }
 
+// writeSeedDiffReport reports the structural differences between the seeds
+// generated for each environment.
+func writeSeedDiffReport(configCtx *config.ConfigContext, sectionCount int, format string, reportFile string, outWriter io.Writer) {
+	for retry := 0; configCtx.ResultMap.Count() < len(configCtx.EnvSlice)*sectionCount && retry < 3; retry++ {
+		time.Sleep(time.Second)
+	}
+	seeds := map[string]*string{}
+	for _, env := range configCtx.EnvSlice {
+		if seed, ok := configCtx.ResultMap.Get(env + "||" + env + "_seed.yml"); ok {
+			seeds[env] = seed
+		}
+	}
+	report, err := eUtils.SemanticSeedDiff(configCtx.EnvSlice, seeds)
+	if err != nil {
+		fmt.Fprintln(outWriter, "Unable to diff seeds: "+err.Error())
+		return
+	}
+	reportWriter := outWriter
+	if len(reportFile) > 0 {
+		f, err := os.OpenFile(reportFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			fmt.Fprintln(outWriter, "Unable to create diff report: "+err.Error())
+			return
+		}
+		defer f.Close()
+		reportWriter = f
+	}
+	if err := report.Write(reportWriter, format); err != nil {
+		fmt.Fprintln(outWriter, "Unable to write diff report: "+err.Error())
+		return
+	}
+	if len(reportFile) > 0 {
+		fmt.Fprintln(outWriter, "Seed diff report written to "+reportFile)
+	}
+}
+
+// seedFiles lists the files under dir with suffix, or dir itself if it is a file.
+func seedFiles(driverConfig *config.DriverConfig, dir string, suffix string) []string {
+	files := []string{}
+	if driverConfig.ReadMemCache && driverConfig.MemFs != nil {
+		driverConfig.MemFs.Walk(dir, func(p string, isDir bool) error {
+			if !isDir && strings.HasSuffix(p, suffix) {
+				files = append(files, p)
+			}
+			return nil
+		})
+	} else {
+		filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && (p == dir || strings.HasSuffix(p, suffix)) {
+				files = append(files, filepath.ToSlash(p))
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// validateSeeds checks the seed files under seedDir against the keys declared
+// by the templates under startDir and reports file:line diagnostics.  Returns
+// false when any seed has errors.
+func validateSeeds(driverConfig *config.DriverConfig, startDir string, seedDir string, outWriter io.Writer) bool {
+	templatePaths := []string{}
+	for _, templatePath := range seedFiles(driverConfig, startDir, ".tmpl") {
+		if strings.Contains(templatePath, "/Common/") {
+			continue
+		}
+		templatePaths = append(templatePaths, templatePath)
+	}
+	schema, err := extract.SeedSchemaFromTemplates(driverConfig, templatePaths)
+	if err != nil {
+		fmt.Fprintln(outWriter, "Unable to read templates: "+err.Error())
+		return false
+	}
+
+	errorCount, warningCount := 0, 0
+	seeds := seedFiles(driverConfig, seedDir, ".yml")
+	for _, seedPath := range seeds {
+		var seed []byte
+		if driverConfig.ReadMemCache && driverConfig.MemFs != nil {
+			seedFile, openErr := driverConfig.MemFs.Open(seedPath)
+			if openErr == nil {
+				seed, err = io.ReadAll(seedFile)
+			} else {
+				err = openErr
+			}
+		} else {
+			seed, err = os.ReadFile(seedPath)
+		}
+		if err != nil {
+			fmt.Fprintf(outWriter, "%s:1: %s: %s\n", seedPath, extract.SeedError, err.Error())
+			errorCount++
+			continue
+		}
+		for _, diagnostic := range extract.ValidateSeed(schema, seedPath, seed) {
+			if diagnostic.Severity == extract.SeedError {
+				errorCount++
+			} else {
+				warningCount++
+			}
+			fmt.Fprintln(outWriter, diagnostic.String())
+		}
+	}
+	fmt.Fprintf(outWriter, "Validated %d seed file(s) against %d template(s): %d error(s), %d warning(s)\n", len(seeds), len(templatePaths), errorCount, warningCount)
+	return errorCount == 0
+}
+
 // CommonMain This executable automates the creation of seed files from template file(s).
 // New seed files are written (or overwrite current seed files) to the specified directory.
 func CommonMain(ctx config.ProcessContext,
@@ -97,9 +208,12 @@ func CommonMain(ctx config.ProcessContext,
 	}
 
 	diffPtr := flagset.Bool("diff", false, "Diff files")
+	diffReportPtr := flagset.String("diffReport", "", "With -diff, compare seeds key by key without showing values and report drift as "+strings.Join(eUtils.SeedDiffFormats, ", "))
+	diffReportFilePtr := flagset.String("diffReportFile", "", "Write the -diffReport to this file instead of the console")
 	versionPtr := flagset.Bool("versions", false, "Gets version metadata information")
 	wantCertsPtr := flagset.Bool("certs", false, "Pull certificates into directory specified by endDirPtr")
 	filterTemplatePtr := flagset.String("templateFilter", "", "Specifies which templates to filter") // -templateFilter=config.yml
+	validatePtr := flagset.Bool("validate", false, "Validate the seed files in endDir (or the seed file endDir names) against the keys declared by the templates in startDir")
 
 	// If running from trcshcmd (IsShellCommand), redirect output to io/STDIO in memfs
 	var outWriter io.Writer = os.Stderr
@@ -242,6 +356,15 @@ func CommonMain(ctx config.ProcessContext,
 	} else if *diffPtr && *versionPtr {
 		fmt.Fprintln(outWriter, "-version flag cannot be used with -diff flag")
 		return
+	} else if (len(*diffReportPtr) > 0 || len(*diffReportFilePtr) > 0) && !*diffPtr {
+		fmt.Fprintln(outWriter, "-diffReport and -diffReportFile flags must be used with -diff flag")
+		return
+	} else if len(*diffReportPtr) > 0 && !slices.Contains(eUtils.SeedDiffFormats, *diffReportPtr) {
+		fmt.Fprintln(outWriter, "Unsupported -diffReport format, expected one of: "+strings.Join(eUtils.SeedDiffFormats, ", "))
+		return
+	} else if *validatePtr && (*diffPtr || *versionPtr || len(*seedPathPtr) > 0 || len(*dynamicPathPtr) > 0) {
+		fmt.Fprintln(outWriter, "-validate flag cannot be used with -diff, -versions, -seedpath or -dynamicPath flags")
+		return
 	} else if *versionPtr && len(*eUtils.RestrictedPtr) > 0 {
 		fmt.Fprintln(outWriter, "-restricted flags cannot be used with -versions flag")
 		return
@@ -272,6 +395,25 @@ func CommonMain(ctx config.ProcessContext,
 		}
 	}
 
+	if *validatePtr {
+		// Validation is local only, no vault access required.
+		validateConfig := &config.DriverConfig{
+			CoreConfig: &coreconfig.CoreConfig{
+				ExitOnFailure: false,
+				Log:           logger,
+			},
+			IsShellCommand: driverConfigBase.IsShellCommand,
+			ReadMemCache:   driverConfigBase.ReadMemCache,
+			MemFs:          driverConfigBase.MemFs,
+			StartDir:       append([]string{}, *startDirPtr),
+			EndDir:         *endDirPtr,
+		}
+		if !validateSeeds(validateConfig, *startDirPtr, *endDirPtr, outWriter) && !driverConfigBase.IsShellCommand && !kernelopts.BuildOptions.IsKernelZ() {
+			os.Exit(1)
+		}
+		return
+	}
+
 	trcxe := false
 	sectionSlice := []string{""}
 	if len(*seedPathPtr) != 0 { // Checks if seed file exists & figured out if index/restricted
@@ -932,7 +1074,9 @@ skipDiff:
 
 	waitg.Wait()
 	close(configCtx.ResultChannel)
-	if *diffPtr { // Diff if needed
+	if *diffPtr && len(*diffReportPtr) > 0 {
+		writeSeedDiffReport(configCtx, len(sectionSlice), *diffReportPtr, *diffReportFilePtr, outWriter)
+	} else if *diffPtr { // Diff if needed
 		waitg.Add(1)
 		go func(cctx *config.ConfigContext) {
 			defer waitg.Done()
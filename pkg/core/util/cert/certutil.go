@@ -22,6 +22,10 @@ import (
 	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
 )
 
+// OptionalCertSuffix marks a cert path returned by a plugin's GetConfigPaths
+// that the plugin can run without.
+const OptionalCertSuffix = "?"
+
 var certPathLocks sync.Map
 
 func getCertPathLock(certPath string) *sync.Mutex {
@@ -0,0 +1,78 @@
+// Package chatrequest defines the request/reply contract between the hive
+// kernel and its plugins.  It has no dependencies on the kernel so that
+// plugins can import it without creating import cycles.
+//
+// The kernel hands every plugin a Func under ServiceConfigKey in the
+// properties passed to Init:
+//
+//	if request, ok := chatrequest.FromConfig(*properties); ok {
+//		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+//		defer cancel()
+//		resp, err := request(ctx, "trcshcmd", &tccore.ChatMsg{ChatId: &cmdType, HookResponse: args})
+//		if errors.Is(err, chatrequest.ErrTimeout) { ... }
+//	}
+package chatrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+)
+
+// ServiceConfigKey is the properties key the kernel stores the plugin's Func under.
+const ServiceConfigKey = "chatRequest"
+
+// CancelChatId is the ChatId of the notice a target receives, with the
+// request's RoutingId, when the requester stops waiting for it.  The notice is
+// not a request and is never answered, targets doing long running work for the
+// RoutingId should abandon it.  Its Response is CancelledResponse.
+const CancelChatId = "chatrequest.cancel"
+
+// CancelledResponse is the Response of the cancellation notice.
+const CancelledResponse = "Request cancelled"
+
+// Errors returned by a Func, wrapped in a *RequestError.
+var (
+	ErrTimeout          = errors.New("chat request timed out")
+	ErrTargetNotRunning = errors.New("chat target not running")
+	ErrNotSupported     = errors.New("chat requests not supported")
+)
+
+// RequestError describes a failed request.
+type RequestError struct {
+	Target    string
+	RoutingId string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("chat request %s to %s: %v", e.RoutingId, e.Target, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Func sends msg to the target plugin and waits for the response carrying the
+// same correlation id, or until ctx is done.  The kernel fills in Name, Query
+// and RoutingId.
+type Func func(ctx context.Context, target string, msg *tccore.ChatMsg) (*tccore.ChatMsg, error)
+
+// IsCancellation reports whether msg is a cancellation notice rather than a
+// request.
+func IsCancellation(msg *tccore.ChatMsg) bool {
+	return msg != nil && msg.ChatId != nil && *msg.ChatId == CancelChatId
+}
+
+// FromConfig returns the Func the kernel provided in a plugin's properties.
+func FromConfig(properties map[string]any) (Func, bool) {
+	switch request := properties[ServiceConfigKey].(type) {
+	case Func:
+		return request, request != nil
+	case func(context.Context, string, *tccore.ChatMsg) (*tccore.ChatMsg, error):
+		return request, request != nil
+	}
+	return nil, false
+}
@@ -0,0 +1,251 @@
+// Package diagnostics defines the registry of named diagnostics hive plugins
+// offer through trcshtalk.  Like chatrequest it has no dependencies on the
+// kernel so that plugins can import it without creating import cycles.
+//
+// The kernel hands every plugin a RegisterFunc under RegisterKey in the
+// properties passed to Init, and trcshtalk a ListFunc under ListKey.  Both
+// use json so that plugins built as separate modules can call them without
+// importing this package:
+//
+//	err := diagnostics.Register(*properties, diagnostics.Diagnostic{
+//		Name:        "trcdb.tenants",
+//		Description: "Reports tenants that failed to load",
+//		Parameters:  []diagnostics.Parameter{{Name: "tenant", Description: "Only check this tenant"}},
+//		Timeout:     10 * time.Second,
+//	})
+//
+// trcshtalk invokes a diagnostic with a chat request to the plugin that
+// registered it.  The request's ChatId is the diagnostic name and its
+// HookResponse the map[string]string of parameters; the plugin answers with
+// Response.  When the timeout passes or the caller goes away the plugin is
+// sent a cancellation notice for the same RoutingId, which it recognizes with
+// chatrequest.IsCancellation and must not answer.
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Properties keys the kernel stores the registry functions under.
+const (
+	RegisterKey = "registerDiagnostic"
+	ListKey     = "listDiagnostics"
+)
+
+// DefaultTimeout is what trcshtalk waits for diagnostics registered without a
+// timeout.
+const DefaultTimeout = 30 * time.Second
+
+// ErrNotSupported is returned when the kernel did not provide the registry.
+var ErrNotSupported = errors.New("diagnostics registry not supported")
+
+// Parameter is an argument a diagnostic accepts.
+type Parameter struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Required     bool   `json:"required,omitempty"`
+	DefaultValue string `json:"default_value,omitempty"`
+}
+
+// Diagnostic describes a named diagnostic.  Plugin is filled in by the kernel.
+type Diagnostic struct {
+	Name        string
+	Plugin      string
+	Description string
+	Parameters  []Parameter
+	Timeout     time.Duration
+}
+
+// diagnosticJSON is the wire form of a Diagnostic, field names match the
+// trcshtalk DiagnosticInfo message.
+type diagnosticJSON struct {
+	Name        string      `json:"name"`
+	Plugin      string      `json:"plugin,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Parameters  []Parameter `json:"parameters,omitempty"`
+	TimeoutMs   int64       `json:"timeout_ms,omitempty"`
+}
+
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(diagnosticJSON{
+		Name:        d.Name,
+		Plugin:      d.Plugin,
+		Description: d.Description,
+		Parameters:  d.Parameters,
+		TimeoutMs:   d.Timeout.Milliseconds(),
+	})
+}
+
+func (d *Diagnostic) UnmarshalJSON(data []byte) error {
+	var wire diagnosticJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*d = Diagnostic{
+		Name:        wire.Name,
+		Plugin:      wire.Plugin,
+		Description: wire.Description,
+		Parameters:  wire.Parameters,
+		Timeout:     time.Duration(wire.TimeoutMs) * time.Millisecond,
+	}
+	return nil
+}
+
+// RegisterFunc registers the json encoded Diagnostic for the calling plugin.
+type RegisterFunc func(definition []byte) error
+
+// ListFunc returns the json encoded list of registered diagnostics.
+type ListFunc func() []byte
+
+// Validate checks a diagnostic's definition.  Names are restricted so they
+// can't be confused with the plugin:query chat routing syntax.
+func (d *Diagnostic) Validate() error {
+	if d.Name == "" {
+		return errors.New("diagnostic name is required")
+	}
+	if strings.ContainsAny(d.Name, ": \t\r\n,") {
+		return fmt.Errorf("invalid diagnostic name %q", d.Name)
+	}
+	if d.Timeout < 0 {
+		return fmt.Errorf("diagnostic %s has a negative timeout", d.Name)
+	}
+	seen := map[string]bool{}
+	for _, parameter := range d.Parameters {
+		if parameter.Name == "" {
+			return fmt.Errorf("diagnostic %s has a parameter without a name", d.Name)
+		}
+		if seen[parameter.Name] {
+			return fmt.Errorf("diagnostic %s declares parameter %s twice", d.Name, parameter.Name)
+		}
+		seen[parameter.Name] = true
+	}
+	return nil
+}
+
+// Registry holds the diagnostics registered by plugins, keyed by name.
+type Registry struct {
+	lock        sync.RWMutex
+	diagnostics map[string]Diagnostic
+}
+
+func NewRegistry() *Registry {
+	return &Registry{diagnostics: map[string]Diagnostic{}}
+}
+
+// Register adds or replaces a diagnostic of plugin.  A name registered by
+// another plugin is an error.
+func (r *Registry) Register(plugin string, d Diagnostic) error {
+	d.Plugin = plugin
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if existing, ok := r.diagnostics[d.Name]; ok && existing.Plugin != plugin {
+		return fmt.Errorf("diagnostic %s is already registered by %s", d.Name, existing.Plugin)
+	}
+	r.diagnostics[d.Name] = d
+	return nil
+}
+
+// Unregister removes all diagnostics of plugin, e.g. before it is reloaded.
+func (r *Registry) Unregister(plugin string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for name, d := range r.diagnostics {
+		if d.Plugin == plugin {
+			delete(r.diagnostics, name)
+		}
+	}
+}
+
+// Lookup returns the diagnostic registered under name.
+func (r *Registry) Lookup(name string) (Diagnostic, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	d, ok := r.diagnostics[name]
+	return d, ok
+}
+
+// List returns the diagnostics sorted by name, only those of plugin if it
+// isn't empty.
+func (r *Registry) List(plugin string) []Diagnostic {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	list := []Diagnostic{}
+	for _, d := range r.diagnostics {
+		if plugin == "" || d.Plugin == plugin {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// RegisterFunc returns the RegisterFunc handed to plugin.
+func (r *Registry) RegisterFunc(plugin string) RegisterFunc {
+	return func(definition []byte) error {
+		var d Diagnostic
+		if err := json.Unmarshal(definition, &d); err != nil {
+			return fmt.Errorf("invalid diagnostic definition: %w", err)
+		}
+		return r.Register(plugin, d)
+	}
+}
+
+// ListFunc returns the ListFunc handed to trcshtalk.
+func (r *Registry) ListFunc() ListFunc {
+	return func() []byte {
+		listBytes, err := json.Marshal(r.List(""))
+		if err != nil {
+			return []byte("[]")
+		}
+		return listBytes
+	}
+}
+
+// Register registers d with the kernel through the RegisterFunc in a
+// plugin's Init properties.
+func Register(properties map[string]any, d Diagnostic) error {
+	var register RegisterFunc
+	switch f := properties[RegisterKey].(type) {
+	case RegisterFunc:
+		register = f
+	case func([]byte) error:
+		register = f
+	}
+	if register == nil {
+		return ErrNotSupported
+	}
+	definition, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return register(definition)
+}
+
+// List returns the registered diagnostics through the ListFunc in a plugin's
+// Init properties.
+func List(properties map[string]any) ([]Diagnostic, error) {
+	var list ListFunc
+	switch f := properties[ListKey].(type) {
+	case ListFunc:
+		list = f
+	case func() []byte:
+		list = f
+	}
+	if list == nil {
+		return nil, ErrNotSupported
+	}
+	var diagnostics []Diagnostic
+	if err := json.Unmarshal(list(), &diagnostics); err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
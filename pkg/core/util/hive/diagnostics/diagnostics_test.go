@@ -0,0 +1,46 @@
+package diagnostics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	properties := map[string]any{
+		RegisterKey: (func([]byte) error)(registry.RegisterFunc("trcdb")),
+		ListKey:     (func() []byte)(registry.ListFunc()),
+	}
+	tenants := Diagnostic{
+		Name:        "trcdb.tenants",
+		Description: "Reports tenants that failed to load",
+		Parameters:  []Parameter{{Name: "tenant", Required: true}, {Name: "verbose", DefaultValue: "false"}},
+		Timeout:     10 * time.Second,
+	}
+	if err := Register(properties, tenants); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+	if err := Register(properties, Diagnostic{Name: "trcdb:tenants"}); err == nil {
+		t.Fatalf("Expected invalid name to be rejected")
+	}
+	if err := registry.Register("healthcheck", Diagnostic{Name: "trcdb.tenants"}); err == nil {
+		t.Fatalf("Expected name registered by another plugin to be rejected")
+	}
+
+	list, err := List(properties)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Unexpected list %v: %v", list, err)
+	}
+	if list[0].Plugin != "trcdb" || list[0].Timeout != 10*time.Second || len(list[0].Parameters) != 2 {
+		t.Fatalf("Diagnostic did not round trip: %+v", list[0])
+	}
+
+	registry.Unregister("trcdb")
+	if _, ok := registry.Lookup("trcdb.tenants"); ok {
+		t.Fatalf("Expected diagnostics to be unregistered")
+	}
+	if err := Register(map[string]any{}, tenants); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
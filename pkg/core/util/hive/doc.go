@@ -19,6 +19,50 @@
 //   - Dynamic plugin updates without downtime
 //   - Safe channel communication between plugins
 //
+// # Chat Requests
+//
+// Besides fire and forget chat routing, the kernel supports request/reply.
+// ChatRequest, or the chatrequest.Func handed to each plugin in its Init
+// properties, stamps the query with a correlation id, waits for the response
+// carrying that id and fails with chatrequest.ErrTimeout when the context
+// ends or chatrequest.ErrTargetNotRunning when the target isn't running.
+// When the requester gives up on a delivered request the target is sent a
+// notice with ChatId chatrequest.CancelChatId, see chatrequest.IsCancellation,
+// and its responses for the request are dropped.
+//
+// # Diagnostics
+//
+// Plugins register named diagnostics, with a description, parameters and a
+// timeout, through the diagnostics.RegisterFunc in their Init properties.
+// trcshtalk lists them with its ListDiagnostics rpc and runs them by name as
+// chat requests to the registering plugin, with RunDiagnostics or, reporting
+// each result as it completes, StreamDiagnostics.  A plugin's diagnostics are
+// dropped when it is started again.
+//
+// # Chat Tracing
+//
+// Setting TRCSHK_CHAT_TRACE (ring size) or TRCSHK_CHAT_TRACE_FILE records every
+// message HandleChat receives, routes, drops or fails to deliver with sender,
+// target, chat and routing ids, sizes, latency and a redacted payload.  The
+// ring is served at /v1/chat/trace by the admin api.  Captures can be replayed
+// against stub plugins with ReplayChatTrace or the trcchatreplay command.
+//
+// # Admin API
+//
+// When TRCSHK_ADMIN_ADDR is set the kernel serves a JSON admin api reporting
+// loaded plugins, their state, release and sha, cert cache expiry and chat
+// counters.  Stopping, starting or reloading a single plugin requires the
+// bearer token in TRCSHK_ADMIN_TOKEN.
+//
+// # Restart Policy
+//
+// Plugins that report an error are restarted according to trcrestartpolicy in
+// their certify record (never, on-failure or always), with exponential backoff
+// from trcrestartbackoff up to trcrestartbackoffmax.  After trcrestartmax
+// restarts within trcrestartwindow the circuit opens, the plugin is marked
+// failed and a degraded notice is broadcast through trcshtalk.  Stops issued
+// by the kernel itself are never restarted.  Flow services are not restarted.
+//
 // # Safety Features
 //
 // All channel operations use generic safeChannelSend[T] to prevent panics from:
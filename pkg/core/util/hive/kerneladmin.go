@@ -0,0 +1,378 @@
+package hive
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+// The admin listener is off unless KernelAdminAddrEnv is set.  Status endpoints
+// are read only; plugin actions additionally require the bearer token in
+// KernelAdminTokenEnv and are disabled when it is unset.
+const (
+	KernelAdminAddrEnv  = "TRCSHK_ADMIN_ADDR"
+	KernelAdminTokenEnv = "TRCSHK_ADMIN_TOKEN"
+)
+
+// Chat counters exposed by the admin api.
+var (
+	chatReceivedCounter atomic.Int64
+	chatRoutedCounter   atomic.Int64
+	chatDroppedCounter  atomic.Int64
+	chatFailedCounter   atomic.Int64
+)
+
+// adminStopTimeout bounds how long a reload waits for the plugin to stop.
+const adminStopTimeout = 30 * time.Second
+
+var pluginStateNames = map[int]string{
+	0: "initialized",
+	1: "running",
+	2: "failed",
+}
+
+type adminPluginStatus struct {
+	Name              string `json:"name"`
+	State             string `json:"state"`
+	Release           string `json:"release,omitempty"`
+	Type              string `json:"type,omitempty"`
+	Path              string `json:"path,omitempty"`
+	Sha256            string `json:"sha256,omitempty"`
+	CertifiedSha256   string `json:"certifiedSha256,omitempty"`
+	LastStateChange   string `json:"lastStateChange,omitempty"`
+	LastRestartReason string `json:"lastRestartReason,omitempty"`
+	RestartPolicy     string `json:"restartPolicy,omitempty"`
+	Restarts          int    `json:"restarts,omitempty"`
+	CircuitOpen       bool   `json:"circuitOpen,omitempty"`
+}
+
+type adminCertStatus struct {
+	Name        string `json:"name"`
+	NotAfter    string `json:"notAfter,omitempty"`
+	CreatedTime any    `json:"createdTime,omitempty"`
+	Sha256      string `json:"sha256,omitempty"`
+}
+
+type adminChatStatus struct {
+	Received         int64 `json:"received"`
+	Routed           int64 `json:"routed"`
+	Dropped          int64 `json:"dropped"`
+	DeliveryFailures int64 `json:"deliveryFailures"`
+}
+
+type adminKernelStatus struct {
+	Id      string              `json:"id"`
+	Plugins []adminPluginStatus `json:"plugins"`
+	Certs   []adminCertStatus   `json:"certs"`
+	Chat    adminChatStatus     `json:"chat"`
+}
+
+// setState records a state transition and why it happened.
+func (pluginHandler *PluginHandler) setState(state int, reason string) {
+	pluginHandler.restartLock.Lock()
+	defer pluginHandler.restartLock.Unlock()
+	pluginHandler.State = state
+	pluginHandler.LastStateChange = time.Now()
+	if reason != "" {
+		pluginHandler.LastRestartReason = reason
+	}
+}
+
+// setRestartReason records why the plugin last stopped, failed or restarted.
+func (pluginHandler *PluginHandler) setRestartReason(reason string) {
+	pluginHandler.restartLock.Lock()
+	pluginHandler.LastRestartReason = reason
+	pluginHandler.restartLock.Unlock()
+}
+
+// receiverStarted is called as the kernel starts receiving the plugin's
+// events.  The returned channel is closed once the receiver returns, after
+// the plugin reported it stopped.
+func (pluginHandler *PluginHandler) receiverStarted() chan struct{} {
+	done := make(chan struct{})
+	pluginHandler.restartLock.Lock()
+	pluginHandler.receiverDone = done
+	pluginHandler.restartLock.Unlock()
+	return done
+}
+
+// liveReceiver returns the done channel of the plugin's receiver, or nil if
+// the plugin isn't running and can no longer accept commands.
+func (pluginHandler *PluginHandler) liveReceiver() chan struct{} {
+	pluginHandler.restartLock.Lock()
+	done := pluginHandler.receiverDone
+	pluginHandler.restartLock.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	default:
+		return done
+	}
+}
+
+// service returns the kernel's handler for a plugin, or nil.
+func (pluginHandler *PluginHandler) service(name string) *PluginHandler {
+	pluginHandler.servicesLock.RLock()
+	defer pluginHandler.servicesLock.RUnlock()
+	if pluginHandler.Services == nil {
+		return nil
+	}
+	return (*pluginHandler.Services)[name]
+}
+
+// services returns the kernel's plugin handlers.
+func (pluginHandler *PluginHandler) services() []*PluginHandler {
+	pluginHandler.servicesLock.RLock()
+	defer pluginHandler.servicesLock.RUnlock()
+	handlers := []*PluginHandler{}
+	if pluginHandler.Services != nil {
+		for _, p := range *pluginHandler.Services {
+			if p != nil {
+				handlers = append(handlers, p)
+			}
+		}
+	}
+	return handlers
+}
+
+func (pluginHandler *PluginHandler) adminPluginStatus() adminPluginStatus {
+	pluginHandler.restartLock.Lock()
+	state, lastStateChange := pluginHandler.State, pluginHandler.LastStateChange
+	status := adminPluginStatus{
+		Name:              pluginHandler.Name,
+		State:             pluginStateNames[state],
+		Path:              pluginHandler.PluginPath,
+		Sha256:            pluginHandler.Signature,
+		LastRestartReason: pluginHandler.LastRestartReason,
+	}
+	pluginHandler.restartLock.Unlock()
+	if status.State == "" {
+		status.State = fmt.Sprintf("unknown(%d)", state)
+	}
+	if !lastStateChange.IsZero() {
+		status.LastStateChange = lastStateChange.UTC().Format(time.RFC3339)
+	}
+	if pluginHandler.DeploymentConfig != nil {
+		status.Release, _ = pluginHandler.DeploymentConfig["trcrelease"].(string)
+		status.Type, _ = pluginHandler.DeploymentConfig["trctype"].(string)
+		status.CertifiedSha256, _ = pluginHandler.DeploymentConfig["trcsha256"].(string)
+		policy := pluginHandler.restartPolicy()
+		status.RestartPolicy = policy.Policy
+		status.Restarts, status.CircuitOpen = policy.status()
+	}
+	return status
+}
+
+func (pluginHandler *PluginHandler) adminKernelStatus(driverConfig *config.DriverConfig) *adminKernelStatus {
+	status := &adminKernelStatus{
+		Id:      pluginHandler.Id,
+		Plugins: []adminPluginStatus{},
+		Certs:   []adminCertStatus{},
+		Chat: adminChatStatus{
+			Received:         chatReceivedCounter.Load(),
+			Routed:           chatRoutedCounter.Load(),
+			Dropped:          chatDroppedCounter.Load(),
+			DeliveryFailures: chatFailedCounter.Load(),
+		},
+	}
+	for _, p := range pluginHandler.services() {
+		status.Plugins = append(status.Plugins, p.adminPluginStatus())
+	}
+	sort.Slice(status.Plugins, func(i, j int) bool { return status.Plugins[i].Name < status.Plugins[j].Name })
+	if driverConfig.CoreConfig.CertCache != nil {
+		for k, v := range driverConfig.CoreConfig.CertCache.Items() {
+			if v == nil {
+				continue
+			}
+			certStatus := adminCertStatus{Name: k, CreatedTime: v.CreatedTime, Sha256: v.Sha256}
+			if v.NotAfter != nil && !v.NotAfter.IsZero() {
+				certStatus.NotAfter = v.NotAfter.UTC().Format(time.RFC3339)
+			}
+			status.Certs = append(status.Certs, certStatus)
+		}
+		sort.Slice(status.Certs, func(i, j int) bool { return status.Certs[i].Name < status.Certs[j].Name })
+	}
+	return status
+}
+
+func writeAdminJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeAdminError(w http.ResponseWriter, code int, message string) {
+	writeAdminJSON(w, code, map[string]string{"error": message})
+}
+
+// sendAdminCmd delivers a command to a running plugin through its
+// CmdSenderChan.
+func (pluginHandler *PluginHandler) sendAdminCmd(driverConfig *config.DriverConfig, p *PluginHandler, command tccore.KernelCmd) error {
+	if p.liveReceiver() == nil || p.ConfigContext == nil || p.ConfigContext.CmdSenderChan == nil {
+		return fmt.Errorf("plugin %s is not running", p.Name)
+	}
+	command.PluginName = p.Name
+	if command.Command == tccore.PLUGIN_EVENT_STOP {
+		p.expectStop()
+	}
+	if !safeChannelSend(p.ConfigContext.CmdSenderChan, command, fmt.Sprintf("admin command to %s", p.Name), driverConfig.CoreConfig.Log) {
+		pluginHandler.sendMsgFailureBroadcast(driverConfig, p.Name)
+		return fmt.Errorf("plugin %s did not accept command", p.Name)
+	}
+	return nil
+}
+
+// adminReload stops the plugin if it is running and runs it again from its
+// last config, the way the restart policy does.
+func (pluginHandler *PluginHandler) adminReload(driverConfig *config.DriverConfig, p *PluginHandler) error {
+	if p.restartConfig == nil {
+		return fmt.Errorf("reload not supported for plugin %s", p.Name)
+	}
+	if done := p.liveReceiver(); done != nil {
+		if err := pluginHandler.sendAdminCmd(driverConfig, p, tccore.KernelCmd{Command: tccore.PLUGIN_EVENT_STOP}); err != nil {
+			return err
+		}
+		select {
+		case <-done:
+		case <-time.After(adminStopTimeout):
+			return fmt.Errorf("plugin %s did not stop within %s", p.Name, adminStopTimeout)
+		}
+	}
+	p.restartPolicy().Reset()
+	go p.rerun(driverConfig)
+	return nil
+}
+
+// adminHandler builds the admin api routes.
+func (pluginHandler *PluginHandler) adminHandler(driverConfig *config.DriverConfig, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, pluginHandler.adminKernelStatus(driverConfig))
+	})
+	mux.HandleFunc("GET /v1/plugins", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, pluginHandler.adminKernelStatus(driverConfig).Plugins)
+	})
+	mux.HandleFunc("GET /v1/plugins/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if p := pluginHandler.service(r.PathValue("name")); p != nil {
+			writeAdminJSON(w, http.StatusOK, p.adminPluginStatus())
+			return
+		}
+		writeAdminError(w, http.StatusNotFound, "unknown plugin")
+	})
+	mux.HandleFunc("GET /v1/certs", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, pluginHandler.adminKernelStatus(driverConfig).Certs)
+	})
+	mux.HandleFunc("GET /v1/chat/trace", func(w http.ResponseWriter, r *http.Request) {
+		if chatTrace == nil {
+			writeAdminError(w, http.StatusNotFound, "chat tracing is disabled")
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, chatTrace.records())
+	})
+	mux.HandleFunc("GET /v1/health", func(w http.ResponseWriter, r *http.Request) {
+		failed := []string{}
+		for _, p := range pluginHandler.services() {
+			if p.adminPluginStatus().State == pluginStateNames[2] {
+				failed = append(failed, p.Name)
+			}
+		}
+		sort.Strings(failed)
+		if len(failed) > 0 {
+			writeAdminJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "degraded", "failed": failed})
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+	})
+
+	mux.HandleFunc("POST /v1/plugins/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeAdminError(w, http.StatusForbidden, "plugin actions are disabled")
+			return
+		}
+		bearer, hasBearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !hasBearer || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		service := r.PathValue("name")
+		action := r.PathValue("action")
+		if action != "stop" && action != "start" && action != "reload" {
+			writeAdminError(w, http.StatusNotFound, "unknown action")
+			return
+		}
+		p := pluginHandler.service(service)
+		if p == nil {
+			writeAdminError(w, http.StatusConflict, fmt.Sprintf("unknown plugin %s", service))
+			return
+		}
+		driverConfig.CoreConfig.Log.Printf("Admin api %s requested for plugin %s from %s\n", action, service, r.RemoteAddr)
+		var err error
+		switch {
+		case action == "stop":
+			err = pluginHandler.sendAdminCmd(driverConfig, p, tccore.KernelCmd{Command: tccore.PLUGIN_EVENT_STOP})
+		case action == "reload":
+			err = pluginHandler.adminReload(driverConfig, p)
+		case p.restartConfig != nil && p.restartPolicy().CircuitOpen():
+			// A plugin given up on by its restart policy is run again from its last config.
+			p.restartPolicy().Reset()
+			go p.rerun(driverConfig)
+		case p.liveReceiver() == nil:
+			err = fmt.Errorf("plugin %s is stopped, reload it to run it again", service)
+		default:
+			err = pluginHandler.sendAdminCmd(driverConfig, p, tccore.KernelCmd{Command: tccore.PLUGIN_EVENT_START})
+		}
+		if err != nil {
+			writeAdminError(w, http.StatusConflict, err.Error())
+			return
+		}
+		p.setRestartReason("admin " + action)
+		writeAdminJSON(w, http.StatusAccepted, map[string]string{"plugin": service, "action": action})
+	})
+	return mux
+}
+
+// StartAdminServer serves the kernel admin api if KernelAdminAddrEnv is set.
+// Bind to a loopback address unless the port is otherwise protected.
+func (pluginHandler *PluginHandler) StartAdminServer(driverConfig *config.DriverConfig) {
+	if pluginHandler == nil || pluginHandler.Name != "Kernel" {
+		driverConfig.CoreConfig.Log.Println("Admin api not supported for plugin.")
+		return
+	}
+	addr := os.Getenv(KernelAdminAddrEnv)
+	if addr == "" {
+		return
+	}
+	token := os.Getenv(KernelAdminTokenEnv)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); (ip == nil || !ip.IsLoopback()) && host != "localhost" {
+			driverConfig.CoreConfig.Log.Printf("Warning: kernel admin api listening on non loopback address %s\n", addr)
+		}
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           pluginHandler.adminHandler(driverConfig, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	driverConfig.CoreConfig.Log.Printf("Kernel admin api listening on %s\n", addr)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			driverConfig.CoreConfig.Log.Printf("Kernel admin api stopped: %v\n", err)
+		}
+	}()
+}
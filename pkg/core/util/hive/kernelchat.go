@@ -0,0 +1,165 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/core/util/hive/chatrequest"
+	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
+)
+
+// pendingChatRequest is a request waiting on the response with its routing id.
+type pendingChatRequest struct {
+	from     string
+	target   string
+	response chan *tccore.ChatMsg
+}
+
+var (
+	pendingChatRequests   sync.Map // routing id -> *pendingChatRequest
+	cancelledChatRequests sync.Map // routing id -> *cancelledChatRequest
+	chatRequestCounter    atomic.Uint64
+)
+
+// cancelledChatRequest is a request its requester stopped waiting on.
+type cancelledChatRequest struct {
+	from      string
+	cancelled time.Time
+}
+
+// Responses to cancelled requests are dropped for this long.
+const cancelledChatRequestTTL = 10 * time.Minute
+
+// ChatRequest sends msg from one plugin to a running target plugin and waits
+// for exactly the response carrying the same correlation id.  Errors are
+// *chatrequest.RequestError wrapping chatrequest.ErrTimeout when ctx is done
+// first or chatrequest.ErrTargetNotRunning when the target isn't running.
+func (pluginHandler *PluginHandler) ChatRequest(ctx context.Context, from string, target string, msg *tccore.ChatMsg) (*tccore.ChatMsg, error) {
+	if pluginHandler == nil || pluginHandler.Name != "Kernel" || pluginHandler.Services == nil ||
+		pluginHandler.ConfigContext == nil || pluginHandler.ConfigContext.ChatReceiverChan == nil {
+		return nil, &chatrequest.RequestError{Target: target, Err: chatrequest.ErrNotSupported}
+	}
+	routingId := fmt.Sprintf("%s-%d-%d", from, time.Now().UnixNano(), chatRequestCounter.Add(1))
+	if p, ok := (*pluginHandler.Services)[target]; !ok || p == nil || p.State != 1 {
+		return nil, &chatrequest.RequestError{Target: target, RoutingId: routingId, Err: chatrequest.ErrTargetNotRunning}
+	}
+
+	request := &tccore.ChatMsg{}
+	if msg != nil {
+		*request = *msg
+	}
+	request.Name = &from
+	request.Query = &[]string{target}
+	request.RoutingId = &routingId
+	request.IsBroadcast = false
+
+	pending := &pendingChatRequest{
+		from:     from,
+		target:   target,
+		response: make(chan *tccore.ChatMsg, 1),
+	}
+	pendingChatRequests.Store(routingId, pending)
+	defer pendingChatRequests.Delete(routingId)
+
+	select {
+	case *pluginHandler.ConfigContext.ChatReceiverChan <- request:
+	case <-ctx.Done():
+		return nil, &chatrequest.RequestError{Target: target, RoutingId: routingId, Err: fmt.Errorf("%w: %w", chatrequest.ErrTimeout, ctx.Err())}
+	}
+
+	select {
+	case response := <-pending.response:
+		if response == nil {
+			return nil, &chatrequest.RequestError{Target: target, RoutingId: routingId, Err: chatrequest.ErrTargetNotRunning}
+		}
+		return response, nil
+	case <-ctx.Done():
+		pluginHandler.cancelChatRequest(request)
+		return nil, &chatrequest.RequestError{Target: target, RoutingId: routingId, Err: fmt.Errorf("%w: %w", chatrequest.ErrTimeout, ctx.Err())}
+	}
+}
+
+// cancelChatRequest tells the target of a delivered request that its requester
+// stopped waiting, so it can abandon the work.  Until cancelledChatRequestTTL
+// passes any response from the target with the request's routing id, late or
+// mistakenly answering the notice, is dropped instead of being routed back to
+// the requester as a new message.
+func (pluginHandler *PluginHandler) cancelChatRequest(request *tccore.ChatMsg) {
+	now := time.Now()
+	cancelledChatRequests.Range(func(routingId, value any) bool {
+		if now.Sub(value.(*cancelledChatRequest).cancelled) > cancelledChatRequestTTL {
+			cancelledChatRequests.Delete(routingId)
+		}
+		return true
+	})
+	cancelledChatRequests.Store(*request.RoutingId, &cancelledChatRequest{from: *request.Name, cancelled: now})
+
+	cancelChatId := chatrequest.CancelChatId
+	cancelled := chatrequest.CancelledResponse
+	notice := &tccore.ChatMsg{
+		Name:      request.Name,
+		Query:     request.Query,
+		ChatId:    &cancelChatId,
+		RoutingId: request.RoutingId,
+		Response:  &cancelled,
+	}
+	go safeChannelSend(pluginHandler.ConfigContext.ChatReceiverChan, notice, "chat request cancellation", pluginHandler.ConfigContext.Log)
+}
+
+// chatRequestFunc is the chatrequest.Func handed to a plugin in its service config.
+func (pluginHandler *PluginHandler) chatRequestFunc() chatrequest.Func {
+	return func(ctx context.Context, target string, msg *tccore.ChatMsg) (*tccore.ChatMsg, error) {
+		return pluginHandler.kernel.ChatRequest(ctx, pluginHandler.Name, target, msg)
+	}
+}
+
+// completeChatRequest hands a response to the request waiting on its routing
+// id.  It returns false if msg isn't a response to a pending request, in which
+// case it is routed as usual.
+func completeChatRequest(msg *tccore.ChatMsg) bool {
+	if eUtils.RefLength(msg.RoutingId) == 0 {
+		return false
+	}
+	if value, ok := cancelledChatRequests.Load(*msg.RoutingId); ok && !eUtils.RefEquals(msg.Name, value.(*cancelledChatRequest).from) {
+		if time.Since(value.(*cancelledChatRequest).cancelled) <= cancelledChatRequestTTL {
+			// Response to a cancelled request, nobody is waiting for it.
+			return true
+		}
+		cancelledChatRequests.Delete(*msg.RoutingId)
+	}
+	value, ok := pendingChatRequests.Load(*msg.RoutingId)
+	if !ok {
+		return false
+	}
+	pending := value.(*pendingChatRequest)
+	if eUtils.RefEquals(msg.Name, pending.from) {
+		// The request itself on its way to the target.
+		return false
+	}
+	select {
+	case pending.response <- msg:
+	default:
+		// Only the first response is delivered.
+	}
+	return true
+}
+
+// failChatRequest ends a pending request whose target could not be reached.
+// It returns false if msg isn't a pending request.
+func failChatRequest(msg *tccore.ChatMsg) bool {
+	if eUtils.RefLength(msg.RoutingId) == 0 {
+		return false
+	}
+	if value, ok := pendingChatRequests.Load(*msg.RoutingId); ok && eUtils.RefEquals(msg.Name, value.(*pendingChatRequest).from) {
+		select {
+		case value.(*pendingChatRequest).response <- nil:
+		default:
+		}
+		return true
+	}
+	return false
+}
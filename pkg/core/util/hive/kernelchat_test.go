@@ -0,0 +1,105 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/core/util/hive/chatrequest"
+)
+
+func newChatTestKernel(targetState int) *PluginHandler {
+	chatReceiver := make(chan *tccore.ChatMsg)
+	return &PluginHandler{
+		Name:          "Kernel",
+		ConfigContext: &tccore.ConfigContext{ChatReceiverChan: &chatReceiver},
+		Services: &map[string]*PluginHandler{
+			"trcshcmd": {Name: "trcshcmd", State: targetState},
+		},
+	}
+}
+
+func TestChatRequestMatchesResponse(t *testing.T) {
+	kernel := newChatTestKernel(1)
+	go func() {
+		request := <-*kernel.ConfigContext.ChatReceiverChan
+		if completeChatRequest(request) {
+			t.Errorf("Request must not complete itself")
+		}
+		target := "trcshcmd"
+		other := "someone-else"
+		unrelated := "pong-unrelated"
+		completeChatRequest(&tccore.ChatMsg{Name: &target, RoutingId: &other, Response: &unrelated})
+		response := "pong"
+		if !completeChatRequest(&tccore.ChatMsg{Name: &target, RoutingId: request.RoutingId, Response: &response}) {
+			t.Errorf("Expected response to complete request")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	response, err := kernel.ChatRequest(ctx, "trcsh", "trcshcmd", &tccore.ChatMsg{})
+	if err != nil {
+		t.Fatalf("Expected response, got %v", err)
+	}
+	if response.Response == nil || *response.Response != "pong" {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+}
+
+func TestChatRequestTypedErrors(t *testing.T) {
+	_, err := newChatTestKernel(0).ChatRequest(context.Background(), "trcsh", "trcshcmd", nil)
+	if !errors.Is(err, chatrequest.ErrTargetNotRunning) {
+		t.Fatalf("Expected target not running, got %v", err)
+	}
+
+	kernel := newChatTestKernel(1)
+	go func() {
+		<-*kernel.ConfigContext.ChatReceiverChan
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = kernel.ChatRequest(ctx, "trcsh", "trcshcmd", nil)
+	var requestErr *chatrequest.RequestError
+	if !errors.Is(err, chatrequest.ErrTimeout) || !errors.As(err, &requestErr) || requestErr.Target != "trcshcmd" {
+		t.Fatalf("Expected timeout, got %v", err)
+	}
+}
+
+func TestChatRequestCancellation(t *testing.T) {
+	kernel := newChatTestKernel(1)
+	request := make(chan *tccore.ChatMsg, 1)
+	go func() {
+		request <- <-*kernel.ConfigContext.ChatReceiverChan
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := kernel.ChatRequest(ctx, "trcshtalk", "trcshcmd", &tccore.ChatMsg{}); !errors.Is(err, chatrequest.ErrTimeout) {
+		t.Fatalf("Expected timeout, got %v", err)
+	}
+	sent := <-request
+
+	notice := <-*kernel.ConfigContext.ChatReceiverChan
+	if !chatrequest.IsCancellation(notice) || notice.Response == nil || *notice.Response != chatrequest.CancelledResponse || *notice.RoutingId != *sent.RoutingId {
+		t.Fatalf("Expected cancellation notice, got %+v", notice)
+	}
+	if chatrequest.IsCancellation(sent) {
+		t.Fatalf("Request must not look like a cancellation notice")
+	}
+	if completeChatRequest(notice) {
+		t.Fatalf("Cancellation notice must be routed to the target")
+	}
+	// A target answering both the notice and the original request must not
+	// reach the requester with either.
+	target := "trcshcmd"
+	noticeReply := "unknown command"
+	if !completeChatRequest(&tccore.ChatMsg{Name: &target, RoutingId: sent.RoutingId, Response: &noticeReply}) {
+		t.Fatalf("Expected reply to the notice to be dropped")
+	}
+	late := "pong"
+	if !completeChatRequest(&tccore.ChatMsg{Name: &target, RoutingId: sent.RoutingId, Response: &late}) {
+		t.Fatalf("Expected late response to be dropped")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
 	"github.com/trimble-oss/tierceron/pkg/utils/config"
 	"github.com/trimble-oss/tierceron/pkg/validator"
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
 	"github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
 	"github.com/trimble-oss/tierceron/pkg/vaulthelper/system"
 	"gopkg.in/yaml.v3"
@@ -51,17 +53,29 @@ var (
 )
 
 type PluginHandler struct {
-	Name             string // service
-	State            int    // 0 - initialized, 1 - running, 2 - failed
-	Id               string
-	KernelId         int
-	Signature        string // sha256 of plugin
-	ConfigContext    *tccore.ConfigContext
-	Services         *map[string]*PluginHandler
-	PluginMod        *plugin.Plugin
-	KernelCtx        *KernelCtx
-	ServiceResource  any
-	DeploymentConfig map[string]any // Full deployment configuration from Vault Certify
+	Name              string // service
+	State             int    // 0 - initialized, 1 - running, 2 - failed
+	Id                string
+	KernelId          int
+	Signature         string    // sha256 of plugin
+	PluginPath        string    // path the plugin module was loaded from
+	LastStateChange   time.Time // when State last changed
+	LastRestartReason string    // why the plugin last stopped or failed
+	ConfigContext     *tccore.ConfigContext
+	Services          *map[string]*PluginHandler
+	PluginMod         *plugin.Plugin
+	KernelCtx         *KernelCtx
+	ServiceResource   any
+	DeploymentConfig  map[string]any // Full deployment configuration from Vault Certify
+	RestartPolicy     *RestartPolicy // Read from DeploymentConfig on first failure
+
+	kernel        *PluginHandler  // Owning kernel, for broadcasts.
+	restartConfig *map[string]any // Service config to re-run the plugin with.
+	restartStats  bool            // Plugin reports dataflow statistics.
+	restartLock   sync.Mutex      // Guards the restart policy, receiverDone and the plugin's state.
+	receiverDone  chan struct{}   // Closed when the command receiver returns.
+	servicesLock  sync.RWMutex    // Kernel only, guards Services.
+	stopping      atomic.Bool     // Set while the plugin is stopping, so its shutdown error isn't a failure.
 }
 
 // IsRunningInKubernetes detects if the process is running in a Kubernetes/AKS environment
@@ -254,6 +268,7 @@ func (pluginHandler *PluginHandler) DynamicReloader(driverConfig *config.DriverC
 										continue
 									}
 									if kernelopts.BuildOptions.IsKernel() {
+										sPluginHandler.expectStop()
 										success := safeChannelSend(sPluginHandler.ConfigContext.CmdSenderChan, tccore.KernelCmd{
 											PluginName: sPluginHandler.Name,
 											Command:    tccore.PLUGIN_EVENT_STOP,
@@ -296,6 +311,7 @@ func (pluginHandler *PluginHandler) DynamicReloader(driverConfig *config.DriverC
 							for s, sPluginHandler := range *pluginHandler.Services {
 								if sPluginHandler != nil && sPluginHandler.ConfigContext != nil && (*sPluginHandler.ConfigContext).CmdSenderChan != nil {
 									if sPluginHandler.Name != "healthcheck" {
+										sPluginHandler.expectStop()
 										success := safeChannelSend(sPluginHandler.ConfigContext.CmdSenderChan, tccore.KernelCmd{
 											PluginName: sPluginHandler.Name,
 											Command:    tccore.PLUGIN_EVENT_STOP,
@@ -365,6 +381,7 @@ func (pluginHandler *PluginHandler) DynamicReloader(driverConfig *config.DriverC
 									goto waitToReload
 								}
 								driverConfig.CoreConfig.Log.Printf("Shutting down service: %s\n", service)
+								servPh.expectStop()
 								success := safeChannelSend(servPh.ConfigContext.CmdSenderChan, tccore.KernelCmd{
 									PluginName: servPh.Name,
 									Command:    tccore.PLUGIN_EVENT_STOP,
@@ -377,8 +394,10 @@ func (pluginHandler *PluginHandler) DynamicReloader(driverConfig *config.DriverC
 								if pluginHandler.KernelCtx != nil && pluginHandler.KernelCtx.PluginRestartChan != nil && *pluginHandler.KernelCtx.PluginRestartChan != nil {
 									cmd := <-*pluginHandler.KernelCtx.PluginRestartChan
 									if cmd.Command == tccore.PLUGIN_EVENT_STOP {
+										pluginHandler.servicesLock.Lock()
 										(*pluginHandler.Services)[service] = &PluginHandler{
-											Name: service,
+											Name:   service,
+											kernel: pluginHandler,
 											ConfigContext: &tccore.ConfigContext{
 												Log: driverConfig.CoreConfig.Log,
 											},
@@ -386,6 +405,7 @@ func (pluginHandler *PluginHandler) DynamicReloader(driverConfig *config.DriverC
 												PluginRestartChan: pluginHandler.KernelCtx.PluginRestartChan,
 											},
 										}
+										pluginHandler.servicesLock.Unlock()
 										driverConfig.CoreConfig.Log.Printf("Restarting service: %s\n", service)
 										if pluginHandler.KernelCtx.DeployRestartChan != nil && *pluginHandler.KernelCtx.DeployRestartChan != nil {
 											safeChannelSend(pluginHandler.KernelCtx.DeployRestartChan, service, fmt.Sprintf("kube service restart %s", service), driverConfig.CoreConfig.Log)
@@ -401,6 +421,7 @@ func (pluginHandler *PluginHandler) DynamicReloader(driverConfig *config.DriverC
 							for s, sPluginHandler := range *pluginHandler.Services {
 								if sPluginHandler != nil && sPluginHandler.ConfigContext != nil && (*sPluginHandler.ConfigContext).CmdSenderChan != nil {
 									driverConfig.CoreConfig.Log.Printf("Shutting down service: %s\n", s)
+									sPluginHandler.expectStop()
 									success := safeChannelSend(sPluginHandler.ConfigContext.CmdSenderChan, tccore.KernelCmd{
 										PluginName: sPluginHandler.Name,
 										Command:    tccore.PLUGIN_EVENT_STOP,
@@ -443,9 +464,12 @@ func (pluginHandler *PluginHandler) AddKernelPlugin(service string, driverConfig
 		if deploymentConfig != nil {
 			deployConfig = *deploymentConfig
 		}
+		pluginHandler.servicesLock.Lock()
+		defer pluginHandler.servicesLock.Unlock()
 		(*pluginHandler.Services)[service] = &PluginHandler{
 			Name:             service,
 			DeploymentConfig: deployConfig,
+			kernel:           pluginHandler,
 			ConfigContext: &tccore.ConfigContext{
 				Log:              driverConfig.CoreConfig.Log,
 				ChatReceiverChan: pluginHandler.ConfigContext.ChatReceiverChan,
@@ -538,6 +562,9 @@ func (pluginHandler *PluginHandler) RunPlugin(
 	service string,
 	serviceConfig *map[string]any,
 ) {
+	pluginHandler.restartConfig = serviceConfig
+	pluginHandler.stopping.Store(false)
+
 	// Initialize channels
 	sender := make(chan tccore.KernelCmd)
 	pluginHandler.ConfigContext.CmdSenderChan = &sender
@@ -578,6 +605,7 @@ func (pluginHandler *PluginHandler) RunPlugin(
 	(*serviceConfig)["env"] = driverConfig.CoreConfig.Env
 	(*serviceConfig)["isKubernetes"] = IsRunningInKubernetes()
 	(*serviceConfig)["isKernelZ"] = kernelopts.BuildOptions.IsKernelZ()
+	pluginHandler.setServiceFuncs(*serviceConfig)
 
 	// Security: KernelZ only allows trcshcmd, trcsh, and rosea plugins
 	if kernelopts.BuildOptions.IsKernelZ() {
@@ -611,7 +639,7 @@ func (pluginHandler *PluginHandler) RunPlugin(
 			isKernelPlugin = (trctype == "kernelplugin")
 		}
 	}
-	go pluginHandler.receiver(driverConfig, isKernelPlugin)
+	go pluginHandler.receiver(driverConfig, isKernelPlugin, pluginHandler.receiverStarted())
 	pluginHandler.Init(serviceConfig)
 
 	// Check if plugin refused to initialize
@@ -789,12 +817,15 @@ func (pluginHandler *PluginHandler) PluginserviceStart(driverConfig *config.Driv
 			serviceConfig := make(map[string]any)
 			for _, path := range paths {
 				if strings.HasPrefix(path, "Common") {
+					path, optional := strings.CutSuffix(path, certutil.OptionalCertSuffix)
 					if v, ok := driverConfig.CoreConfig.CertCache.Get(path); ok {
 						driverConfig.CoreConfig.WantCerts = false
 						serviceConfig[path] = *v.CertBytes
 					} else {
 						configuredCert, err := certutil.AddToCache(path, driverConfig, mod)
-						if err != nil {
+						if err != nil && optional {
+							driverConfig.CoreConfig.Log.Printf("Optional cert: %s not loaded for plugin: %s\n", path, service)
+						} else if err != nil {
 							driverConfig.CoreConfig.Log.Printf("Unable to load cert: %v for plugin: %s\n", err, service)
 							if pluginHandler.ConfigContext.ChatReceiverChan != nil {
 								go func(recChan *chan *tccore.ChatMsg, log *log.Logger) {
@@ -984,6 +1015,7 @@ func (pluginHandler *PluginHandler) PluginserviceStart(driverConfig *config.Driv
 			serviceConfig["env"] = driverConfig.CoreConfig.Env
 			serviceConfig["isKubernetes"] = IsRunningInKubernetes()
 			serviceConfig["isKernelZ"] = kernelopts.BuildOptions.IsKernelZ()
+			pluginHandler.setServiceFuncs(serviceConfig)
 			go pluginHandler.handleErrors(driverConfig)
 			*driverConfig.CoreConfig.CurrentTokenNamePtr = "config_token_pluginany"
 
@@ -1124,7 +1156,7 @@ func (pluginHandler *PluginHandler) PluginserviceStart(driverConfig *config.Driv
 						}
 
 						go handler.handleDataflowStat(bootDriverConfig, statMod, nil)
-						go handler.receiver(bootDriverConfig, isKernelPlugin)
+						go handler.receiver(bootDriverConfig, isKernelPlugin, handler.receiverStarted())
 					}
 				}()
 
@@ -1164,7 +1196,11 @@ func (pluginHandler *PluginHandler) PluginserviceStart(driverConfig *config.Driv
 					}
 
 					go pluginHandler.handleDataflowStat(driverConfig, kernelmod, nil)
+					pluginHandler.restartStats = true
 				}
+				// Kept to restart the plugin in place, which flow services with their
+				// own flow machine (the branch above) are not.
+				pluginHandler.restartConfig = &serviceConfig
 
 				// Determine if this is a kernel plugin BEFORE starting receiver to avoid race
 				var isKernelPlugin bool
@@ -1174,7 +1210,7 @@ func (pluginHandler *PluginHandler) PluginserviceStart(driverConfig *config.Driv
 					}
 				}
 
-				go pluginHandler.receiver(driverConfig, isKernelPlugin)
+				go pluginHandler.receiver(driverConfig, isKernelPlugin, pluginHandler.receiverStarted())
 				if len(driverConfig.CoreConfig.Regions) > 0 {
 					serviceConfig["region"] = driverConfig.CoreConfig.Regions[0]
 				} else {
@@ -1239,7 +1275,8 @@ func reloadFlows(tfmContext flow.FlowMachineContext, mod *kv.Modifier) {
 	}
 }
 
-func (pluginHandler *PluginHandler) receiver(driverConfig *config.DriverConfig, isKernelPlugin bool) {
+func (pluginHandler *PluginHandler) receiver(driverConfig *config.DriverConfig, isKernelPlugin bool, done chan struct{}) {
+	defer close(done)
 	for {
 		event := <-*pluginHandler.ConfigContext.CmdReceiverChan
 		switch {
@@ -1250,18 +1287,23 @@ func (pluginHandler *PluginHandler) receiver(driverConfig *config.DriverConfig,
 				CallPluginStart(pluginHandler.Name)
 				// For kernel plugins, wait until they signal ready before setting State
 			}
-			pluginHandler.State = 1
+			pluginHandler.setState(1, "")
 			pluginsync.SignalPluginReady(pluginHandler.Name)
 			if isKernelPlugin {
 				driverConfig.CoreConfig.Log.Printf("Kernel plugin %s is ready\n", pluginHandler.Name)
 			}
 			if globalPluginStatusChan != nil {
-				<-globalPluginStatusChan
+				// Non blocking, restarted plugins were already counted.
+				select {
+				case <-globalPluginStatusChan:
+				default:
+				}
 			}
 			driverConfig.CoreConfig.Log.Printf("Kernel finished starting plugin: %s\n", pluginHandler.Name)
 		case event.Command == tccore.PLUGIN_EVENT_STOP:
 			driverConfig.CoreConfig.Log.Printf("Kernel finished stopping plugin: %s\n", pluginHandler.Name)
-			pluginHandler.State = 0
+			pluginHandler.setState(0, "stopped")
+			pluginHandler.stopping.Store(true)
 			safeChannelSend(pluginHandler.ConfigContext.ErrorChan,
 				errors.New(pluginHandler.Name+" shutting down"),
 				pluginHandler.Name+" shutting down", driverConfig.CoreConfig.Log)
@@ -1274,6 +1316,7 @@ func (pluginHandler *PluginHandler) receiver(driverConfig *config.DriverConfig,
 					safeChannelSend(pluginHandler.KernelCtx.PluginRestartChan, e, "plugin restart message", driverConfig.CoreConfig.Log)
 				}(event)
 			}
+			pluginHandler.handleExit(driverConfig, false, "stopped")
 			return
 		case event.Command == tccore.PLUGIN_EVENT_STATUS:
 			// TODO
@@ -1289,12 +1332,24 @@ func (pluginHandler *PluginHandler) handleErrors(driverConfig *config.DriverConf
 		switch {
 		case pluginHandler.State == 2 && result != nil:
 			if globalPluginStatusChan != nil {
-				<-globalPluginStatusChan
+				// Non blocking, restarted plugins were already counted.
+				select {
+				case <-globalPluginStatusChan:
+				default:
+				}
 			}
+			pluginHandler.setRestartReason(result.Error())
 			eUtils.LogErrorObject(driverConfig.CoreConfig, result, false)
+			if !pluginHandler.stopping.Load() {
+				pluginHandler.handleExit(driverConfig, true, result.Error())
+			}
 			return
 		case result != nil:
+			pluginHandler.setRestartReason(result.Error())
 			eUtils.LogErrorObject(driverConfig.CoreConfig, result, false)
+			if !pluginHandler.stopping.Load() {
+				pluginHandler.handleExit(driverConfig, true, result.Error())
+			}
 			return
 		}
 	}
@@ -1353,6 +1408,7 @@ func (pluginHandler *PluginHandler) PluginserviceStop(driverConfig *config.Drive
 		return
 	}
 	driverConfig.CoreConfig.Log.Printf("Sending stop message to plugin: %s\n", pluginName)
+	pluginHandler.expectStop()
 	safeChannelSend(pluginHandler.ConfigContext.CmdSenderChan, tccore.KernelCmd{
 		PluginName: pluginName,
 		Command:    tccore.PLUGIN_EVENT_STOP,
@@ -1395,6 +1451,7 @@ func LoadPluginPath(driverConfig *config.DriverConfig, pluginToolConfig map[stri
 
 func (pluginHandler *PluginHandler) LoadPluginMod(driverConfig *config.DriverConfig, pluginPath string) {
 	driverConfig.CoreConfig.Log.Printf("Loading plugin: %s\n", pluginPath)
+	pluginHandler.PluginPath = pluginPath
 
 	var pluginM *plugin.Plugin
 	if !plugincoreopts.BuildOptions.IsPluginHardwired() {
@@ -1402,7 +1459,7 @@ func (pluginHandler *PluginHandler) LoadPluginMod(driverConfig *config.DriverCon
 		if err != nil {
 			driverConfig.CoreConfig.Log.Printf("Unable to open plugin module for service: %s\n", pluginPath)
 			driverConfig.CoreConfig.Log.Printf("Returned with %v\n", err)
-			pluginHandler.State = 2
+			pluginHandler.setState(2, err.Error())
 			return
 		}
 		pluginM = pM
@@ -1412,10 +1469,10 @@ func (pluginHandler *PluginHandler) LoadPluginMod(driverConfig *config.DriverCon
 		driverConfig.CoreConfig.Log.Printf("Successfully opened plugin module for %s\n", pluginName)
 		// PluginMods[pluginName] = pluginM
 		pluginHandler.PluginMod = pluginM
-		pluginHandler.State = 0
+		pluginHandler.setState(0, "")
 	} else {
 		driverConfig.CoreConfig.Log.Println("Unable to load plugin module because missing plugin name")
-		pluginHandler.State = 2
+		pluginHandler.setState(2, "missing plugin name")
 		return
 	}
 }
@@ -1470,6 +1527,7 @@ func (pluginHandler *PluginHandler) sendInitBroadcast(driverConfig *config.Drive
 }
 
 func (pluginHandler *PluginHandler) sendMsgFailureBroadcast(driverConfig *config.DriverConfig, failedService string) {
+	chatFailedCounter.Add(1)
 	if driverConfig == nil || driverConfig.CoreConfig == nil || driverConfig.CoreConfig.Log == nil {
 		return
 	}
@@ -1484,17 +1542,24 @@ func (pluginHandler *PluginHandler) sendMsgFailureBroadcast(driverConfig *config
 	if msgFailureBroadcastCounter.Add(1) > 3 {
 		return
 	}
-	response := "Message delivery to " + failedService + " timed out after 10 seconds."
-	broadcastSuccess := safeChannelSend(pluginHandler.ConfigContext.ChatReceiverChan,
+	if !pluginHandler.sendServiceFailureBroadcast(driverConfig, "Message delivery to "+failedService+" timed out after 10 seconds.") {
+		driverConfig.CoreConfig.Log.Printf("Failed to broadcast message failure to trcshtalk for service: %s\n", failedService)
+	}
+}
+
+// sendServiceFailureBroadcast tells peers through trcshtalk that a service is failing.
+func (pluginHandler *PluginHandler) sendServiceFailureBroadcast(driverConfig *config.DriverConfig, response string) bool {
+	if pluginHandler == nil || pluginHandler.Name != "Kernel" || pluginHandler.ConfigContext == nil || pluginHandler.ConfigContext.ChatReceiverChan == nil {
+		driverConfig.CoreConfig.Log.Printf("Service failure broadcasting not supported for plugin: %v\n", pluginHandler)
+		return false
+	}
+	return safeChannelSend(pluginHandler.ConfigContext.ChatReceiverChan,
 		&tccore.ChatMsg{
 			Name:        &pluginHandler.Name,
 			Query:       &[]string{"trcshtalk"},
 			IsBroadcast: true,
 			Response:    &response,
-		}, "msg failure broadcast sender", driverConfig.CoreConfig.Log)
-	if !broadcastSuccess {
-		driverConfig.CoreConfig.Log.Printf("Failed to broadcast message failure to trcshtalk for service: %s\n", failedService)
-	}
+		}, "service failure broadcast sender", driverConfig.CoreConfig.Log)
 }
 
 func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig) {
@@ -1507,21 +1572,48 @@ func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig
 		pluginHandler.ConfigContext.ChatReceiverChan = &msgReceiver
 		pluginHandler.State = 1
 	}
+	kernelChatRequest = pluginHandler.ChatRequest
+	startChatTrace(driverConfig)
+	if auditTarget := os.Getenv(audit.ChatTargetEnv); auditTarget != "" {
+		driverConfig.CoreConfig.Log.Printf("Routing audit events to %s\n", auditTarget)
+		audit.AddSink(audit.NewChatSink(pluginHandler.ConfigContext.ChatReceiverChan, pluginHandler.Name, auditTarget))
+	}
 
 	if !plugincoreopts.BuildOptions.IsPluginHardwired() {
 		driverConfig.CoreConfig.Log.Println("All plugins have loaded, sending broadcast message...")
 		go pluginHandler.sendInitBroadcast(driverConfig)
 	}
+	pluginHandler.routeChat(driverConfig, chatTrace, true)
+}
 
+// routeChat routes messages from the kernel chat receiver until SHUTDOWN.
+// Messages are recorded in tracer if set.  Unless live, pending chat
+// requests, counters and failure broadcasts are left alone so a replay
+// doesn't disturb the running kernel.
+func (pluginHandler *PluginHandler) routeChat(driverConfig *config.DriverConfig, tracer *chatTracer, live bool) {
 	for {
 		msg := <-*pluginHandler.ConfigContext.ChatReceiverChan
 		if msg == nil {
 			driverConfig.CoreConfig.Log.Println("Kernel received nil message")
 			continue
 		}
+		if live {
+			chatReceivedCounter.Add(1)
+		}
+		received := time.Now()
+		var sender string
+		if msg.Name != nil {
+			sender = *msg.Name
+		}
+		traceSeq := tracer.trace(ChatTraceReceived, sender, "", msg, received, 0)
 		if msg.KernelId == nil || *msg.KernelId == "" {
 			msg.KernelId = &pluginHandler.Id
 		}
+		if live && completeChatRequest(msg) {
+			chatRoutedCounter.Add(1)
+			tracer.trace(ChatTraceReplied, sender, "", msg, received, traceSeq)
+			continue
+		}
 		driverConfig.CoreConfig.Log.Println("Kernel received message from chat.")
 		if eUtils.RefEquals(msg.Name, "SHUTDOWN") {
 			driverConfig.CoreConfig.Log.Println("Shutting down chat receiver.")
@@ -1534,7 +1626,9 @@ func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig
 						}, "SHUTDOWN plugin chat receiver", driverConfig.CoreConfig.Log)
 						if !success {
 							driverConfig.CoreConfig.Log.Printf("Failed to send shutdown message to plugin: %s\n", p.Name)
-							pluginHandler.sendMsgFailureBroadcast(driverConfig, p.Name)
+							if live {
+								pluginHandler.sendMsgFailureBroadcast(driverConfig, p.Name)
+							}
 						}
 					}(p)
 				}
@@ -1544,6 +1638,10 @@ func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig
 
 		if msg.Query == nil {
 			driverConfig.CoreConfig.Log.Println("No query provided in chat message.")
+			if live {
+				chatDroppedCounter.Add(1)
+			}
+			tracer.trace(ChatTraceDropped, sender, "", msg, received, traceSeq)
 			continue
 		}
 		for _, q := range *msg.Query {
@@ -1599,12 +1697,25 @@ func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig
 				}
 				go func() {
 					success := safeChannelSend(&chatSenderChan, newMsg, "chat sender", driverConfig.CoreConfig.Log)
-					if !success {
+					if success {
+						if live {
+							chatRoutedCounter.Add(1)
+						}
+						tracer.trace(ChatTraceRouted, sender, plugin.Name, newMsg, received, traceSeq)
+					} else {
+						tracer.trace(ChatTraceFailed, sender, plugin.Name, newMsg, received, traceSeq)
 						driverConfig.CoreConfig.Log.Printf("Failed to send chat message from %s\n", *msg.Name)
-						pluginHandler.sendMsgFailureBroadcast(driverConfig, plugin.Name)
+						if live {
+							failChatRequest(msg)
+							pluginHandler.sendMsgFailureBroadcast(driverConfig, plugin.Name)
+						}
 					}
 				}()
 			} else if eUtils.RefLength(msg.Name) > 0 && !msg.IsBroadcast {
+				if live && failChatRequest(msg) {
+					driverConfig.CoreConfig.Log.Printf("Service unavailable to process request from %s\n", *msg.Name)
+					continue
+				}
 				if plugin, ok := (*pluginHandler.Services)[*msg.Name]; ok && plugin != nil && plugin.State == 1 {
 					// Querying plugin is running - forward the message
 					responseError := "Service unavailable"
@@ -1621,11 +1732,14 @@ func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig
 					time.Sleep(2 * time.Second) // Give time for the plugin to start
 					msg.Response = &responseError
 					if plugin.ConfigContext != nil && plugin.ConfigContext.ChatSenderChan != nil {
+						tracer.trace(ChatTraceUnavailable, queryPlugin[0], plugin.Name, msg, received, traceSeq)
 						go func() {
 							success := safeChannelSend(plugin.ConfigContext.ChatSenderChan, msg, "unavailable service notification", driverConfig.CoreConfig.Log)
 							if !success {
 								driverConfig.CoreConfig.Log.Printf("Failed to send unavailable service notification to plugin: %s\n", plugin.Name)
-								pluginHandler.sendMsgFailureBroadcast(driverConfig, plugin.Name)
+								if live {
+									pluginHandler.sendMsgFailureBroadcast(driverConfig, plugin.Name)
+								}
 							}
 						}()
 					}
@@ -1635,6 +1749,10 @@ func (pluginHandler *PluginHandler) HandleChat(driverConfig *config.DriverConfig
 				continue
 			} else {
 				driverConfig.CoreConfig.Log.Println("Unable to interpret message.")
+				if live {
+					chatDroppedCounter.Add(1)
+				}
+				tracer.trace(ChatTraceDropped, sender, queryPlugin[0], msg, received, traceSeq)
 			}
 		}
 	}
@@ -0,0 +1,28 @@
+package hive
+
+import (
+	"context"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/core/util/hive/chatrequest"
+	"github.com/trimble-oss/tierceron/pkg/core/util/hive/diagnostics"
+)
+
+// diagnosticRegistry holds the named diagnostics plugins register at Init.
+var diagnosticRegistry = diagnostics.NewRegistry()
+
+// DiagnosticRegistry returns the diagnostics registered by the kernel's plugins.
+func DiagnosticRegistry() *diagnostics.Registry {
+	return diagnosticRegistry
+}
+
+// setServiceFuncs stores the kernel functions a plugin may call in its
+// service config.  They are stored as plain func types so plugins built as
+// separate modules can assert them without importing the kernel packages.
+// Diagnostics registered by a previous run of the plugin are dropped.
+func (pluginHandler *PluginHandler) setServiceFuncs(serviceConfig map[string]any) {
+	diagnosticRegistry.Unregister(pluginHandler.Name)
+	serviceConfig[chatrequest.ServiceConfigKey] = (func(context.Context, string, *tccore.ChatMsg) (*tccore.ChatMsg, error))(pluginHandler.chatRequestFunc())
+	serviceConfig[diagnostics.RegisterKey] = (func([]byte) error)(diagnosticRegistry.RegisterFunc(pluginHandler.Name))
+	serviceConfig[diagnostics.ListKey] = (func() []byte)(diagnosticRegistry.ListFunc())
+}
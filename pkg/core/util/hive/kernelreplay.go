@@ -0,0 +1,194 @@
+package hive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+// ChatReplayResult compares the deliveries in a capture with those observed
+// when replaying it.
+type ChatReplayResult struct {
+	Sent       int      `json:"sent"`
+	Expected   int      `json:"expected"`
+	Observed   int      `json:"observed"`
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+}
+
+// Ok returns true if the replay delivered exactly what was captured.
+func (r *ChatReplayResult) Ok() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0
+}
+
+// ReadChatTrace reads a capture written through KernelChatTraceFileEnv.
+func ReadChatTrace(reader io.Reader) ([]ChatTraceRecord, error) {
+	records := []ChatTraceRecord{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		record := ChatTraceRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func chatDeliveryKey(from string, to string, chatId string, routingId string) string {
+	from, _, _ = strings.Cut(from, ":")
+	return fmt.Sprintf("%s -> %s chatId=%s routingId=%s", from, to, chatId, routingId)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ReplayChatTrace runs a kernel chat handler against stub plugins, feeds it
+// every message received in the capture and reports deliveries that differ
+// from the capture.  Stubs are created for every sender and every plugin a
+// message was routed to, so targets that were unavailable stay unavailable.
+// Responses to ChatRequest calls are not replayed as there is no pending
+// request to complete.
+func ReplayChatTrace(driverConfig *config.DriverConfig, records []ChatTraceRecord, settle time.Duration) (*ChatReplayResult, error) {
+	replied := map[uint64]bool{}
+	expected := []string{}
+	stubNames := map[string]bool{}
+	for _, record := range records {
+		switch record.Event {
+		case ChatTraceReceived:
+			if record.From != "" {
+				stubNames[record.From] = true
+			}
+		case ChatTraceReplied:
+			replied[record.Ref] = true
+		case ChatTraceRouted, ChatTraceUnavailable:
+			stubNames[record.To] = true
+			expected = append(expected, chatDeliveryKey(record.From, record.To, record.ChatId, record.RoutingId))
+		}
+	}
+	if len(stubNames) == 0 {
+		return nil, fmt.Errorf("no messages in capture")
+	}
+
+	observedLock := sync.Mutex{}
+	observed := []string{}
+	stubsDone := make(chan struct{})
+	defer close(stubsDone)
+	services := map[string]*PluginHandler{}
+	for name := range stubNames {
+		chatSender := make(chan *tccore.ChatMsg)
+		chatBroadcast := make(chan *tccore.ChatMsg)
+		services[name] = &PluginHandler{
+			Name:  name,
+			State: 1,
+			ConfigContext: &tccore.ConfigContext{
+				ChatSenderChan:    &chatSender,
+				ChatBroadcastChan: &chatBroadcast,
+			},
+		}
+		stub := func(name string, msg *tccore.ChatMsg) {
+			if msg == nil || msg.Query == nil || len(*msg.Query) == 0 {
+				return
+			}
+			var chatId, routingId string
+			if msg.ChatId != nil {
+				chatId = *msg.ChatId
+			}
+			if msg.RoutingId != nil {
+				routingId = *msg.RoutingId
+			}
+			observedLock.Lock()
+			observed = append(observed, chatDeliveryKey((*msg.Query)[0], name, chatId, routingId))
+			observedLock.Unlock()
+		}
+		go func(name string) {
+			for {
+				select {
+				case msg := <-chatSender:
+					stub(name, msg)
+				case msg := <-chatBroadcast:
+					stub(name, msg)
+				case <-stubsDone:
+					return
+				}
+			}
+		}(name)
+	}
+
+	chatReceiver := make(chan *tccore.ChatMsg)
+	kernel := &PluginHandler{
+		Name:          "Kernel",
+		Id:            "replay",
+		State:         1,
+		ConfigContext: &tccore.ConfigContext{ChatReceiverChan: &chatReceiver},
+		Services:      &services,
+	}
+	done := make(chan bool)
+	go func() {
+		// Route without tracing or touching the running kernel's chat state.
+		kernel.routeChat(driverConfig, nil, false)
+		done <- true
+	}()
+
+	result := &ChatReplayResult{Expected: len(expected)}
+	for _, record := range records {
+		if record.Event != ChatTraceReceived || replied[record.Seq] {
+			continue
+		}
+		query := append([]string{}, record.Query...)
+		chatReceiver <- &tccore.ChatMsg{
+			Name:        optionalString(record.From),
+			Query:       &query,
+			ChatId:      optionalString(record.ChatId),
+			RoutingId:   optionalString(record.RoutingId),
+			Response:    optionalString(record.Response),
+			IsBroadcast: record.Broadcast,
+		}
+		result.Sent++
+	}
+	time.Sleep(settle)
+	shutdown := "SHUTDOWN"
+	chatReceiver <- &tccore.ChatMsg{Name: &shutdown, Query: &[]string{}}
+	<-done
+
+	observedLock.Lock()
+	defer observedLock.Unlock()
+	result.Observed = len(observed)
+	remaining := map[string]int{}
+	for _, key := range observed {
+		remaining[key]++
+	}
+	for _, key := range expected {
+		if remaining[key] > 0 {
+			remaining[key]--
+		} else {
+			result.Missing = append(result.Missing, key)
+		}
+	}
+	for key, count := range remaining {
+		for range count {
+			result.Unexpected = append(result.Unexpected, key)
+		}
+	}
+	sort.Strings(result.Missing)
+	sort.Strings(result.Unexpected)
+	return result, nil
+}
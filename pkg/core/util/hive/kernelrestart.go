@@ -0,0 +1,293 @@
+package hive
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+// Restart policies, read from trcrestartpolicy in the plugin's certify record.
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+const (
+	defaultRestartMax        = 5
+	defaultRestartWindow     = 10 * time.Minute
+	defaultRestartBackoff    = time.Second
+	defaultRestartBackoffMax = 5 * time.Minute
+)
+
+// RestartPolicy decides if and when the kernel re-runs a plugin that failed.
+// After Max restarts within Window the circuit opens and the plugin is left
+// failed until the kernel is restarted or the plugin is started by hand.
+type RestartPolicy struct {
+	Policy     string
+	Max        int
+	Window     time.Duration
+	Backoff    time.Duration
+	BackoffMax time.Duration
+
+	lock         sync.Mutex
+	restarts     []time.Time
+	circuitOpen  bool
+	stopExpected bool
+}
+
+// restartDuration parses a positive restart duration such as 10m.
+func restartDuration(value any) (time.Duration, bool) {
+	if s, ok := value.(string); ok && s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// restartInt parses a positive restart count.
+func restartInt(value any) (int, bool) {
+	i := 0
+	switch v := value.(type) {
+	case int:
+		i = v
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		i = int(v)
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return 0, false
+		}
+		i = int(n)
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, false
+		}
+		i = n
+	default:
+		return 0, false
+	}
+	return i, i > 0
+}
+
+func deploymentDuration(deploymentConfig map[string]any, key string, defaultValue time.Duration) time.Duration {
+	if d, ok := restartDuration(deploymentConfig[key]); ok {
+		return d
+	}
+	return defaultValue
+}
+
+func deploymentInt(deploymentConfig map[string]any, key string, defaultValue int) int {
+	if i, ok := restartInt(deploymentConfig[key]); ok {
+		return i
+	}
+	return defaultValue
+}
+
+// CheckRestartPolicy returns an error for restart settings in a certify or
+// deployment config that NewRestartPolicy would ignore in favor of defaults.
+// Settings that aren't present are fine.
+func CheckRestartPolicy(deploymentConfig map[string]any) error {
+	if p, ok := deploymentConfig["trcrestartpolicy"]; ok {
+		switch p {
+		case RestartNever, RestartOnFailure, RestartAlways:
+		default:
+			return fmt.Errorf("unsupported restart policy %v: use %s, %s or %s", p, RestartNever, RestartOnFailure, RestartAlways)
+		}
+	}
+	if m, ok := deploymentConfig["trcrestartmax"]; ok {
+		if _, valid := restartInt(m); !valid {
+			return fmt.Errorf("invalid restart max %v: must be a positive number of restarts", m)
+		}
+	}
+	for _, key := range []string{"trcrestartwindow", "trcrestartbackoff", "trcrestartbackoffmax"} {
+		if d, ok := deploymentConfig[key]; ok {
+			if _, valid := restartDuration(d); !valid {
+				return fmt.Errorf("invalid %s %v: must be a positive duration such as 30s or 10m", key, d)
+			}
+		}
+	}
+	backoff := deploymentDuration(deploymentConfig, "trcrestartbackoff", defaultRestartBackoff)
+	backoffMax := deploymentDuration(deploymentConfig, "trcrestartbackoffmax", defaultRestartBackoffMax)
+	if backoff > backoffMax {
+		return fmt.Errorf("restart backoff %s is greater than restart backoff max %s", backoff, backoffMax)
+	}
+	return nil
+}
+
+// NewRestartPolicy reads the restart policy from a certify/deployment config.
+//
+//	trcrestartpolicy      never | on-failure | always (default never)
+//	trcrestartmax         restarts allowed within the window (default 5)
+//	trcrestartwindow      e.g. 10m
+//	trcrestartbackoff     initial delay, doubled on each restart (default 1s)
+//	trcrestartbackoffmax  upper bound on the delay (default 5m)
+func NewRestartPolicy(deploymentConfig map[string]any) *RestartPolicy {
+	policy := &RestartPolicy{
+		Policy:     RestartNever,
+		Max:        defaultRestartMax,
+		Window:     defaultRestartWindow,
+		Backoff:    defaultRestartBackoff,
+		BackoffMax: defaultRestartBackoffMax,
+	}
+	if deploymentConfig == nil {
+		return policy
+	}
+	if p, ok := deploymentConfig["trcrestartpolicy"].(string); ok {
+		switch p {
+		case RestartOnFailure, RestartAlways:
+			policy.Policy = p
+		}
+	}
+	policy.Max = deploymentInt(deploymentConfig, "trcrestartmax", defaultRestartMax)
+	policy.Window = deploymentDuration(deploymentConfig, "trcrestartwindow", defaultRestartWindow)
+	policy.Backoff = deploymentDuration(deploymentConfig, "trcrestartbackoff", defaultRestartBackoff)
+	policy.BackoffMax = deploymentDuration(deploymentConfig, "trcrestartbackoffmax", defaultRestartBackoffMax)
+	return policy
+}
+
+// ExpectStop marks the next stop as requested by the kernel, so it is not
+// treated as a crash.
+func (r *RestartPolicy) ExpectStop() {
+	r.lock.Lock()
+	r.stopExpected = true
+	r.lock.Unlock()
+}
+
+// CircuitOpen returns true once the plugin has exhausted its restarts.
+func (r *RestartPolicy) CircuitOpen() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.circuitOpen
+}
+
+func (r *RestartPolicy) status() (int, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.restarts), r.circuitOpen
+}
+
+// Reset closes the circuit and forgets previous restarts.
+func (r *RestartPolicy) Reset() {
+	r.lock.Lock()
+	r.restarts = nil
+	r.circuitOpen = false
+	r.stopExpected = false
+	r.lock.Unlock()
+}
+
+// next decides what to do after the plugin exited.  failed is true when the
+// plugin reported an error rather than stopping.  It returns whether to
+// restart, the delay before doing so, and whether the circuit just opened.
+func (r *RestartPolicy) next(failed bool, now time.Time) (bool, time.Duration, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stopExpected {
+		r.stopExpected = false
+		return false, 0, false
+	}
+	if r.circuitOpen {
+		return false, 0, false
+	}
+	switch r.Policy {
+	case RestartAlways:
+	case RestartOnFailure:
+		if !failed {
+			return false, 0, false
+		}
+	default:
+		return false, 0, false
+	}
+
+	recent := r.restarts[:0]
+	for _, t := range r.restarts {
+		if now.Sub(t) < r.Window {
+			recent = append(recent, t)
+		}
+	}
+	r.restarts = recent
+	if len(r.restarts) >= r.Max {
+		r.circuitOpen = true
+		return false, 0, true
+	}
+
+	delay := r.Backoff << len(r.restarts)
+	if delay <= 0 || delay > r.BackoffMax {
+		delay = r.BackoffMax
+	}
+	r.restarts = append(r.restarts, now)
+	return true, delay, false
+}
+
+// restartPolicy returns the plugin's policy, reading it from the deployment
+// config on first use.
+func (pluginHandler *PluginHandler) restartPolicy() *RestartPolicy {
+	pluginHandler.restartLock.Lock()
+	defer pluginHandler.restartLock.Unlock()
+	if pluginHandler.RestartPolicy == nil {
+		pluginHandler.RestartPolicy = NewRestartPolicy(pluginHandler.DeploymentConfig)
+	}
+	return pluginHandler.RestartPolicy
+}
+
+// expectStop is called before the kernel itself stops a plugin.
+func (pluginHandler *PluginHandler) expectStop() {
+	pluginHandler.restartPolicy().ExpectStop()
+}
+
+// handleExit applies the restart policy after a plugin failed or stopped.
+func (pluginHandler *PluginHandler) handleExit(driverConfig *config.DriverConfig, failed bool, reason string) {
+	policy := pluginHandler.restartPolicy()
+	restart, delay, circuitOpened := policy.next(failed, time.Now())
+	if circuitOpened {
+		pluginHandler.setState(2, fmt.Sprintf("circuit open after %d restarts in %s: %s", policy.Max, policy.Window, reason))
+		driverConfig.CoreConfig.Log.Printf("Plugin %s exceeded %d restarts in %s, giving up.\n", pluginHandler.Name, policy.Max, policy.Window)
+		if pluginHandler.kernel != nil {
+			pluginHandler.kernel.sendServiceFailureBroadcast(driverConfig,
+				fmt.Sprintf("Service %s is degraded: restart limit of %d in %s reached. Last failure: %s", pluginHandler.Name, policy.Max, policy.Window, reason))
+		}
+		return
+	}
+	if !restart {
+		return
+	}
+	if pluginHandler.restartConfig == nil {
+		driverConfig.CoreConfig.Log.Printf("Restart not supported for plugin %s\n", pluginHandler.Name)
+		return
+	}
+
+	driverConfig.CoreConfig.Log.Printf("Restarting plugin %s in %s (%s)\n", pluginHandler.Name, delay, reason)
+	go func() {
+		time.Sleep(delay)
+		pluginHandler.setRestartReason(reason)
+		pluginHandler.rerun(driverConfig)
+	}()
+}
+
+// rerun runs the plugin again from its last config.
+func (pluginHandler *PluginHandler) rerun(driverConfig *config.DriverConfig) {
+	pluginHandler.RunPlugin(driverConfig, pluginHandler.Name, pluginHandler.restartConfig)
+	if pluginHandler.restartStats {
+		// RunPlugin replaces the DfsChan, so the previous stat handler is no longer listening.
+		_, statMod, statVault, err := eUtils.InitVaultMod(driverConfig)
+		if err != nil {
+			driverConfig.CoreConfig.Log.Printf("Problem initializing stat mod for %s: %s  Continuing without stats.\n", pluginHandler.Name, err)
+			return
+		}
+		if statVault != nil {
+			defer statVault.Close()
+		}
+		go pluginHandler.handleDataflowStat(driverConfig, statMod, nil)
+	}
+}
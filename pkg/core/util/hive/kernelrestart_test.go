@@ -0,0 +1,71 @@
+package hive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyBackoffAndCircuit(t *testing.T) {
+	policy := NewRestartPolicy(map[string]any{
+		"trcrestartpolicy":     RestartOnFailure,
+		"trcrestartmax":        "3",
+		"trcrestartwindow":     "1m",
+		"trcrestartbackoff":    "1s",
+		"trcrestartbackoffmax": "3s",
+	})
+	now := time.Now()
+
+	if restart, _, _ := policy.next(false, now); restart {
+		t.Fatalf("Expected no restart after a clean stop with on-failure")
+	}
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		restart, delay, opened := policy.next(true, now)
+		if !restart || opened || delay != expected {
+			t.Fatalf("Restart %d: expected restart after %s, got %v %s %v", i, expected, restart, delay, opened)
+		}
+	}
+	if restart, _, opened := policy.next(true, now); restart || !opened || !policy.CircuitOpen() {
+		t.Fatalf("Expected circuit to open after 3 restarts")
+	}
+
+	policy.Reset()
+	policy.ExpectStop()
+	if restart, _, _ := policy.next(true, now); restart {
+		t.Fatalf("Expected no restart after kernel requested stop")
+	}
+	if restart, delay, _ := policy.next(true, now.Add(2*time.Minute)); !restart || delay != time.Second {
+		t.Fatalf("Expected restart counting to start over after reset")
+	}
+}
+
+func TestRestartPolicyDefaultsToNever(t *testing.T) {
+	if restart, _, _ := NewRestartPolicy(nil).next(true, time.Now()); restart {
+		t.Fatalf("Expected no restart without a policy")
+	}
+}
+
+func TestCheckRestartPolicy(t *testing.T) {
+	tests := []struct {
+		config map[string]any
+		ok     bool
+	}{
+		{map[string]any{}, true},
+		{map[string]any{"trcrestartpolicy": RestartAlways, "trcrestartmax": "3", "trcrestartwindow": "1m", "trcrestartbackoff": "1s", "trcrestartbackoffmax": "3s"}, true},
+		{map[string]any{"trcrestartpolicy": "sometimes"}, false},
+		{map[string]any{"trcrestartpolicy": "Always"}, false},
+		{map[string]any{"trcrestartmax": "0"}, false},
+		{map[string]any{"trcrestartmax": "-1"}, false},
+		{map[string]any{"trcrestartmax": "three"}, false},
+		{map[string]any{"trcrestartmax": 2.5}, false},
+		{map[string]any{"trcrestartwindow": "10"}, false},
+		{map[string]any{"trcrestartbackoff": "-1s"}, false},
+		{map[string]any{"trcrestartbackoffmax": ""}, false},
+		{map[string]any{"trcrestartbackoff": "10m"}, false}, // Greater than the default max of 5m.
+		{map[string]any{"trcrestartbackoff": "10m", "trcrestartbackoffmax": "1h"}, true},
+	}
+	for i, test := range tests {
+		if err := CheckRestartPolicy(test.config); (err == nil) != test.ok {
+			t.Errorf("Config %d %v: expected ok %v, got %v", i, test.config, test.ok, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package hive
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+)
+
+// Chat tracing is off unless one of these is set.  KernelChatTraceEnv sizes
+// the in memory ring buffer served by the admin api; KernelChatTraceFileEnv
+// additionally appends every record to a file as JSON lines, which
+// trcchatreplay can replay against stubbed plugins.
+const (
+	KernelChatTraceEnv     = "TRCSHK_CHAT_TRACE"
+	KernelChatTraceFileEnv = "TRCSHK_CHAT_TRACE_FILE"
+)
+
+const (
+	defaultChatTraceSize   = 1000
+	maxTracedPayloadLength = 512
+)
+
+// Trace events.  Every event other than ChatTraceReceived refers back to the
+// received message it resulted from.
+const (
+	ChatTraceReceived    = "received"
+	ChatTraceRouted      = "routed"
+	ChatTraceReplied     = "replied"
+	ChatTraceUnavailable = "unavailable"
+	ChatTraceDropped     = "dropped"
+	ChatTraceFailed      = "failed"
+)
+
+// ChatTraceRecord is a single traced chat message.  Payloads are redacted and
+// truncated.
+type ChatTraceRecord struct {
+	Seq          uint64   `json:"seq"`
+	Ref          uint64   `json:"ref,omitempty"`
+	Time         string   `json:"time"`
+	Event        string   `json:"event"`
+	From         string   `json:"from,omitempty"`
+	To           string   `json:"to,omitempty"`
+	Query        []string `json:"query,omitempty"`
+	ChatId       string   `json:"chatId,omitempty"`
+	RoutingId    string   `json:"routingId,omitempty"`
+	Broadcast    bool     `json:"broadcast,omitempty"`
+	ResponseSize int      `json:"responseSize,omitempty"`
+	Response     string   `json:"response,omitempty"`
+	LatencyMs    float64  `json:"latencyMs,omitempty"`
+}
+
+type chatTracer struct {
+	lock      sync.Mutex
+	ring      []ChatTraceRecord
+	next      int
+	full      bool
+	seq       uint64
+	encoder   *json.Encoder
+	file      *os.File
+	firstSeen map[string]time.Time // routing id -> first time seen, for round trip latency
+}
+
+var chatTrace *chatTracer
+
+var redactPattern = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|apikey|api_key|private_key|credential)[a-z_]*["']?\s*[:=]\s*["']?)([^\s"',;}]+)`)
+
+// redactPayload masks values that look like credentials and truncates the rest.
+func redactPayload(payload string) string {
+	payload = redactPattern.ReplaceAllString(payload, "${1}****")
+	if len(payload) > maxTracedPayloadLength {
+		payload = payload[:maxTracedPayloadLength] + "..."
+	}
+	return payload
+}
+
+// startChatTrace enables tracing if configured.
+func startChatTrace(driverConfig *config.DriverConfig) {
+	sizeSpec := os.Getenv(KernelChatTraceEnv)
+	traceFile := os.Getenv(KernelChatTraceFileEnv)
+	if sizeSpec == "" && traceFile == "" {
+		return
+	}
+	size := defaultChatTraceSize
+	if s, err := strconv.Atoi(sizeSpec); err == nil && s > 0 {
+		size = s
+	}
+	tracer := &chatTracer{
+		ring:      make([]ChatTraceRecord, size),
+		firstSeen: map[string]time.Time{},
+	}
+	if traceFile != "" {
+		file, err := os.OpenFile(traceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			driverConfig.CoreConfig.Log.Printf("Unable to open chat trace file %s: %v\n", traceFile, err)
+		} else {
+			tracer.file = file
+			tracer.encoder = json.NewEncoder(file)
+		}
+	}
+	driverConfig.CoreConfig.Log.Printf("Chat tracing enabled, keeping last %d messages\n", size)
+	chatTrace = tracer
+}
+
+// trace records msg and returns its sequence number, or 0 if tracing is off.
+func (t *chatTracer) trace(event string, from string, to string, msg *tccore.ChatMsg, received time.Time, ref uint64) uint64 {
+	if t == nil || msg == nil {
+		return 0
+	}
+	now := time.Now()
+	record := ChatTraceRecord{
+		Ref:       ref,
+		Time:      now.UTC().Format(time.RFC3339Nano),
+		Event:     event,
+		From:      from,
+		To:        to,
+		Broadcast: msg.IsBroadcast,
+	}
+	if msg.Query != nil && len(*msg.Query) > 0 {
+		record.Query = append([]string{}, *msg.Query...)
+	}
+	if msg.ChatId != nil {
+		record.ChatId = *msg.ChatId
+	}
+	if msg.RoutingId != nil {
+		record.RoutingId = *msg.RoutingId
+	}
+	if msg.Response != nil {
+		record.ResponseSize = len(*msg.Response)
+		record.Response = redactPayload(*msg.Response)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch event {
+	case ChatTraceReceived:
+		if record.RoutingId != "" {
+			// Replies carry the routing id of their request, report the round trip.
+			if first, ok := t.firstSeen[record.RoutingId]; ok {
+				record.LatencyMs = float64(now.Sub(first).Microseconds()) / 1000
+			} else {
+				if len(t.firstSeen) >= len(t.ring) {
+					t.evictFirstSeen(now)
+				}
+				t.firstSeen[record.RoutingId] = now
+			}
+		}
+	default:
+		if !received.IsZero() {
+			record.LatencyMs = float64(now.Sub(received).Microseconds()) / 1000
+		}
+	}
+	t.seq++
+	record.Seq = t.seq
+	t.ring[t.next] = record
+	t.next = (t.next + 1) % len(t.ring)
+	if t.next == 0 {
+		t.full = true
+	}
+	if t.encoder != nil {
+		t.encoder.Encode(&record)
+	}
+	return record.Seq
+}
+
+// evictFirstSeen drops routing ids not seen for a while, or the oldest one if
+// none are stale.  Callers hold the lock.
+func (t *chatTracer) evictFirstSeen(now time.Time) {
+	oldestId := ""
+	var oldest time.Time
+	for id, seen := range t.firstSeen {
+		if now.Sub(seen) > 10*time.Minute {
+			delete(t.firstSeen, id)
+		} else if oldestId == "" || seen.Before(oldest) {
+			oldestId, oldest = id, seen
+		}
+	}
+	if len(t.firstSeen) >= len(t.ring) {
+		delete(t.firstSeen, oldestId)
+	}
+}
+
+// records returns the buffered records, oldest first.
+func (t *chatTracer) records() []ChatTraceRecord {
+	if t == nil {
+		return []ChatTraceRecord{}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.full {
+		return append([]ChatTraceRecord{}, t.ring[:t.next]...)
+	}
+	return append(append([]ChatTraceRecord{}, t.ring[t.next:]...), t.ring[:t.next]...)
+}
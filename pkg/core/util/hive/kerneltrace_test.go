@@ -0,0 +1,57 @@
+package hive
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+)
+
+func TestRedactPayload(t *testing.T) {
+	redacted := redactPayload(`{"user":"admin","password":"hunter2"} token=s.abc123 apiKey: xyz`)
+	for _, secret := range []string{"hunter2", "s.abc123", "xyz"} {
+		if strings.Contains(redacted, secret) {
+			t.Fatalf("Payload leaked %s: %s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, "admin") {
+		t.Fatalf("Expected non secret values to be kept: %s", redacted)
+	}
+}
+
+func TestChatTraceRing(t *testing.T) {
+	tracer := &chatTracer{ring: make([]ChatTraceRecord, 2), firstSeen: map[string]time.Time{}}
+	routingId := "trcsh-1"
+	msg := &tccore.ChatMsg{RoutingId: &routingId}
+	request := tracer.trace(ChatTraceReceived, "trcsh", "", msg, time.Now(), 0)
+	tracer.trace(ChatTraceRouted, "trcsh", "trcshcmd", msg, time.Now(), request)
+	tracer.trace(ChatTraceReceived, "trcshcmd", "", msg, time.Now(), 0)
+
+	records := tracer.records()
+	if len(records) != 2 || records[0].Event != ChatTraceRouted || records[0].Ref != request || records[1].Seq != 3 {
+		t.Fatalf("Unexpected records: %+v", records)
+	}
+	if records[1].LatencyMs < 0 {
+		t.Fatalf("Expected round trip latency on reply: %+v", records[1])
+	}
+}
+
+func TestChatTraceFirstSeenEviction(t *testing.T) {
+	tracer := &chatTracer{ring: make([]ChatTraceRecord, 3), firstSeen: map[string]time.Time{}}
+	now := time.Now()
+	tracer.firstSeen["stale"] = now.Add(-time.Hour)
+	tracer.firstSeen["older"] = now.Add(-2 * time.Second)
+	tracer.firstSeen["newer"] = now.Add(-time.Second)
+	routingId := "trcsh-1"
+	tracer.trace(ChatTraceReceived, "trcsh", "", &tccore.ChatMsg{RoutingId: &routingId}, now, 0)
+	if len(tracer.firstSeen) != 3 || tracer.firstSeen["older"].IsZero() || tracer.firstSeen["newer"].IsZero() {
+		t.Fatalf("Expected only the stale routing id to be evicted: %v", tracer.firstSeen)
+	}
+
+	routingId = "trcsh-2"
+	tracer.trace(ChatTraceReceived, "trcsh", "", &tccore.ChatMsg{RoutingId: &routingId}, now, 0)
+	if _, ok := tracer.firstSeen["older"]; ok || len(tracer.firstSeen) != 3 {
+		t.Fatalf("Expected only the oldest routing id to be evicted: %v", tracer.firstSeen)
+	}
+}
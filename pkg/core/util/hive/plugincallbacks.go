@@ -1,5 +1,12 @@
 package hive
 
+import (
+	"context"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+	"github.com/trimble-oss/tierceron/pkg/core/util/hive/chatrequest"
+)
+
 // PluginInitFunc is the signature for a plugin's Init function
 type PluginInitFunc func(string, *map[string]any)
 
@@ -36,3 +43,16 @@ func CallPluginStart(pluginName string) {
 		startFunc()
 	}
 }
+
+// kernelChatRequest is set once the kernel starts handling chat.
+var kernelChatRequest func(context.Context, string, string, *tccore.ChatMsg) (*tccore.ChatMsg, error)
+
+// ChatRequest sends a request on behalf of the named plugin and waits for the
+// matching response.  Plugins loaded by the kernel receive the same function
+// as a chatrequest.Func in their Init properties.
+func ChatRequest(ctx context.Context, from string, target string, msg *tccore.ChatMsg) (*tccore.ChatMsg, error) {
+	if kernelChatRequest == nil {
+		return nil, &chatrequest.RequestError{Target: target, Err: chatrequest.ErrNotSupported}
+	}
+	return kernelChatRequest(ctx, from, target, msg)
+}
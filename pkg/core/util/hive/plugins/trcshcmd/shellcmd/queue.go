@@ -219,8 +219,8 @@ func ExecuteShellCommand(cmdType string, args []string, driverConfig *config.Dri
 			break
 		}
 
-		isPatch := len(args) > 0 && args[0] == "patch"
-		if isPatch {
+		isWrite := len(args) > 0 && (args[0] == "patch" || args[0] == "rollback" || args[0] == "delete")
+		if isWrite {
 			// Use unrestricted token for write access (available after su)
 			patchTokenName := fmt.Sprintf("config_%s_unrestricted", driverConfig.CoreConfig.EnvBasis)
 			err = trctvbase.CommonMain(&envDefaultPtr, &envCtx, &patchTokenName, &region, nil, argLines, driverConfig)
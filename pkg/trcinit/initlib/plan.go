@@ -0,0 +1,186 @@
+package initlib
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
+)
+
+// SeedPlan collects the changes a seed run would make without writing them.
+// Attach one to DriverConfig.Context to put SeedVault into plan mode.
+type SeedPlan struct {
+	Env     string
+	Changes []*PlanChange
+	Errors  []error // Paths that could not be compared.
+	lock    sync.Mutex
+}
+
+// PlanChange describes the key level changes planned for a single vault path.
+type PlanChange struct {
+	Path      string
+	Create    bool
+	Added     map[string]any
+	Changed   map[string][2]any // key -> old, new
+	Removed   map[string]any
+	Sensitive bool
+}
+
+// NewSeedPlan creates an empty plan for env.
+func NewSeedPlan(env string) *SeedPlan {
+	return &SeedPlan{Env: env}
+}
+
+// HasDrift returns true if applying the seeds would change anything in vault.
+func (p *SeedPlan) HasDrift() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.Changes) > 0
+}
+
+// Failed returns true if some paths could not be compared, so the plan is
+// incomplete.
+func (p *SeedPlan) Failed() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.Errors) > 0
+}
+
+// planPath resolves the path Modifier.Write would write to, relative to the
+// mount, so that it can be read back with SectionPath cleared.
+func planPath(mod *helperkv.Modifier, path string) string {
+	pathBlocks := strings.SplitAfterN(path, "/", 2)
+	if len(pathBlocks) == 1 {
+		pathBlocks[0] += "/"
+	}
+	fullPath := pathBlocks[0]
+	if mod.SectionPath != "" && !strings.HasPrefix(fullPath, "templates") {
+		fullPath += mod.SectionPath + "/"
+	}
+	if len(pathBlocks) > 1 {
+		if !strings.Contains(fullPath, "/"+pathBlocks[1]+"/") {
+			fullPath += pathBlocks[1]
+		}
+	}
+	if strings.Contains(fullPath, "/super-secrets/") {
+		fullPath = strings.ReplaceAll(fullPath, "/super-secrets/", "/")
+	}
+	return strings.TrimSuffix(fullPath, "/")
+}
+
+// Record compares data against what is currently stored at path and keeps any
+// difference.  Failures to read path are kept on the plan as well.
+func (p *SeedPlan) Record(mod *helperkv.Modifier, path string, data map[string]any) error {
+	readPath := planPath(mod, path)
+
+	sectionPath := mod.SectionPath
+	mod.SectionPath = ""
+	existing, err := mod.ReadData(readPath)
+	mod.SectionPath = sectionPath
+	if err != nil {
+		err = fmt.Errorf("unable to read %s: %w", readPath, err)
+		p.lock.Lock()
+		p.Errors = append(p.Errors, err)
+		p.lock.Unlock()
+		return err
+	}
+
+	change := &PlanChange{
+		Path:      readPath,
+		Create:    existing == nil,
+		Added:     map[string]any{},
+		Changed:   map[string][2]any{},
+		Removed:   map[string]any{},
+		Sensitive: !strings.HasPrefix(readPath, "values/") && !strings.HasPrefix(readPath, "templates/"),
+	}
+	for k, v := range data {
+		oldValue, ok := existing[k]
+		if !ok {
+			change.Added[k] = v
+		} else if fmt.Sprint(oldValue) != fmt.Sprint(v) {
+			change.Changed[k] = [2]any{oldValue, v}
+		}
+	}
+	for k, v := range existing {
+		if _, ok := data[k]; !ok {
+			change.Removed[k] = v // Vault KV v2 replaces the whole secret on write.
+		}
+	}
+	if len(change.Added)+len(change.Changed)+len(change.Removed) == 0 {
+		return nil
+	}
+
+	p.lock.Lock()
+	p.Changes = append(p.Changes, change)
+	p.lock.Unlock()
+	return nil
+}
+
+func planValue(change *PlanChange, key string, value any) string {
+	if change.Sensitive || key == "certData" {
+		return "(sensitive value)"
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
+
+func sortedPlanKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Print writes a terraform style summary of the plan.
+func (p *SeedPlan) Print(out io.Writer) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sort.Slice(p.Changes, func(i, j int) bool { return p.Changes[i].Path < p.Changes[j].Path })
+
+	added, changed, removed := 0, 0, 0
+	for _, change := range p.Changes {
+		if change.Create {
+			fmt.Fprintf(out, "  # %s (%s) will be created\n", change.Path, p.Env)
+			fmt.Fprintf(out, "  + %s {\n", change.Path)
+		} else {
+			fmt.Fprintf(out, "  # %s (%s) will be updated in-place\n", change.Path, p.Env)
+			fmt.Fprintf(out, "  ~ %s {\n", change.Path)
+		}
+		for _, k := range sortedPlanKeys(change.Added) {
+			fmt.Fprintf(out, "      + %s = %s\n", k, planValue(change, k, change.Added[k]))
+		}
+		for _, k := range sortedPlanKeys(change.Changed) {
+			values := change.Changed[k]
+			fmt.Fprintf(out, "      ~ %s = %s -> %s\n", k, planValue(change, k, values[0]), planValue(change, k, values[1]))
+		}
+		for _, k := range sortedPlanKeys(change.Removed) {
+			fmt.Fprintf(out, "      - %s = %s\n", k, planValue(change, k, change.Removed[k]))
+		}
+		fmt.Fprintln(out, "    }")
+		fmt.Fprintln(out)
+		added += len(change.Added)
+		changed += len(change.Changed)
+		removed += len(change.Removed)
+	}
+
+	for _, err := range p.Errors {
+		fmt.Fprintf(out, "  ! %v\n", err)
+	}
+	if len(p.Errors) > 0 {
+		fmt.Fprintf(out, "Plan incomplete: %d paths could not be compared.\n", len(p.Errors))
+	}
+
+	if len(p.Changes) == 0 {
+		if len(p.Errors) > 0 {
+			return
+		}
+		fmt.Fprintf(out, "No changes. Vault %s matches the seed files.\n", p.Env)
+		return
+	}
+	fmt.Fprintf(out, "Plan: %d paths to change; %d keys to add, %d to change, %d to remove.\n", len(p.Changes), added, changed, removed)
+}
@@ -56,7 +56,7 @@ func GetTemplateParam(driverConfig *config.DriverConfig, mod *helperkv.Modifier,
 
 	templateStr := string(templateBytes)
 
-	t := template.New("template")
+	t := template.New("template").Funcs(template.FuncMap(vcutils.TemplateParseFuncMap()))
 	t, err = t.Parse(templateStr)
 	if err != nil {
 		return "", err
@@ -871,6 +871,10 @@ func SeedVaultFromData(driverConfig *config.DriverConfig, filepath string, fData
 		}
 	}
 
+	if _, isPlan := driverConfig.Context.(*SeedPlan); isPlan {
+		return nil // Nothing written, so nothing to verify.
+	}
+
 	// Run verification after seeds have been written
 	warn, err := verify(driverConfig.CoreConfig, mod, verificationData)
 	eUtils.LogErrorObject(driverConfig.CoreConfig, err, false)
@@ -893,6 +897,13 @@ func WriteData(driverConfig *config.DriverConfig, path string, data map[string]a
 			return mod
 		}
 	}
+	if plan, isPlan := driverConfig.Context.(*SeedPlan); isPlan {
+		// Plan mode: compare against vault instead of writing.
+		if err := plan.Record(mod, path, data); err != nil {
+			eUtils.LogErrorObject(driverConfig.CoreConfig, err, false)
+		}
+		return mod
+	}
 	tokenName := fmt.Sprintf("config_token_%s_unrestricted", driverConfig.CoreConfig.EnvBasis)
 	if driverConfig.CoreConfig.CurrentTokenNamePtr != nil &&
 		driverConfig.CoreConfig.TokenCache.GetToken(*driverConfig.CoreConfig.CurrentTokenNamePtr) != nil {
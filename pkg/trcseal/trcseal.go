@@ -0,0 +1,238 @@
+// Package trcseal encrypts blobs at rest with either a passphrase or an X25519
+// key pair.  Sealed output is
+//
+//	<magic><json envelope>\n<AES-256-GCM ciphertext>
+//
+// where the envelope records how the key was derived and is authenticated as
+// additional data so it cannot be altered.
+package trcseal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	modePassphrase = "passphrase"
+	modeX25519     = "x25519"
+)
+
+// Key holds the key material used to seal or open.  Sealing uses PublicKey
+// (or the public half of PrivateKey) when present and Passphrase otherwise.
+type Key struct {
+	Passphrase []byte
+	PublicKey  *ecdh.PublicKey
+	PrivateKey *ecdh.PrivateKey
+}
+
+type envelope struct {
+	Mode         string `json:"mode"`
+	Salt         []byte `json:"salt,omitempty"`
+	EphemeralKey []byte `json:"ephemeralKey,omitempty"`
+	Nonce        []byte `json:"nonce"`
+}
+
+// ParsePublicKey parses a PEM encoded X25519 public key such as one produced by
+// openssl pkey -in key.pem -pubout.
+func ParsePublicKey(pemBytes []byte) (*ecdh.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM data found in public key")
+	}
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := parsedKey.(*ecdh.PublicKey)
+	if !ok || publicKey.Curve() != ecdh.X25519() {
+		return nil, errors.New("public key is not an X25519 key")
+	}
+	return publicKey, nil
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS8 X25519 private key such as one produced by
+// openssl genpkey -algorithm X25519.
+func ParsePrivateKey(pemBytes []byte) (*ecdh.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM data found in private key")
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsedKey.(*ecdh.PrivateKey)
+	if !ok || privateKey.Curve() != ecdh.X25519() {
+		return nil, errors.New("private key is not an X25519 key")
+	}
+	return privateKey, nil
+}
+
+// GeneratePrivateKey creates a new X25519 private key and returns it with its PEM encoding.
+func GeneratePrivateKey() (*ecdh.PrivateKey, []byte, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func passphraseKey(passphrase []byte, salt []byte) ([]byte, error) {
+	return scrypt.Key(passphrase, salt, 1<<15, 8, 1, 32)
+}
+
+func x25519Key(magic string, sharedSecret []byte, ephemeralKey []byte, recipientKey []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeralKey...), recipientKey...)
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte(magic)), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext and prefixes it with magic.
+func Seal(magic string, plaintext []byte, sealKey *Key) ([]byte, error) {
+	env := envelope{}
+	var key []byte
+
+	publicKey := sealKey.PublicKey
+	if publicKey == nil && sealKey.PrivateKey != nil {
+		publicKey = sealKey.PrivateKey.PublicKey()
+	}
+
+	switch {
+	case publicKey != nil:
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		sharedSecret, err := ephemeral.ECDH(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		env.Mode = modeX25519
+		env.EphemeralKey = ephemeral.PublicKey().Bytes()
+		key, err = x25519Key(magic, sharedSecret, env.EphemeralKey, publicKey.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	case len(sealKey.Passphrase) > 0:
+		env.Mode = modePassphrase
+		env.Salt = make([]byte, 16)
+		if _, err := rand.Read(env.Salt); err != nil {
+			return nil, err
+		}
+		var err error
+		key, err = passphraseKey(sealKey.Passphrase, env.Salt)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("a passphrase or public key is required to seal")
+	}
+
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteString(magic)
+	out.Write(header)
+	out.WriteByte('\n')
+	out.Write(aead.Seal(nil, env.Nonce, plaintext, header))
+	return out.Bytes(), nil
+}
+
+// Open decrypts data produced by Seal with the same magic.
+func Open(magic string, sealed []byte, openKey *Key) ([]byte, error) {
+	if !bytes.HasPrefix(sealed, []byte(magic)) {
+		return nil, errors.New("unrecognized sealed data")
+	}
+	sealed = sealed[len(magic):]
+	headerEnd := bytes.IndexByte(sealed, '\n')
+	if headerEnd < 0 {
+		return nil, errors.New("malformed sealed data header")
+	}
+	header := sealed[:headerEnd]
+	ciphertext := sealed[headerEnd+1:]
+
+	env := envelope{}
+	if err := json.Unmarshal(header, &env); err != nil {
+		return nil, fmt.Errorf("malformed sealed data header: %w", err)
+	}
+
+	var key []byte
+	switch env.Mode {
+	case modeX25519:
+		if openKey.PrivateKey == nil {
+			return nil, errors.New("data was sealed to a public key; a private key is required")
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(env.EphemeralKey)
+		if err != nil {
+			return nil, err
+		}
+		sharedSecret, err := openKey.PrivateKey.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		key, err = x25519Key(magic, sharedSecret, env.EphemeralKey, openKey.PrivateKey.PublicKey().Bytes())
+		if err != nil {
+			return nil, err
+		}
+	case modePassphrase:
+		if len(openKey.Passphrase) == 0 {
+			return nil, errors.New("data was sealed with a passphrase; a passphrase is required")
+		}
+		var err error
+		key, err = passphraseKey(openKey.Passphrase, env.Salt)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported seal mode %q", env.Mode)
+	}
+
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, ciphertext, header)
+	if err != nil {
+		return nil, errors.New("unable to open sealed data: wrong key or corrupted data")
+	}
+	return plaintext, nil
+}
@@ -93,7 +93,7 @@ func ToSeed(driverConfig *config.DriverConfig, mod *helperkv.Modifier,
 	}
 
 	// Parse template
-	t := template.New("template")
+	t := template.New("template").Funcs(template.FuncMap(vcutils.TemplateParseFuncMap()))
 	theTemplate, err := t.Parse(newTemplate)
 	if err != nil {
 		return nil, nil, nil, 0, eUtils.LogAndSafeExit(driverConfig.CoreConfig, err.Error(), -1)
@@ -102,31 +102,29 @@ func ToSeed(driverConfig *config.DriverConfig, mod *helperkv.Modifier,
 
 	for _, node := range commandList.Nodes {
 		if node.Type() == parse.NodeAction {
-			var args []string
-			fields := node.(*parse.ActionNode).Pipe
-			for _, arg := range fields.Cmds[0].Args {
-				templateParameter := strings.ReplaceAll(arg.String(), "\\\"", "\"")
-				if strings.Contains(templateParameter, "~") {
-					eUtils.LogInfo(driverConfig.CoreConfig, "Unsupported parameter name character ~: "+templateParameter)
-					return nil, nil, nil, 0, errors.New("Unsupported parameter name character ~: " + templateParameter)
+			for _, args := range templateKeyArgs(node.(*parse.ActionNode).Pipe) {
+				for _, templateParameter := range args {
+					if strings.Contains(templateParameter, "~") {
+						eUtils.LogInfo(driverConfig.CoreConfig, "Unsupported parameter name character ~: "+templateParameter)
+						return nil, nil, nil, 0, errors.New("Unsupported parameter name character ~: " + templateParameter)
+					}
 				}
-				args = append(args, templateParameter)
-			}
 
-			// Gets the parsed file line
-			errParse := Parse(driverConfig.CoreConfig, cds,
-				args,
-				pathSlice[len(pathSlice)-2],
-				templatePathSlice,
-				templateDir,
-				templateDepth,
-				service,
-				interfaceTemplateSection,
-				valueSection,
-				secretSection,
-			)
-			if errParse != nil {
-				return nil, nil, nil, 0, errParse
+				// Gets the parsed file line
+				errParse := Parse(driverConfig.CoreConfig, cds,
+					args,
+					pathSlice[len(pathSlice)-2],
+					templatePathSlice,
+					templateDir,
+					templateDepth,
+					service,
+					interfaceTemplateSection,
+					valueSection,
+					secretSection,
+				)
+				if errParse != nil {
+					return nil, nil, nil, 0, errParse
+				}
 			}
 		}
 	}
@@ -134,6 +132,53 @@ func ToSeed(driverConfig *config.DriverConfig, mod *helperkv.Modifier,
 	return interfaceTemplateSection, valueSection, secretSection, templateDepth, nil
 }
 
+// templateKeyArgs returns the arguments Parse takes for each key used by a
+// template action: [.key] for a secret or [or .key "default"] for a value.
+// Template functions such as b64enc or default are skipped for the keys they
+// are given, e.g. {{ b64enc .key }} or {{ .key | b64enc }} use the secret key
+// and {{ index . "key" }} is the same as {{ .key }}.
+func templateKeyArgs(pipe *parse.PipeNode) [][]string {
+	keyArgs := [][]string{}
+	if pipe == nil {
+		return keyArgs
+	}
+	for i, cmd := range pipe.Cmds {
+		if len(cmd.Args) == 0 {
+			continue
+		}
+		function, isFunction := cmd.Args[0].(*parse.IdentifierNode)
+		if !isFunction || function.Ident == "or" {
+			if i > 0 {
+				// Piped into, e.g. {{ b64enc .key | or .other "default" }}.
+				continue
+			}
+			args := []string{}
+			for _, arg := range cmd.Args {
+				args = append(args, strings.ReplaceAll(arg.String(), "\\\"", "\""))
+			}
+			keyArgs = append(keyArgs, args)
+			continue
+		}
+		if function.Ident == "index" && len(cmd.Args) == 3 {
+			if _, isDot := cmd.Args[1].(*parse.DotNode); isDot {
+				if key, isString := cmd.Args[2].(*parse.StringNode); isString {
+					keyArgs = append(keyArgs, []string{"." + key.Text})
+					continue
+				}
+			}
+		}
+		for _, arg := range cmd.Args[1:] {
+			switch arg := arg.(type) {
+			case *parse.FieldNode:
+				keyArgs = append(keyArgs, []string{arg.String()})
+			case *parse.PipeNode:
+				keyArgs = append(keyArgs, templateKeyArgs(arg)...)
+			}
+		}
+	}
+	return keyArgs
+}
+
 // GetInitialTemplateStructure Initializes the structure of the template section using the template directory path
 // Input:
 //   - A slice of the template file path delimited by "/"
@@ -0,0 +1,45 @@
+package extract
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+	"text/template/parse"
+
+	vcutils "github.com/trimble-oss/tierceron/pkg/cli/trcconfigbase/utils"
+)
+
+func TestTemplateKeyArgs(t *testing.T) {
+	templateText := `host: {{or .host "localhost"}}
+password: {{.password}}
+cert: {{ b64enc .cert }}
+key: {{ .key | b64enc }}
+port: {{ index . "port" | default 8080 }}
+user: {{ b64enc (or .user "admin") }}
+token: {{ required "token is required" .token | quote }}
+tls: {{ include "tls.yml" . | nindent 2 }}
+env: {{ env }}
+`
+	theTemplate, err := template.New("template").Funcs(template.FuncMap(vcutils.TemplateParseFuncMap())).Parse(templateText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyArgs := [][]string{}
+	for _, node := range theTemplate.Tree.Root.Nodes {
+		if action, ok := node.(*parse.ActionNode); ok {
+			keyArgs = append(keyArgs, templateKeyArgs(action.Pipe)...)
+		}
+	}
+	expected := [][]string{
+		{"or", ".host", `"localhost"`},
+		{".password"},
+		{".cert"},
+		{".key"},
+		{".port"},
+		{"or", ".user", `"admin"`},
+		{".token"},
+	}
+	if !reflect.DeepEqual(keyArgs, expected) {
+		t.Fatalf("key args %v, expected %v", keyArgs, expected)
+	}
+}
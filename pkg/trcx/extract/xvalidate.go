@@ -0,0 +1,391 @@
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	eUtils "github.com/trimble-oss/tierceron/pkg/utils"
+	"github.com/trimble-oss/tierceron/pkg/utils/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic severities.  Only errors fail validation.
+const (
+	SeedError   = "error"
+	SeedWarning = "warning"
+)
+
+// Top level seed sections, one per secret engine.
+var seedSections = map[string]bool{
+	"apiLogins":     true,
+	"templates":     true,
+	"values":        true,
+	"super-secrets": true,
+	"value-metrics": true,
+	"verification":  true,
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// SeedDiagnostic is a problem found in a seed file.
+type SeedDiagnostic struct {
+	File     string
+	Line     int
+	Severity string
+	Message  string
+}
+
+func (d SeedDiagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+}
+
+// SeedSchema holds the keys templates declare, by section and service.
+type SeedSchema struct {
+	Keys      map[string]map[string]map[string]bool // section -> service -> key
+	Templates map[string]any                        // template section with [section/service, key] links
+}
+
+// SeedSchemaFromTemplates parses templates the way seeds are generated from
+// them to derive the keys a seed must provide.
+func SeedSchemaFromTemplates(driverConfig *config.DriverConfig, templatePaths []string) (*SeedSchema, error) {
+	schema := &SeedSchema{
+		Keys: map[string]map[string]map[string]bool{
+			"values":        {},
+			"super-secrets": {},
+		},
+		Templates: map[string]any{},
+	}
+	for _, templatePath := range templatePaths {
+		project, service, _, templatePath := eUtils.GetProjectService(driverConfig, templatePath)
+		var templateSection any
+		valueSection := map[string]map[string]map[string]string{"values": {}}
+		secretSection := map[string]map[string]map[string]string{"super-secrets": {}}
+		_, _, _, _, err := ToSeed(driverConfig, nil, nil, templatePath, project, service, false, &templateSection, &valueSection, &secretSection)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", templatePath, err)
+		}
+		for section, sectionData := range map[string]map[string]map[string]string{"values": valueSection["values"], "super-secrets": secretSection["super-secrets"]} {
+			for sectionService, keys := range sectionData {
+				if schema.Keys[section][sectionService] == nil {
+					schema.Keys[section][sectionService] = map[string]bool{}
+				}
+				for key := range keys {
+					schema.Keys[section][sectionService][key] = true
+				}
+			}
+		}
+		if templates, ok := templateSection.(map[string]any); ok {
+			if templates, ok := templates["templates"].(map[string]any); ok {
+				mergeTemplateSection(schema.Templates, templates)
+			}
+		}
+	}
+	return schema, nil
+}
+
+func mergeTemplateSection(dst map[string]any, src map[string]any) {
+	for k, v := range src {
+		if srcChild, ok := v.(map[string]any); ok {
+			dstChild, ok := dst[k].(map[string]any)
+			if !ok {
+				dstChild = map[string]any{}
+				dst[k] = dstChild
+			}
+			mergeTemplateSection(dstChild, srcChild)
+		} else {
+			dst[k] = v
+		}
+	}
+}
+
+// seedValidator collects the diagnostics for a single seed file.
+type seedValidator struct {
+	schema      *SeedSchema
+	file        string
+	diagnostics []SeedDiagnostic
+}
+
+func (v *seedValidator) report(node *yaml.Node, severity string, format string, args ...any) {
+	line := 1
+	if node != nil && node.Line > 0 {
+		line = node.Line
+	}
+	v.diagnostics = append(v.diagnostics, SeedDiagnostic{File: v.file, Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateSeed checks a seed file against schema for missing keys,
+// unexpected keys, empty secrets, type mismatches and keys in the wrong
+// section.  Diagnostics are ordered by line.
+func ValidateSeed(schema *SeedSchema, file string, seed []byte) []SeedDiagnostic {
+	v := &seedValidator{schema: schema, file: file}
+	var root yaml.Node
+	if err := yaml.Unmarshal(seed, &root); err != nil {
+		line := 1
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		return []SeedDiagnostic{{File: file, Line: line, Severity: SeedError, Message: err.Error()}}
+	}
+	if len(root.Content) == 0 {
+		v.report(&root, SeedError, "seed is empty")
+		return v.diagnostics
+	}
+	document := root.Content[0]
+	if document.Kind != yaml.MappingNode {
+		v.report(document, SeedError, "expected sections templates, values and super-secrets")
+		return v.diagnostics
+	}
+
+	sectionNodes := map[string]*yaml.Node{}
+	sectionKeyNodes := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		keyNode, valueNode := document.Content[i], document.Content[i+1]
+		if !seedSections[keyNode.Value] {
+			v.report(keyNode, SeedError, "unexpected section %q%s", keyNode.Value, suggestion(keyNode.Value, mapKeys(seedSections)))
+			continue
+		}
+		if valueNode.Kind != yaml.MappingNode && !isNull(valueNode) {
+			v.report(valueNode, SeedError, "type mismatch: section %s must be a mapping, found %s", keyNode.Value, kindName(valueNode))
+			continue
+		}
+		sectionNodes[keyNode.Value] = valueNode
+		sectionKeyNodes[keyNode.Value] = keyNode
+	}
+
+	if templates, ok := sectionNodes["templates"]; ok {
+		v.validateTemplates(templates, nil, v.schema.Templates)
+	}
+
+	seedServices := map[string]bool{}
+	seen := map[string]map[string]map[string]bool{}
+	serviceNodes := map[string]map[string]*yaml.Node{}
+	for _, section := range []string{"values", "super-secrets"} {
+		seen[section] = map[string]map[string]bool{}
+		serviceNodes[section] = map[string]*yaml.Node{}
+		sectionNode, ok := sectionNodes[section]
+		if !ok {
+			continue
+		}
+		for i := 0; i+1 < len(sectionNode.Content); i += 2 {
+			serviceKey, serviceValue := sectionNode.Content[i], sectionNode.Content[i+1]
+			service := serviceKey.Value
+			seedServices[service] = true
+			serviceNodes[section][service] = serviceKey
+			seen[section][service] = v.validateService(section, service, serviceValue)
+		}
+	}
+
+	// Report missing keys for every service the seed provides.
+	for _, service := range sortedKeys(seedServices) {
+		for _, section := range []string{"values", "super-secrets"} {
+			missingAt := serviceNodes[section][service]
+			if missingAt == nil {
+				missingAt = sectionKeyNodes[section]
+			}
+			for _, key := range sortedKeys(v.schema.Keys[section][service]) {
+				if seen[section][service][key] {
+					continue
+				}
+				if seen[otherSection(section)][service][key] {
+					// Already reported as being in the wrong section.
+					continue
+				}
+				v.report(missingAt, SeedError, "missing key %s in %s/%s", key, section, service)
+			}
+		}
+	}
+
+	sort.SliceStable(v.diagnostics, func(i, j int) bool { return v.diagnostics[i].Line < v.diagnostics[j].Line })
+	return v.diagnostics
+}
+
+// validateService checks the keys of one service and returns the template
+// keys it saw, including those in the wrong section.
+func (v *seedValidator) validateService(section string, service string, serviceNode *yaml.Node) map[string]bool {
+	seen := map[string]bool{}
+	if isNull(serviceNode) {
+		return seen
+	}
+	if serviceNode.Kind != yaml.MappingNode {
+		v.report(serviceNode, SeedError, "type mismatch: %s/%s must be a mapping of keys, found %s", section, service, kindName(serviceNode))
+		return seen
+	}
+	expected, known := v.schema.Keys[section][service]
+	otherExpected, otherKnown := v.schema.Keys[otherSection(section)][service]
+	if !known && !otherKnown {
+		v.report(serviceNode, SeedWarning, "no template declares service %s", service)
+		return seen
+	}
+	for i := 0; i+1 < len(serviceNode.Content); i += 2 {
+		keyNode, valueNode := serviceNode.Content[i], serviceNode.Content[i+1]
+		key, _, _ := strings.Cut(keyNode.Value, "~") // Region overrides share the key of their base value.
+		switch {
+		case expected[key]:
+			seen[key] = true
+		case otherExpected[key]:
+			seen[key] = true
+			v.report(keyNode, SeedError, "%s of %s belongs in the %s section", key, service, otherSection(section))
+			continue
+		default:
+			v.report(keyNode, SeedError, "unexpected key %s in %s/%s%s", keyNode.Value, section, service, suggestion(key, expected))
+			continue
+		}
+		if valueNode.Kind != yaml.ScalarNode {
+			v.report(valueNode, SeedError, "type mismatch: %s must be a single value, found %s (check nesting)", keyNode.Value, kindName(valueNode))
+			continue
+		}
+		if section == "super-secrets" && key != "certData" && (strings.TrimSpace(valueNode.Value) == "" || valueNode.Value == defaultSecret) {
+			v.report(valueNode, SeedError, "empty secret %s in %s", keyNode.Value, service)
+		}
+	}
+	return seen
+}
+
+// validateTemplates checks that the templates section only holds links to
+// values and secrets, matching the template declarations where known.
+func (v *seedValidator) validateTemplates(node *yaml.Node, path []string, expected map[string]any) {
+	if isNull(node) {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		childPath := append(append([]string{}, path...), keyNode.Value)
+		var expectedChild any
+		expectedKnown := false
+		if expected != nil {
+			expectedChild, expectedKnown = expected[keyNode.Value]
+		}
+		if valueNode.Kind == yaml.MappingNode {
+			expectedMap, _ := expectedChild.(map[string]any)
+			if expectedKnown && expectedMap == nil {
+				v.report(valueNode, SeedError, "type mismatch: templates/%s must be a link, found mapping", strings.Join(childPath, "/"))
+				continue
+			}
+			v.validateTemplates(valueNode, childPath, expectedMap)
+			continue
+		}
+
+		linkSection, ok := templateLinkSection(valueNode)
+		if !ok {
+			v.report(valueNode, SeedError, "templates/%s holds a value, templates may only link to values or super-secrets like [values/Service, key]", strings.Join(childPath, "/"))
+			continue
+		}
+		if linkSection != "values" && linkSection != "super-secrets" {
+			v.report(valueNode, SeedError, "templates/%s links to unknown section %s", strings.Join(childPath, "/"), linkSection)
+			continue
+		}
+		if expected == nil {
+			continue
+		}
+		if !expectedKnown {
+			v.report(keyNode, SeedError, "unexpected key %s in templates/%s%s", keyNode.Value, strings.Join(path, "/"), suggestion(keyNode.Value, expected))
+			continue
+		}
+		if expectedLink, ok := expectedChild.(string); ok && !strings.HasPrefix(expectedLink, "["+linkSection+"/") {
+			expectedSection, _, _ := strings.Cut(strings.TrimPrefix(expectedLink, "["), "/")
+			v.report(valueNode, SeedError, "templates/%s links to %s but the template declares it in %s", strings.Join(childPath, "/"), linkSection, expectedSection)
+		}
+	}
+}
+
+// templateLinkSection returns the section a template link points to.  Links
+// are a [section/service, key] sequence, or the same as a string.
+func templateLinkSection(node *yaml.Node) (string, bool) {
+	var target string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if len(node.Content) != 2 || node.Content[0].Kind != yaml.ScalarNode || node.Content[1].Kind != yaml.ScalarNode {
+			return "", false
+		}
+		target = node.Content[0].Value
+	case yaml.ScalarNode:
+		if !strings.HasPrefix(node.Value, "[") || !strings.HasSuffix(node.Value, "]") || !strings.Contains(node.Value, ",") {
+			return "", false
+		}
+		target = strings.TrimPrefix(node.Value, "[")
+	default:
+		return "", false
+	}
+	section, _, found := strings.Cut(target, "/")
+	return section, found
+}
+
+func otherSection(section string) string {
+	if section == "values" {
+		return "super-secrets"
+	}
+	return "values"
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func kindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.ScalarNode:
+		return "value"
+	case yaml.AliasNode:
+		return "alias"
+	}
+	return "document"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mapKeys[V any](m map[string]V) map[string]bool {
+	keys := map[string]bool{}
+	for k := range m {
+		keys[k] = true
+	}
+	return keys
+}
+
+// suggestion offers the closest expected key for a likely misspelling.
+func suggestion[V any](key string, expected map[string]V) string {
+	best, bestDistance := "", 3
+	for _, candidate := range sortedKeys(expected) {
+		if distance := editDistance(strings.ToLower(key), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", best)
+}
+
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,58 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateSeed(t *testing.T) {
+	schema := &SeedSchema{
+		Keys: map[string]map[string]map[string]bool{
+			"values":        {"Hello": {"host": true, "port": true}},
+			"super-secrets": {"Hello": {"password": true}},
+		},
+		Templates: map[string]any{
+			"hello": map[string]any{
+				"config": map[string]any{
+					"host":     "[values/Hello, host]",
+					"port":     "[values/Hello, port]",
+					"password": "[super-secrets/Hello, password]",
+				},
+			},
+		},
+	}
+	seed := `templates:
+  hello:
+    config:
+      host: [values/Hello, host]
+      port: 8080
+      password: [values/Hello, password]
+values:
+  Hello:
+    hots: example.com
+    password: hunter2
+super-secrets:
+  Hello: {}
+`
+	diagnostics := ValidateSeed(schema, "dev_seed.yml", []byte(seed))
+	messages := []string{}
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+	expected := []string{
+		"dev_seed.yml:5: error: templates/hello/config/port holds a value, templates may only link to values or super-secrets like [values/Service, key]",
+		"dev_seed.yml:6: error: templates/hello/config/password links to values but the template declares it in super-secrets",
+		"dev_seed.yml:8: error: missing key host in values/Hello",
+		"dev_seed.yml:8: error: missing key port in values/Hello",
+		"dev_seed.yml:9: error: unexpected key hots in values/Hello (did you mean host?)",
+		"dev_seed.yml:10: error: password of Hello belongs in the super-secrets section",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Fatalf("Unexpected diagnostics:\n%v", messages)
+	}
+
+	diagnostics = ValidateSeed(schema, "dev_seed.yml", []byte("values:\n  Hello:\n    host: a\n\tport: b\n"))
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeedError || diagnostics[0].Line < 2 {
+		t.Fatalf("Expected a parse error with its line, got %v", diagnostics)
+	}
+}
@@ -473,6 +473,21 @@ func AutoAuth(driverConfig *config.DriverConfig,
 	if RefLength(wantedTokenNamePtr) > 0 {
 		tokenPtr = driverConfig.CoreConfig.TokenCache.GetToken(*wantedTokenNamePtr)
 	}
+	if helperkv.IsFileBackendAddress(*addrPtr) {
+		// A local file backend has nothing to authenticate against and
+		// ignores the token.
+		if tokenPtr == nil {
+			tokenPtr = new(string)
+			*tokenPtr = "filebackend"
+			if RefLength(wantedTokenNamePtr) > 0 {
+				driverConfig.CoreConfig.TokenCache.AddToken(*wantedTokenNamePtr, tokenPtr)
+			}
+		}
+		if tokenProvidedPtr != nil {
+			*tokenProvidedPtr = tokenPtr
+		}
+		return nil
+	}
 	if tokenPtr == nil && tokenProvidedPtr != nil && RefLength(*tokenProvidedPtr) > 0 {
 		if !driverConfig.CoreConfig.IsShell {
 			driverConfig.CoreConfig.CurrentTokenNamePtr = wantedTokenNamePtr
@@ -34,6 +34,46 @@ type ConfigContext struct {
 	DiffFileCount        int32
 	EnvLength            int
 	ConfigWg             sync.WaitGroup
+
+	templateFailuresLock sync.Mutex
+	templateFailures     []string
+
+	renderedLock sync.Mutex
+	rendered     map[string]map[string]string // service -> file -> rendered template
+}
+
+// AddRenderedTemplate keeps a rendered template for packaged output.
+func (cfgContext *ConfigContext) AddRenderedTemplate(service string, file string, data string) {
+	cfgContext.renderedLock.Lock()
+	defer cfgContext.renderedLock.Unlock()
+	if cfgContext.rendered == nil {
+		cfgContext.rendered = map[string]map[string]string{}
+	}
+	if cfgContext.rendered[service] == nil {
+		cfgContext.rendered[service] = map[string]string{}
+	}
+	cfgContext.rendered[service][file] = data
+}
+
+// RenderedTemplates returns the rendered templates kept for packaged output.
+func (cfgContext *ConfigContext) RenderedTemplates() map[string]map[string]string {
+	cfgContext.renderedLock.Lock()
+	defer cfgContext.renderedLock.Unlock()
+	return cfgContext.rendered
+}
+
+// AddTemplateFailure records a template that couldn't be configured.
+func (cfgContext *ConfigContext) AddTemplateFailure(failure string) {
+	cfgContext.templateFailuresLock.Lock()
+	defer cfgContext.templateFailuresLock.Unlock()
+	cfgContext.templateFailures = append(cfgContext.templateFailures, failure)
+}
+
+// TemplateFailures returns the recorded template failures.
+func (cfgContext *ConfigContext) TemplateFailures() []string {
+	cfgContext.templateFailuresLock.Lock()
+	defer cfgContext.templateFailuresLock.Unlock()
+	return append([]string{}, cfgContext.templateFailures...)
 }
 
 func (cfgContext *ConfigContext) SetDiffFileCount(cnt int) {
@@ -75,6 +115,7 @@ type DriverConfig struct {
 	MemFs               trcshio.MemoryFileSystem
 	CertPathOverrides   map[string]string // certFileName -> certDest
 	OutputFileSystemDir string            // Optional: output to filesystem in addition to MemFs (KernelZ only)
+	OutputFormat        string            // Optional: package rendered templates instead of writing files (trcconfig -outputFormat)
 
 	// Config modes....
 	NoVault     bool // Working straight from seed files
@@ -85,6 +126,7 @@ type DriverConfig struct {
 	Trcxr       bool     // Used for TRCXR
 
 	Clean  bool
+	Strict bool // Fail templates referencing keys without values
 	Update func(*ConfigContext, *string, string)
 
 	// KeyStore Output tooling
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Seed diff statuses.  A value is env specific when it only differs by the
+// names of the environments, e.g. dev.example.com and QA.example.com.
+const (
+	SeedDiffIdentical   = "identical"
+	SeedDiffEnvSpecific = "env-specific"
+	SeedDiffDiffers     = "differs"
+	SeedDiffMissing     = "missing"
+)
+
+// Seed diff report formats.
+const (
+	SeedDiffFormatTable    = "table"
+	SeedDiffFormatJson     = "json"
+	SeedDiffFormatMarkdown = "markdown"
+)
+
+// SeedDiffFormats lists the supported report formats.
+var SeedDiffFormats = []string{SeedDiffFormatTable, SeedDiffFormatJson, SeedDiffFormatMarkdown}
+
+var seedDiffSections = []string{"values", "super-secrets"}
+
+// SeedDiffEntry compares a single seed key across environments.  Values are
+// never reported, environments sharing a value share a group letter instead.
+type SeedDiffEntry struct {
+	Section string            `json:"section"`
+	Path    string            `json:"path"`
+	Key     string            `json:"key"`
+	Status  string            `json:"status"`
+	Groups  map[string]string `json:"groups"` // env -> group, "-" when missing
+}
+
+// SeedDiffReport is the structural diff of the seeds of several environments.
+type SeedDiffReport struct {
+	Envs    []string        `json:"envs"`
+	Summary map[string]int  `json:"summary"`
+	Entries []SeedDiffEntry `json:"entries"` // Everything but identical keys.
+}
+
+// seedDiffEnvName drops the implied latest version from an env for display.
+func seedDiffEnvName(env string) string {
+	return strings.TrimSuffix(env, "_0")
+}
+
+// flattenSeed returns section -> path -> key -> value for the value and
+// secret sections of a generated seed.
+func flattenSeed(seed string) (map[string]map[string]map[string]string, error) {
+	// The templates section isn't valid yaml once quotes are stripped and
+	// only holds links anyway, start at the first compared section.
+	start := -1
+	for _, section := range seedDiffSections {
+		if i := strings.Index("\n"+seed, "\n"+section+":"); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	flattened := map[string]map[string]map[string]string{}
+	if start < 0 {
+		return flattened, nil
+	}
+	var rawSeed map[any]any
+	if err := yaml.Unmarshal([]byte(seed[start:]), &rawSeed); err != nil {
+		return nil, err
+	}
+	var walk func(section string, path []string, node map[any]any)
+	walk = func(section string, path []string, node map[any]any) {
+		for k, v := range node {
+			key := fmt.Sprint(k)
+			if child, ok := v.(map[any]any); ok {
+				walk(section, append(append([]string{}, path...), key), child)
+				continue
+			}
+			joinedPath := strings.Join(path, "/")
+			if flattened[section][joinedPath] == nil {
+				flattened[section][joinedPath] = map[string]string{}
+			}
+			if v == nil {
+				v = ""
+			}
+			flattened[section][joinedPath][key] = fmt.Sprint(v)
+		}
+	}
+	for _, section := range seedDiffSections {
+		flattened[section] = map[string]map[string]string{}
+		if node, ok := rawSeed[section].(map[any]any); ok {
+			walk(section, nil, node)
+		}
+	}
+	return flattened, nil
+}
+
+func seedValueHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return string(sum[:])
+}
+
+// envNeutralHash hashes value with the env's name replaced wherever it
+// appears as a whole word, so dev.example.com and qa.example.com hash the
+// same but device does not.
+func envNeutralHash(value string, env string) string {
+	envBasis := GetEnvBasis(env)
+	if len(envBasis) > 1 {
+		var neutral strings.Builder
+		for i := 0; i < len(value); {
+			end := i + len(envBasis)
+			if end <= len(value) && strings.EqualFold(value[i:end], envBasis) &&
+				(i == 0 || !isWordByte(value[i-1])) && (end == len(value) || !isWordByte(value[end])) {
+				neutral.WriteString("\x00env\x00")
+				i = end
+				continue
+			}
+			neutral.WriteByte(value[i])
+			i++
+		}
+		value = neutral.String()
+	}
+	return seedValueHash(value)
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b >= 0x80
+}
+
+// SemanticSeedDiff compares the seeds generated for envs key by key.  seeds
+// maps each env to its generated seed.
+func SemanticSeedDiff(envs []string, seeds map[string]*string) (*SeedDiffReport, error) {
+	if len(envs) < 2 {
+		return nil, errors.New("at least two environments are needed for a diff")
+	}
+	report := &SeedDiffReport{
+		Summary: map[string]int{SeedDiffIdentical: 0, SeedDiffEnvSpecific: 0, SeedDiffDiffers: 0, SeedDiffMissing: 0},
+		Entries: []SeedDiffEntry{},
+	}
+	flattenedSeeds := map[string]map[string]map[string]map[string]string{}
+	for _, env := range envs {
+		report.Envs = append(report.Envs, seedDiffEnvName(env))
+		seed, ok := seeds[env]
+		if !ok || seed == nil {
+			return nil, fmt.Errorf("no seed generated for %s", seedDiffEnvName(env))
+		}
+		flattened, err := flattenSeed(*seed)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse seed for %s: %w", seedDiffEnvName(env), err)
+		}
+		flattenedSeeds[env] = flattened
+	}
+
+	for _, section := range seedDiffSections {
+		keys := map[[2]string]bool{}
+		for _, flattened := range flattenedSeeds {
+			for path, values := range flattened[section] {
+				for key := range values {
+					keys[[2]string{path, key}] = true
+				}
+			}
+		}
+		sortedKeys := make([][2]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Slice(sortedKeys, func(i, j int) bool {
+			if sortedKeys[i][0] != sortedKeys[j][0] {
+				return sortedKeys[i][0] < sortedKeys[j][0]
+			}
+			return sortedKeys[i][1] < sortedKeys[j][1]
+		})
+
+		for _, key := range sortedKeys {
+			entry := SeedDiffEntry{Section: section, Path: key[0], Key: key[1], Groups: map[string]string{}}
+			groups := map[string]string{}
+			missing := false
+			neutralHashes := map[string]bool{}
+			for _, env := range envs {
+				value, ok := flattenedSeeds[env][section][key[0]][key[1]]
+				if !ok {
+					missing = true
+					entry.Groups[seedDiffEnvName(env)] = "-"
+					continue
+				}
+				hash := seedValueHash(value)
+				if _, ok := groups[hash]; !ok {
+					groups[hash] = string(rune('A' + len(groups)%26))
+				}
+				entry.Groups[seedDiffEnvName(env)] = groups[hash]
+				neutralHashes[envNeutralHash(value, env)] = true
+			}
+			switch {
+			case missing:
+				entry.Status = SeedDiffMissing
+			case len(groups) == 1:
+				entry.Status = SeedDiffIdentical
+			case len(neutralHashes) == 1:
+				entry.Status = SeedDiffEnvSpecific
+			default:
+				entry.Status = SeedDiffDiffers
+			}
+			report.Summary[entry.Status]++
+			if entry.Status != SeedDiffIdentical {
+				report.Entries = append(report.Entries, entry)
+			}
+		}
+	}
+	return report, nil
+}
+
+// Write writes the report as a table, json or markdown.
+func (report *SeedDiffReport) Write(w io.Writer, format string) error {
+	switch format {
+	case SeedDiffFormatJson:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case SeedDiffFormatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "STATUS\tSECTION\tPATH\tKEY\t%s\n", strings.Join(report.Envs, "\t"))
+		for _, entry := range report.Entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.Status, entry.Section, entry.Path, entry.Key, strings.Join(report.groups(entry), "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\n%s\n", report.summaryLine())
+		return err
+	case SeedDiffFormatMarkdown:
+		fmt.Fprintf(w, "## Seed diff: %s\n\n%s\n\n", strings.Join(report.Envs, " vs "), report.summaryLine())
+		if len(report.Entries) == 0 {
+			_, err := fmt.Fprintln(w, "No drift found.")
+			return err
+		}
+		fmt.Fprintf(w, "| Status | Section | Path | Key | %s |\n", strings.Join(report.Envs, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", 4+len(report.Envs)))
+		for _, entry := range report.Entries {
+			fmt.Fprintf(w, "| %s | %s | `%s` | `%s` | %s |\n", entry.Status, entry.Section, entry.Path, entry.Key, strings.Join(report.groups(entry), " | "))
+		}
+		_, err := fmt.Fprintln(w, "\nEnvironments sharing a letter have the same value, - marks a missing key.")
+		return err
+	}
+	return fmt.Errorf("unsupported diff report format %q, expected one of %s", format, strings.Join(SeedDiffFormats, ", "))
+}
+
+func (report *SeedDiffReport) groups(entry SeedDiffEntry) []string {
+	groups := make([]string, 0, len(report.Envs))
+	for _, env := range report.Envs {
+		groups = append(groups, entry.Groups[env])
+	}
+	return groups
+}
+
+func (report *SeedDiffReport) summaryLine() string {
+	return fmt.Sprintf("%d identical, %d env-specific, %d differ, %d missing",
+		report.Summary[SeedDiffIdentical], report.Summary[SeedDiffEnvSpecific], report.Summary[SeedDiffDiffers], report.Summary[SeedDiffMissing])
+}
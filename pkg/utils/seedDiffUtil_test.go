@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSemanticSeedDiff(t *testing.T) {
+	dev := `templates:
+  Project/Service/config.yml:
+    host: [values/Project/Service, host]
+values:
+  Project/Service/config.yml:
+    host: dev.example.com
+    port: "8080"
+    timeout: "30"
+super-secrets:
+  Project/Service/config.yml:
+    password: hunter2
+`
+	qa := `values:
+  Project/Service/config.yml:
+    host: qa.example.com
+    port: "8080"
+    timeout: "60"
+super-secrets:
+  Project/Service/config.yml:
+    password: swordfish
+    apikey: qakey
+`
+	report, err := SemanticSeedDiff([]string{"dev_0", "QA_0"}, map[string]*string{"dev_0": &dev, "QA_0": &qa})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, entry := range report.Entries {
+		statuses[entry.Key] = entry.Status
+	}
+	expected := map[string]string{
+		"host":     SeedDiffEnvSpecific,
+		"timeout":  SeedDiffDiffers,
+		"password": SeedDiffDiffers,
+		"apikey":   SeedDiffMissing,
+	}
+	for key, status := range expected {
+		if statuses[key] != status {
+			t.Errorf("Expected %s to be %s, got %s", key, status, statuses[key])
+		}
+	}
+	if report.Summary[SeedDiffIdentical] != 1 {
+		t.Errorf("Expected port to be identical, got summary %v", report.Summary)
+	}
+
+	var out bytes.Buffer
+	if err := report.Write(&out, SeedDiffFormatMarkdown); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "swordfish", "qakey"} {
+		if strings.Contains(out.String(), secret) {
+			t.Fatalf("Report must not contain values:\n%s", out.String())
+		}
+	}
+}
+
+func TestEnvNeutralHash(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"dev.example.com", "qa.example.com"},
+		{"https://DEV-api/v1", "https://QA-api/v1"},
+		{"dev", "qa"},
+	} {
+		if envNeutralHash(pair[0], "dev_0") != envNeutralHash(pair[1], "QA") {
+			t.Errorf("Expected %s and %s to hash the same", pair[0], pair[1])
+		}
+	}
+	for _, pair := range [][2]string{
+		{"device", "qaice"},
+		{"mydev.example.com", "myqa.example.com"},
+		{"dev1", "qa1"},
+	} {
+		if envNeutralHash(pair[0], "dev") == envNeutralHash(pair[1], "QA") {
+			t.Errorf("Expected %s and %s to differ", pair[0], pair[1])
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package audit records every change the toolchain makes to vault as a
+// structured JSON event.  Events name the tool, the identity behind the token,
+// the path, and the keys touched -- never the values -- so that changes can be
+// reconstructed without enabling vault's own audit device on shared clusters.
+//
+// Sinks are configured through TRC_AUDIT_SINK as a comma separated list:
+//
+//	file:/var/log/tierceron/audit.log
+//	syslog[:tag]
+//
+// The hive kernel additionally routes events over chat to the plugin named
+// in TRC_AUDIT_CHAT.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// SinkEnv holds the sink configuration.
+const SinkEnv = "TRC_AUDIT_SINK"
+
+// ChatTargetEnv names the plugin the hive kernel routes audit events to over chat.
+const ChatTargetEnv = "TRC_AUDIT_CHAT"
+
+// Actions recorded in events.
+const (
+	ActionWrite        = "write"
+	ActionAdjust       = "adjust"
+	ActionSoftDelete   = "soft-delete"
+	ActionHardDelete   = "hard-delete"
+	ActionTokenCreate  = "token-create"
+	ActionPolicyCreate = "policy-create"
+)
+
+// Results recorded in events.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Actor identifies who made a change.
+type Actor struct {
+	Accessor    string   `json:"accessor,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Role        string   `json:"role,omitempty"`
+	Policies    []string `json:"policies,omitempty"`
+}
+
+// Event is a single audited change.
+type Event struct {
+	Time   string   `json:"time"`
+	Tool   string   `json:"tool"`
+	Host   string   `json:"host,omitempty"`
+	Actor  *Actor   `json:"actor,omitempty"`
+	Action string   `json:"action"`
+	Env    string   `json:"env,omitempty"`
+	Path   string   `json:"path"`
+	Keys   []string `json:"keys,omitempty"`
+	Result string   `json:"result"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// Sink receives audit events.  Emit must not block for long as it is called
+// inline with vault writes.
+type Sink interface {
+	Emit(event *Event, line []byte) error
+	Close() error
+}
+
+var (
+	sinks     []Sink
+	sinksOnce sync.Once
+	sinksLock sync.RWMutex
+	tool      = filepath.Base(os.Args[0])
+	toolLock  sync.RWMutex
+	hostName  string
+)
+
+// SetTool names the tool reported in subsequent events (trcinit, trcpub, trctv, flume, ...).
+func SetTool(name string) {
+	toolLock.Lock()
+	tool = name
+	toolLock.Unlock()
+}
+
+func getTool() string {
+	toolLock.RLock()
+	defer toolLock.RUnlock()
+	return tool
+}
+
+// initSinks loads the sinks named in SinkEnv.  Misconfigured sinks are
+// reported on stderr rather than failing the tool.
+func initSinks() {
+	sinksOnce.Do(func() {
+		hostName, _ = os.Hostname()
+		spec := os.Getenv(SinkEnv)
+		if spec == "" {
+			return
+		}
+		for _, sinkSpec := range strings.Split(spec, ",") {
+			sink, err := newSink(strings.TrimSpace(sinkSpec))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to configure audit sink %s: %v\n", sinkSpec, err)
+				continue
+			}
+			sinksLock.Lock()
+			sinks = append(sinks, sink)
+			sinksLock.Unlock()
+		}
+	})
+}
+
+func newSink(spec string) (Sink, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "file":
+		return NewFileSink(arg)
+	case "syslog":
+		return NewSyslogSink(arg)
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", kind)
+	}
+}
+
+// AddSink registers an additional sink.
+func AddSink(sink Sink) {
+	initSinks()
+	sinksLock.Lock()
+	sinks = append(sinks, sink)
+	sinksLock.Unlock()
+}
+
+// Enabled returns true if at least one sink is registered.  Callers use it to
+// skip the work of identifying the actor when nobody is listening.
+func Enabled() bool {
+	initSinks()
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	return len(sinks) > 0
+}
+
+// Close flushes and releases all sinks.
+func Close() {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	for _, sink := range sinks {
+		sink.Close()
+	}
+	sinks = nil
+}
+
+// Keys returns the sorted keys of data.  Values are never audited.
+func Keys(data map[string]any) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ActorFromLookup builds the actor from a token lookup-self response.
+func ActorFromLookup(secret *api.Secret) *Actor {
+	if secret == nil || secret.Data == nil {
+		return nil
+	}
+	actor := &Actor{}
+	if accessor, ok := secret.Data["accessor"].(string); ok {
+		actor.Accessor = accessor
+	}
+	if displayName, ok := secret.Data["display_name"].(string); ok {
+		actor.DisplayName = displayName
+	}
+	if meta, ok := secret.Data["meta"].(map[string]any); ok {
+		if role, ok := meta["role_name"].(string); ok {
+			actor.Role = role
+		} else if role, ok := meta["role"].(string); ok {
+			actor.Role = role
+		}
+	}
+	if policies, ok := secret.Data["policies"].([]any); ok {
+		for _, policy := range policies {
+			actor.Policies = append(actor.Policies, fmt.Sprint(policy))
+		}
+	}
+	return actor
+}
+
+// Record emits an event for the outcome of a change to every sink.
+func Record(actor *Actor, action string, env string, path string, keys []string, err error) {
+	if !Enabled() {
+		return
+	}
+	event := &Event{
+		Time:   time.Now().UTC().Format(time.RFC3339Nano),
+		Tool:   getTool(),
+		Host:   hostName,
+		Actor:  actor,
+		Action: action,
+		Env:    env,
+		Path:   path,
+		Keys:   keys,
+		Result: ResultSuccess,
+	}
+	if err != nil {
+		event.Result = ResultFailure
+		event.Error = err.Error()
+	}
+	line, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		fmt.Fprintf(os.Stderr, "Unable to encode audit event: %v\n", marshalErr)
+		return
+	}
+
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	for _, sink := range sinks {
+		if emitErr := sink.Emit(event, line); emitErr != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write audit event: %v\n", emitErr)
+		}
+	}
+}
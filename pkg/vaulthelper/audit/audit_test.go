@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSinkRecordsKeysNotValues(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(logPath)
+	if err != nil {
+		t.Fatalf("Expected file sink, got %v", err)
+	}
+	AddSink(sink)
+	defer Close()
+	SetTool("trctv")
+
+	data := map[string]any{"password": "hunter2", "user": "admin"}
+	Record(&Actor{Accessor: "abc"}, ActionWrite, "dev", "super-secrets/Restricted/Foo", Keys(data), nil)
+	Record(nil, ActionHardDelete, "dev", "super-secrets/Restricted/Foo", nil, errors.New("permission denied"))
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Unable to read audit log: %v", err)
+	}
+	if strings.Contains(string(content), "hunter2") {
+		t.Fatalf("Audit log leaked a value: %s", content)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(lines))
+	}
+
+	event := Event{}
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf("Malformed event: %v", err)
+	}
+	if event.Tool != "trctv" || event.Result != ResultSuccess || strings.Join(event.Keys, ",") != "password,user" || event.Actor.Accessor != "abc" {
+		t.Fatalf("Unexpected event: %+v", event)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatalf("Malformed event: %v", err)
+	}
+	if event.Result != ResultFailure || event.Error != "permission denied" {
+		t.Fatalf("Unexpected failure event: %+v", event)
+	}
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	tccore "github.com/trimble-oss/tierceron-core/v2/core"
+)
+
+// FileSink appends one JSON event per line to a file.
+type FileSink struct {
+	file *os.File
+	lock sync.Mutex
+}
+
+// NewFileSink opens (or creates) path for appending.
+func NewFileSink(path string) (*FileSink, error) {
+	if path == "" {
+		return nil, errors.New("file sink requires a path")
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (f *FileSink) Emit(event *Event, line []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	_, err := f.file.Write(append(line, '\n'))
+	return err
+}
+
+func (f *FileSink) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.file.Close()
+}
+
+// ChatSink forwards events through the kernel chat to a plugin.  Delivery is
+// best effort: events are dropped rather than stalling a vault write.
+type ChatSink struct {
+	chatChan *chan *tccore.ChatMsg
+	name     string
+	target   string
+}
+
+// NewChatSink sends events as name to the target plugin over chatChan.
+func NewChatSink(chatChan *chan *tccore.ChatMsg, name string, target string) *ChatSink {
+	return &ChatSink{chatChan: chatChan, name: name, target: target}
+}
+
+func (c *ChatSink) Emit(event *Event, line []byte) error {
+	if c.chatChan == nil || *c.chatChan == nil {
+		return errors.New("chat sink has no channel")
+	}
+	response := string(line)
+	chatId := "AUDIT"
+	msg := &tccore.ChatMsg{
+		Name:     &c.name,
+		ChatId:   &chatId,
+		Query:    &[]string{c.target},
+		Response: &response,
+	}
+	go func(chatChan chan *tccore.ChatMsg) {
+		select {
+		case chatChan <- msg:
+		case <-time.After(5 * time.Second):
+		}
+	}(*c.chatChan)
+	return nil
+}
+
+func (c *ChatSink) Close() error {
+	return nil
+}
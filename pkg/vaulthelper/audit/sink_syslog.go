@@ -0,0 +1,35 @@
+//go:build !windows
+
+package audit
+
+import (
+	"log/syslog"
+)
+
+// SyslogSink writes events to the local syslog daemon.
+type SyslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink connects to syslog under tag (default tierceron-audit).
+func NewSyslogSink(tag string) (Sink, error) {
+	if tag == "" {
+		tag = "tierceron-audit"
+	}
+	writer, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{writer: writer}, nil
+}
+
+func (s *SyslogSink) Emit(event *Event, line []byte) error {
+	if event.Result == ResultFailure {
+		return s.writer.Warning(string(line))
+	}
+	return s.writer.Notice(string(line))
+}
+
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
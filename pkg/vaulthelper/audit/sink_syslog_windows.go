@@ -0,0 +1,12 @@
+//go:build windows
+
+package audit
+
+import (
+	"errors"
+)
+
+// NewSyslogSink is not supported on windows.
+func NewSyslogSink(tag string) (Sink, error) {
+	return nil, errors.New("syslog audit sink is not supported on windows")
+}
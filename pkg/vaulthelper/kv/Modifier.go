@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -20,6 +19,7 @@ import (
 	"github.com/trimble-oss/tierceron-core/v2/buildopts/memprotectopts"
 	"github.com/trimble-oss/tierceron-core/v2/core/coreconfig"
 	"github.com/trimble-oss/tierceron/buildopts"
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
 
 	"github.com/hashicorp/vault/api"
 )
@@ -39,9 +39,8 @@ var noEnvironments = map[string]bool{
 type Modifier struct {
 	Insecure         bool         // Indicates if connections to vault should be secure
 	Direct           bool         // Bypass vault and utilize alternative source when possible.
-	httpClient       *http.Client // Handle to http client.
-	client           *api.Client  // Client connected to vault
-	logical          *api.Logical // Logical used for read/write options
+	backend          Backend      // Storage engine: vault or local file.
+	auditActor       *audit.Actor // Token identity cached for audit events.
 	SecretDictionary *api.Secret  // Current Secret Dictionary Cache -- populated by mod.List("templates"
 
 	Env             string // Environment (local/dev/QA; Initialized to secrets)
@@ -137,12 +136,22 @@ func NewModifier(insecure bool, tokenPtr *string, addressPtr *string, env string
 			checkoutModifier.SubSectionValue = ""       // The actual value for the sub section.
 			checkoutModifier.SectionPath = ""           // The path to the Index (both seed and vault)
 			if tokenPtr != nil {
-				checkoutModifier.client.SetToken(*tokenPtr)
+				checkoutModifier.backend.SetToken(*tokenPtr)
+				checkoutModifier.auditActor = nil
 			}
 			return checkoutModifier, nil
 		}
 	}
 
+	if IsFileBackendAddress(*addressPtr) {
+		// Local encrypted file store, no token required.
+		backend, err := openFileBackend(*addressPtr)
+		if err != nil {
+			return nil, err
+		}
+		return NewModifierWithBackend(backend, env, regions), nil
+	}
+
 	httpClient, err := CreateHTTPClient(insecure, *addressPtr, env, false)
 	if err != nil {
 		return nil, err
@@ -169,10 +178,20 @@ func NewModifier(insecure bool, tokenPtr *string, addressPtr *string, env string
 	}
 
 	// Return the modifier
-	newModifier := &Modifier{httpClient: httpClient, client: modClient, logical: modClient.Logical(), Env: "secret", EnvBasis: env, Regions: regions, Version: "", Insecure: insecure}
+	newModifier := &Modifier{backend: newVaultBackend(httpClient, modClient), Env: "secret", EnvBasis: env, Regions: regions, Version: "", Insecure: insecure}
 	return newModifier, nil
 }
 
+// NewModifierWithBackend Constructs a new modifier struct on top of the provided backend.
+// Useful for offline tooling and tests that shouldn't require a vault.
+// @param backend	The storage engine behind the modifier
+// @param env   	The environment currently connecting to.
+// @param regions   Regions we want
+// @return 			A pointer to the newly contstructed modifier object (Note: path set to default)
+func NewModifierWithBackend(backend Backend, env string, regions []string) *Modifier {
+	return &Modifier{backend: backend, Env: "secret", EnvBasis: env, Regions: regions, Version: ""}
+}
+
 func checkInitModCache(env string, addr string) {
 	if _, ok := modifierCache[fmt.Sprintf("%s+%s", env, addr)]; !ok {
 		modifierCachLock.Lock()
@@ -199,10 +218,11 @@ func cachedModifierHelper(env string, addr string) (*Modifier, error) {
 // Release - releases the modifier back to the cache.
 func (m *Modifier) Release() {
 	if m.Stale {
-		m.httpClient.CloseIdleConnections()
+		m.backend.CloseIdleConnections()
 		return
 	}
-	m.client.SetToken("")
+	m.backend.SetToken("")
+	m.auditActor = nil
 	if _, ok := modifierCache[m.Env]; ok {
 		m.releaseHelper(m.Env)
 	} else {
@@ -211,11 +231,11 @@ func (m *Modifier) Release() {
 }
 
 func (m *Modifier) releaseHelper(env string) {
-	checkInitModCache(env, m.client.Address())
+	checkInitModCache(env, m.backend.Address())
 
 	// Since modifiers are re-used now, this may not be necessary or even desired for that
 	// matter.
-	key := fmt.Sprintf("%s+%s", env, m.client.Address())
+	key := fmt.Sprintf("%s+%s", env, m.backend.Address())
 	modifierCachLock.Lock()
 	modifierCache[key].modCount++
 	if modifierCache[key].modCount > 10 {
@@ -292,9 +312,9 @@ func (m *Modifier) Reset() {
 func (m *Modifier) CleanCache(limit uint64) {
 	m.Close()
 	if _, ok := modifierCache[m.Env]; ok {
-		cleanCacheHelper(m.Env, m.client.Address(), limit)
+		cleanCacheHelper(m.Env, m.backend.Address(), limit)
 	} else {
-		cleanCacheHelper(m.EnvBasis, m.client.Address(), limit)
+		cleanCacheHelper(m.EnvBasis, m.backend.Address(), limit)
 	}
 }
 
@@ -320,7 +340,7 @@ func (m *Modifier) ValidateEnvironment(environment string, init bool, policySuff
 		desiredPolicy = "vault_pub_" + strings.ToLower(environment)
 	}
 
-	secret, err := m.client.Auth().Token().LookupSelf()
+	secret, err := m.backend.LookupSelf()
 	if err != nil {
 		logger.Printf("LookupSelf Auth failure: %v\n", err)
 		if urlErr, urlErrOk := err.(*url.Error); urlErrOk {
@@ -357,10 +377,30 @@ func (m *Modifier) ValidateEnvironment(environment string, init bool, policySuff
 // ValidateToken - validates the current token using LookupSelf
 // @return error if token is invalid or expired, nil if valid
 func (m *Modifier) ValidateToken() error {
-	_, err := m.client.Auth().Token().LookupSelf()
+	_, err := m.backend.LookupSelf()
 	return err
 }
 
+// Actor identifies who is behind the modifier's token.  The token is looked up
+// once per checkout.  Returns nil if the lookup fails.
+func (m *Modifier) Actor() *audit.Actor {
+	if m.auditActor == nil {
+		if secret, lookupErr := m.backend.LookupSelf(); lookupErr == nil {
+			m.auditActor = audit.ActorFromLookup(secret)
+		}
+	}
+	return m.auditActor
+}
+
+// recordAudit records the outcome of a change, identifying who made it only
+// when audit sinks are configured.
+func (m *Modifier) recordAudit(action string, path string, keys []string, err error) {
+	if !audit.Enabled() {
+		return
+	}
+	audit.Record(m.Actor(), action, m.Env, path, keys, err)
+}
+
 // Write - writes the key,value pairs in data to the vault
 //
 // @param   data A set of key,value pairs to be written
@@ -369,6 +409,12 @@ func (m *Modifier) ValidateToken() error {
 //
 //	errors generated by writing
 func (m *Modifier) Write(path string, data map[string]any, logger *log.Logger) ([]string, error) {
+	warnings, err := m.write(path, data, logger)
+	m.recordAudit(audit.ActionWrite, path, audit.Keys(data), err)
+	return warnings, err
+}
+
+func (m *Modifier) write(path string, data map[string]any, logger *log.Logger) ([]string, error) {
 	// Wrap data and send
 	sendData := map[string]any{"data": data}
 
@@ -399,7 +445,7 @@ func (m *Modifier) Write(path string, data map[string]any, logger *log.Logger) (
 	}
 	retries := 0
 retryQuery:
-	Secret, err := m.logical.Write(fullPath, sendData)
+	Secret, err := m.backend.Write(fullPath, sendData)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -428,16 +474,16 @@ func (m *Modifier) getVersionSpecificRead(path string, fullPath string, versionM
 			m.Version = strings.Split(m.Version, "***")[0]
 			versionSlice := []string{m.Version}
 			versionMap["version"] = versionSlice
-			return m.logical.ReadWithData(fullPath, versionMap)
+			return m.backend.ReadWithData(fullPath, versionMap)
 		}
 	} else if m.Version != "" && !strings.HasPrefix(path, "templates") { // config path
 		versionSlice := []string{m.Version}
 		versionMap["version"] = versionSlice
-		return m.logical.ReadWithData(fullPath, versionMap)
+		return m.backend.ReadWithData(fullPath, versionMap)
 	} else {
-		return m.logical.Read(fullPath)
+		return m.backend.Read(fullPath)
 	}
-	return m.logical.Read(fullPath)
+	return m.backend.Read(fullPath)
 }
 
 // ReadData Reads the most recent data from the path referenced by this Modifier
@@ -526,7 +572,7 @@ retryVaultAccess:
 			if timeoutRetry >= maxTimeoutRetries {
 				// Max retries exceeded, mark as stale and return error
 				m.Stale = true
-				m.httpClient.CloseIdleConnections()
+				m.backend.CloseIdleConnections()
 				err = fmt.Errorf("vault operation timed out after %d retries: %s", maxTimeoutRetries, fullPath)
 				goto processResult
 			}
@@ -686,7 +732,7 @@ func (m *Modifier) ReadMetadata(path string, logger *log.Logger) (map[string]any
 	fullPath += pathBlocks[1]
 	retries := 0
 retryQuery:
-	secret, err := m.logical.Read(fullPath)
+	secret, err := m.backend.Read(fullPath)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -725,7 +771,7 @@ func (m *Modifier) ReadVersionMetadata(path string, logger *log.Logger) (map[str
 	}
 	retries := 0
 retryQuery:
-	secret, err := m.logical.Read(fullPath)
+	secret, err := m.backend.Read(fullPath)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -771,7 +817,7 @@ func (m *Modifier) List(path string, logger *log.Logger) (*api.Secret, error) {
 	}
 	retries := 0
 retryQuery:
-	result, err := m.logical.List(fullPath)
+	result, err := m.backend.List(fullPath)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -803,7 +849,7 @@ func (m *Modifier) ListEnv(path string, logger *log.Logger) (*api.Secret, error)
 	}
 	retries := 0
 retryQuery:
-	result, err := m.logical.List(fullPath)
+	result, err := m.backend.List(fullPath)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -832,9 +878,11 @@ func (m *Modifier) AdjustValue(path string, data map[string]any, n int, logger *
 	if oldData == nil { // Path has not been used yet, create an empty map
 		oldData = make(map[string]any)
 	}
+	adjustedKeys := []string{}
 	for _, v := range data {
 		if templateKey, ok := v.([]any); ok {
 			metricsKey := templateKey[0].(string) + "." + templateKey[1].(string)
+			adjustedKeys = append(adjustedKeys, metricsKey)
 			// Try to fetch the value with the given key, start empty values with 0
 			if oldData[metricsKey] == nil {
 				oldData[metricsKey] = "0"
@@ -849,16 +897,18 @@ func (m *Modifier) AdjustValue(path string, data map[string]any, n int, logger *
 			oldData[metricsKey] = newValue
 		}
 	}
-	return m.Write(path, oldData, logger)
+	warnings, err := m.write(path, oldData, logger)
+	m.recordAudit(audit.ActionAdjust, path, adjustedKeys, err)
+	return warnings, err
 }
 
 // Close - proper shutdown of modifier.
 func (m *Modifier) Close() {
-	m.httpClient.CloseIdleConnections()
+	m.backend.CloseIdleConnections()
 }
 
 func (m *Modifier) Exists(path string) bool {
-	secret, err := m.logical.List(path)
+	secret, err := m.backend.List(path)
 	if err != nil {
 		return false
 	}
@@ -1194,6 +1244,12 @@ indexFound:
 }
 
 func (m *Modifier) SoftDelete(path string, logger *log.Logger) (map[string]any, error) {
+	result, err := m.softDelete(path, logger)
+	m.recordAudit(audit.ActionSoftDelete, path, nil, err)
+	return result, err
+}
+
+func (m *Modifier) softDelete(path string, logger *log.Logger) (map[string]any, error) {
 	if !strings.HasPrefix(path, "super-secrets") && !strings.HasPrefix(path, "values") {
 		path = "super-secrets/" + path
 	}
@@ -1206,7 +1262,7 @@ func (m *Modifier) SoftDelete(path string, logger *log.Logger) (map[string]any,
 	fullDataPath += pathBlocks[1]
 	retries := 0
 retryQuery:
-	secret, err := m.logical.Delete(fullDataPath)
+	secret, err := m.backend.Delete(fullDataPath)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -1229,6 +1285,12 @@ retryQuery:
 }
 
 func (m *Modifier) HardDelete(path string, logger *log.Logger) (map[string]any, error) {
+	result, err := m.hardDelete(path, logger)
+	m.recordAudit(audit.ActionHardDelete, path, nil, err)
+	return result, err
+}
+
+func (m *Modifier) hardDelete(path string, logger *log.Logger) (map[string]any, error) {
 	if !strings.HasPrefix(path, "super-secrets") && !strings.HasPrefix(path, "values") {
 		path = "super-secrets/" + path
 	}
@@ -1245,7 +1307,7 @@ func (m *Modifier) HardDelete(path string, logger *log.Logger) (map[string]any,
 	fullMetadataPath += pathBlocks[1]
 	retries := 0
 retryQuery:
-	secret, err := m.logical.Delete(fullDataPath)
+	secret, err := m.backend.Delete(fullDataPath)
 	if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 		if retries < 3 {
 			retries = retries + 1
@@ -1262,7 +1324,7 @@ retryQuery:
 	}
 
 	if secret == nil && err == nil {
-		metadataSecret, err := m.logical.Delete(fullMetadataPath)
+		metadataSecret, err := m.backend.Delete(fullMetadataPath)
 		if netErr, netErrOk := err.(*url.Error); netErrOk && netErr.Unwrap().Error() == "EOF" {
 			if retries < 3 {
 				retries = retries + 1
@@ -0,0 +1,104 @@
+package kv
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Backend is the storage engine behind a Modifier.  Paths are full KV v2
+// paths as built by the Modifier (<mount>/data/<env>/..., <mount>/metadata/<env>/...)
+// and responses are shaped like the vault KV v2 api responses.
+type Backend interface {
+	Read(path string) (*api.Secret, error)
+	ReadWithData(path string, data map[string][]string) (*api.Secret, error)
+	Write(path string, data map[string]any) (*api.Secret, error)
+	List(path string) (*api.Secret, error)
+	Delete(path string) (*api.Secret, error)
+
+	// Address identifies the backend; it keys the modifier cache.
+	Address() string
+	SetToken(token string)
+	LookupSelf() (*api.Secret, error)
+	CloseIdleConnections()
+}
+
+// SecretStore is the public surface of a Modifier.  Code that only needs to
+// read and write secrets should depend on this rather than on *Modifier.
+type SecretStore interface {
+	Write(path string, data map[string]any, logger *log.Logger) ([]string, error)
+	ReadData(path string) (map[string]any, error)
+	ReadValue(path string, key string) (string, error)
+	ReadMapValue(valueMap map[string]any, path string, key string) (string, error)
+	ReadMetadata(path string, logger *log.Logger) (map[string]any, error)
+	ReadVersionMetadata(path string, logger *log.Logger) (map[string]any, error)
+	List(path string, logger *log.Logger) (*api.Secret, error)
+	ListEnv(path string, logger *log.Logger) (*api.Secret, error)
+	AdjustValue(path string, data map[string]any, n int, logger *log.Logger) ([]string, error)
+	Exists(path string) bool
+	SoftDelete(path string, logger *log.Logger) (map[string]any, error)
+	HardDelete(path string, logger *log.Logger) (map[string]any, error)
+	ValidateEnvironment(environment string, init bool, policySuffix string, logger *log.Logger) (bool, string, error)
+	ValidateToken() error
+	Release()
+	Close()
+}
+
+var _ SecretStore = (*Modifier)(nil)
+
+// fileBackendScheme selects the local encrypted file backend when used as the vault address.
+const fileBackendScheme = "file://"
+
+// IsFileBackendAddress returns true if address refers to a local file backend rather than vault.
+func IsFileBackendAddress(address string) bool {
+	return strings.HasPrefix(address, fileBackendScheme)
+}
+
+// vaultBackend talks to a Hashicorp vault KV v2 engine.
+type vaultBackend struct {
+	httpClient *http.Client
+	client     *api.Client
+	logical    *api.Logical
+}
+
+func newVaultBackend(httpClient *http.Client, client *api.Client) *vaultBackend {
+	return &vaultBackend{httpClient: httpClient, client: client, logical: client.Logical()}
+}
+
+func (v *vaultBackend) Read(path string) (*api.Secret, error) {
+	return v.logical.Read(path)
+}
+
+func (v *vaultBackend) ReadWithData(path string, data map[string][]string) (*api.Secret, error) {
+	return v.logical.ReadWithData(path, data)
+}
+
+func (v *vaultBackend) Write(path string, data map[string]any) (*api.Secret, error) {
+	return v.logical.Write(path, data)
+}
+
+func (v *vaultBackend) List(path string) (*api.Secret, error) {
+	return v.logical.List(path)
+}
+
+func (v *vaultBackend) Delete(path string) (*api.Secret, error) {
+	return v.logical.Delete(path)
+}
+
+func (v *vaultBackend) Address() string {
+	return v.client.Address()
+}
+
+func (v *vaultBackend) SetToken(token string) {
+	v.client.SetToken(token)
+}
+
+func (v *vaultBackend) LookupSelf() (*api.Secret, error) {
+	return v.client.Auth().Token().LookupSelf()
+}
+
+func (v *vaultBackend) CloseIdleConnections() {
+	v.httpClient.CloseIdleConnections()
+}
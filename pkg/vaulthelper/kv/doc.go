@@ -6,6 +6,7 @@
 //   - Environment-specific configuration handling
 //   - Template processing and path validation
 //   - HTTP client generation with TLS support
+//   - Pluggable storage backends: vault, or a local encrypted file (file://<path>) for offline use
 //
 // The package handles both KV v1 and KV v2 secrets engines and provides helper functions
 // for common Vault operations such as reading secrets, checking paths, and managing
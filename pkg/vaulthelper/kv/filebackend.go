@@ -0,0 +1,394 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/trimble-oss/tierceron/pkg/trcseal"
+)
+
+// FileBackendKeyEnv names a PEM encoded X25519 private key for the file backend.
+// When unset the key is read from (or created at) <store>.key.
+const FileBackendKeyEnv = "TRC_FILE_BACKEND_KEY"
+
+const fileBackendMagic = "trc-kv-file-v1\n"
+
+type fileSecretVersion struct {
+	Data         json.RawMessage `json:"data,omitempty"`
+	CreatedTime  string          `json:"createdTime"`
+	DeletionTime string          `json:"deletionTime,omitempty"`
+	Destroyed    bool            `json:"destroyed,omitempty"`
+}
+
+type fileSecret struct {
+	CurrentVersion int                        `json:"currentVersion"`
+	CreatedTime    string                     `json:"createdTime"`
+	UpdatedTime    string                     `json:"updatedTime"`
+	Versions       map[int]*fileSecretVersion `json:"versions"`
+}
+
+// fileBackend is an offline KV v2 emulation kept in a single file encrypted
+// to an X25519 key.  It lets the tools run without a vault server, e.g. for
+// local development and unit tests.
+type fileBackend struct {
+	storePath string // Empty for an in memory store.
+	key       *trcseal.Key
+	modTime   time.Time
+	secrets   map[string]*fileSecret
+	lock      sync.Mutex
+}
+
+var (
+	fileBackends     = map[string]*fileBackend{}
+	fileBackendsLock sync.Mutex
+)
+
+// NewMemoryBackend returns an empty backend that is never persisted.
+func NewMemoryBackend() Backend {
+	return &fileBackend{secrets: map[string]*fileSecret{}}
+}
+
+// openFileBackend returns the shared backend for a file:// address, creating the
+// store and its key on first use.
+func openFileBackend(address string) (*fileBackend, error) {
+	storePath := filepath.Clean(strings.TrimPrefix(address, fileBackendScheme))
+
+	fileBackendsLock.Lock()
+	defer fileBackendsLock.Unlock()
+	if backend, ok := fileBackends[storePath]; ok {
+		return backend, nil
+	}
+
+	keyPath := os.Getenv(FileBackendKeyEnv)
+	if keyPath == "" {
+		keyPath = storePath + ".key"
+	}
+	key := &trcseal.Key{}
+	keyPem, err := os.ReadFile(keyPath)
+	switch {
+	case err == nil:
+		key.PrivateKey, err = trcseal.ParsePrivateKey(keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file backend key %s: %w", keyPath, err)
+		}
+	case os.IsNotExist(err):
+		if _, statErr := os.Stat(storePath); statErr == nil {
+			return nil, fmt.Errorf("missing key %s for existing file backend %s", keyPath, storePath)
+		}
+		key.PrivateKey, keyPem, err = trcseal.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(keyPath, keyPem, 0o600); err != nil {
+			return nil, fmt.Errorf("unable to write file backend key: %w", err)
+		}
+	default:
+		return nil, err
+	}
+
+	backend := &fileBackend{storePath: storePath, key: key, secrets: map[string]*fileSecret{}}
+	if err := backend.reload(); err != nil {
+		return nil, err
+	}
+	fileBackends[storePath] = backend
+	return backend, nil
+}
+
+// reload reads the store from disk if another process has changed it.
+func (f *fileBackend) reload() error {
+	if f.storePath == "" {
+		return nil
+	}
+	info, err := os.Stat(f.storePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	sealed, err := os.ReadFile(f.storePath)
+	if err != nil {
+		return err
+	}
+	plain, err := trcseal.Open(fileBackendMagic, sealed, f.key)
+	if err != nil {
+		return fmt.Errorf("unable to open file backend %s: %w", f.storePath, err)
+	}
+	secrets := map[string]*fileSecret{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("corrupt file backend %s: %w", f.storePath, err)
+	}
+	f.secrets = secrets
+	f.modTime = info.ModTime()
+	return nil
+}
+
+// save atomically replaces the store on disk.
+func (f *fileBackend) save() error {
+	if f.storePath == "" {
+		return nil
+	}
+	plain, err := json.Marshal(f.secrets)
+	if err != nil {
+		return err
+	}
+	sealed, err := trcseal.Seal(fileBackendMagic, plain, f.key)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(f.storePath), filepath.Base(f.storePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(sealed); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), f.storePath); err != nil {
+		return err
+	}
+	if info, err := os.Stat(f.storePath); err == nil {
+		f.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// splitBackendPath splits <mount>/<data|metadata>/<rest> into the kind and the
+// storage key <mount>/<rest>.
+func splitBackendPath(path string) (string, string, error) {
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", 3)
+	if len(parts) < 2 || (parts[1] != "data" && parts[1] != "metadata") {
+		return "", "", fmt.Errorf("unsupported path for file backend: %s", path)
+	}
+	if len(parts) == 2 {
+		return parts[1], parts[0], nil
+	}
+	return parts[1], parts[0] + "/" + parts[2], nil
+}
+
+func (s *fileSecret) versionMetadata(version int) map[string]any {
+	v := s.Versions[version]
+	return map[string]any{
+		"version":       json.Number(strconv.Itoa(version)),
+		"created_time":  v.CreatedTime,
+		"deletion_time": v.DeletionTime,
+		"destroyed":     v.Destroyed,
+	}
+}
+
+func decodeBackendData(raw json.RawMessage) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	data := map[string]any{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (f *fileBackend) readVersion(path string, version int) (*api.Secret, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	kind, key, err := splitBackendPath(path)
+	if err != nil {
+		return nil, err
+	}
+	secret, ok := f.secrets[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if kind == "metadata" {
+		versions := map[string]any{}
+		for v := range secret.Versions {
+			versions[strconv.Itoa(v)] = secret.versionMetadata(v)
+		}
+		return &api.Secret{Data: map[string]any{
+			"current_version": json.Number(strconv.Itoa(secret.CurrentVersion)),
+			"created_time":    secret.CreatedTime,
+			"updated_time":    secret.UpdatedTime,
+			"versions":        versions,
+		}}, nil
+	}
+
+	if version == 0 {
+		version = secret.CurrentVersion
+	}
+	v, ok := secret.Versions[version]
+	if !ok {
+		return nil, nil
+	}
+	response := &api.Secret{Data: map[string]any{
+		"data":     nil,
+		"metadata": secret.versionMetadata(version),
+	}}
+	if v.DeletionTime == "" && !v.Destroyed && len(v.Data) > 0 {
+		data, err := decodeBackendData(v.Data)
+		if err != nil {
+			return nil, err
+		}
+		response.Data["data"] = data
+	}
+	return response, nil
+}
+
+func (f *fileBackend) Read(path string) (*api.Secret, error) {
+	return f.readVersion(path, 0)
+}
+
+func (f *fileBackend) ReadWithData(path string, data map[string][]string) (*api.Secret, error) {
+	version := 0
+	if versions, ok := data["version"]; ok && len(versions) > 0 {
+		var err error
+		version, err = strconv.Atoi(versions[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", versions[0])
+		}
+	}
+	return f.readVersion(path, version)
+}
+
+func (f *fileBackend) Write(path string, data map[string]any) (*api.Secret, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	kind, key, err := splitBackendPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "data" {
+		return nil, fmt.Errorf("file backend only supports writes to data paths: %s", path)
+	}
+	raw, err := json.Marshal(data["data"])
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	secret, ok := f.secrets[key]
+	if !ok {
+		secret = &fileSecret{CreatedTime: now, Versions: map[int]*fileSecretVersion{}}
+		f.secrets[key] = secret
+	}
+	secret.CurrentVersion++
+	secret.UpdatedTime = now
+	secret.Versions[secret.CurrentVersion] = &fileSecretVersion{Data: raw, CreatedTime: now}
+
+	if err := f.save(); err != nil {
+		return nil, err
+	}
+	return &api.Secret{Data: secret.versionMetadata(secret.CurrentVersion)}, nil
+}
+
+func (f *fileBackend) List(path string) (*api.Secret, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	_, key, err := splitBackendPath(path)
+	if err != nil {
+		return nil, err
+	}
+	prefix := key + "/"
+	children := map[string]bool{}
+	for secretKey := range f.secrets {
+		if !strings.HasPrefix(secretKey, prefix) {
+			continue
+		}
+		child, _, isFolder := strings.Cut(strings.TrimPrefix(secretKey, prefix), "/")
+		if isFolder {
+			child += "/"
+		}
+		children[child] = true
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(children))
+	for child := range children {
+		keys = append(keys, child)
+	}
+	sort.Strings(keys)
+	listKeys := make([]any, len(keys))
+	for i, k := range keys {
+		listKeys[i] = k
+	}
+	return &api.Secret{Data: map[string]any{"keys": listKeys}}, nil
+}
+
+// Delete soft deletes the latest version of a data path, or removes every
+// version of a metadata path.
+func (f *fileBackend) Delete(path string) (*api.Secret, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	kind, key, err := splitBackendPath(path)
+	if err != nil {
+		return nil, err
+	}
+	secret, ok := f.secrets[key]
+	if !ok {
+		return nil, nil
+	}
+	if kind == "metadata" {
+		delete(f.secrets, key)
+	} else if v, ok := secret.Versions[secret.CurrentVersion]; ok {
+		v.DeletionTime = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	return nil, f.save()
+}
+
+func (f *fileBackend) Address() string {
+	if f.storePath == "" {
+		return fileBackendScheme + "memory"
+	}
+	return fileBackendScheme + f.storePath
+}
+
+// SetToken is a no-op: whoever can read the key can read the store.
+func (f *fileBackend) SetToken(token string) {}
+
+// LookupSelf reports a root token so environment validation passes.
+func (f *fileBackend) LookupSelf() (*api.Secret, error) {
+	if f.storePath != "" && f.key == nil {
+		return nil, errors.New("file backend key not loaded")
+	}
+	return &api.Secret{
+		Data: map[string]any{"policies": []any{"root"}},
+		Auth: &api.SecretAuth{Policies: []string{"root"}, TokenPolicies: []string{"root"}},
+	}, nil
+}
+
+func (f *fileBackend) CloseIdleConnections() {}
@@ -0,0 +1,79 @@
+package kv
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryBackendModifier(t *testing.T) {
+	logger := log.New(os.Stderr, "", 0)
+	mod := NewModifierWithBackend(NewMemoryBackend(), "dev", nil)
+	mod.Env = "dev"
+
+	if _, err := mod.Write("super-secrets/Restricted/Foo", map[string]any{"password": "one"}, logger); err != nil {
+		t.Fatalf("Expected write to succeed, got %v", err)
+	}
+	if _, err := mod.Write("super-secrets/Restricted/Foo", map[string]any{"password": "two"}, logger); err != nil {
+		t.Fatalf("Expected write to succeed, got %v", err)
+	}
+	if value, err := mod.ReadValue("super-secrets/Restricted/Foo", "password"); err != nil || value != "two" {
+		t.Fatalf("Expected latest value two, got %q %v", value, err)
+	}
+
+	mod.Version = "1"
+	if value, err := mod.ReadValue("super-secrets/Restricted/Foo", "password"); err != nil || value != "one" {
+		t.Fatalf("Expected version 1 value one, got %q %v", value, err)
+	}
+	mod.Version = ""
+
+	versions, err := mod.ReadVersionMetadata("super-secrets/Restricted/Foo", logger)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got %v %v", versions, err)
+	}
+
+	secret, err := mod.List("super-secrets/Restricted", logger)
+	if err != nil || secret == nil || len(secret.Data["keys"].([]any)) != 1 {
+		t.Fatalf("Expected one key listed, got %v %v", secret, err)
+	}
+
+	if _, err := mod.SoftDelete("super-secrets/Restricted/Foo", logger); err != nil {
+		t.Fatalf("Expected soft delete to succeed, got %v", err)
+	}
+	if data, _ := mod.ReadData("super-secrets/Restricted/Foo"); data != nil {
+		t.Fatalf("Expected no data after soft delete, got %v", data)
+	}
+}
+
+func TestFileBackendPersists(t *testing.T) {
+	logger := log.New(os.Stderr, "", 0)
+	storePath := filepath.Join(t.TempDir(), "secrets.trckv")
+	address := fileBackendScheme + storePath
+
+	mod, err := NewModifier(false, nil, &address, "dev", nil, false, logger)
+	if err != nil {
+		t.Fatalf("Expected file backend modifier, got %v", err)
+	}
+	mod.Env = "dev"
+	if _, err := mod.Write("values/Index/Foo", map[string]any{"endpoint": "https://example"}, logger); err != nil {
+		t.Fatalf("Expected write to succeed, got %v", err)
+	}
+	if _, err := os.Stat(storePath + ".key"); err != nil {
+		t.Fatalf("Expected generated key, got %v", err)
+	}
+
+	// Force a fresh load from disk.
+	fileBackendsLock.Lock()
+	delete(fileBackends, storePath)
+	fileBackendsLock.Unlock()
+
+	reopened, err := NewModifier(false, nil, &address, "dev", nil, false, logger)
+	if err != nil {
+		t.Fatalf("Expected file backend to reopen, got %v", err)
+	}
+	reopened.Env = "dev"
+	if value, err := reopened.ReadValue("values/Index/Foo", "endpoint"); err != nil || value != "https://example" {
+		t.Fatalf("Expected persisted value, got %q %v", value, err)
+	}
+}
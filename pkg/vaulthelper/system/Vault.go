@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/trimble-oss/tierceron/pkg/vaulthelper/audit"
 	helperkv "github.com/trimble-oss/tierceron/pkg/vaulthelper/kv"
 
 	"github.com/hashicorp/vault/api"
@@ -429,12 +430,28 @@ func (v *Vault) CreatePolicyFromFile(name string, filepath string) error {
 	if err != nil {
 		return err
 	}
-	return v.client.Sys().PutPolicy(name, string(data))
+	err = v.client.Sys().PutPolicy(name, string(data))
+	v.recordAudit(audit.ActionPolicyCreate, "sys/policy/"+name, nil, err)
+	return err
 }
 
 // CreateEmptyPolicy Creates a policy with no permissions
 func (v *Vault) CreateEmptyPolicy(name string) error {
-	return v.client.Sys().PutPolicy(name, "")
+	err := v.client.Sys().PutPolicy(name, "")
+	v.recordAudit(audit.ActionPolicyCreate, "sys/policy/"+name, nil, err)
+	return err
+}
+
+// recordAudit records the outcome of a change made with this vault's token.
+func (v *Vault) recordAudit(action string, path string, keys []string, err error) {
+	if !audit.Enabled() {
+		return
+	}
+	var actor *audit.Actor
+	if secret, lookupErr := v.client.Auth().Token().LookupSelf(); lookupErr == nil {
+		actor = audit.ActorFromLookup(secret)
+	}
+	audit.Record(actor, action, "", path, keys, err)
 }
 
 // ValidateEnvironment Ensures token has access to requested data.
@@ -501,11 +518,16 @@ func (v *Vault) CreateTokenFromFile(filename string) (string, error) {
 	token := api.TokenCreateRequest{}
 	yaml.Unmarshal(tokenfile, &token)
 
+	// Keys of the token file are audited, never its values.
+	tokenFields := map[string]any{}
+	yaml.Unmarshal(tokenfile, &tokenFields)
+
 	tokenRole := YamlNewTokenRoleOptions{}
 	yamlErr := yaml.Unmarshal(tokenfile, &tokenRole)
 	if yamlErr == nil {
 		if tokenRole.RoleName != "" {
 			response, err := v.client.Auth().Token().CreateWithRole(&token, tokenRole.RoleName)
+			v.recordAudit(audit.ActionTokenCreate, "auth/token/create/"+tokenRole.RoleName, audit.Keys(tokenFields), err)
 			if err != nil {
 				return "", err
 			}
@@ -514,6 +536,7 @@ func (v *Vault) CreateTokenFromFile(filename string) (string, error) {
 	}
 
 	response, err := v.client.Auth().Token().Create(&token)
+	v.recordAudit(audit.ActionTokenCreate, "auth/token/create", audit.Keys(tokenFields), err)
 	if err != nil {
 		return "", err
 	}
@@ -563,6 +586,7 @@ func (v *Vault) CreateTokenFromMap(data map[string]any) (string, error) {
 	}
 
 	response, err := v.client.Auth().Token().Create(token)
+	v.recordAudit(audit.ActionTokenCreate, "auth/token/create", audit.Keys(data), err)
 	if response == nil {
 		return "", err
 	}